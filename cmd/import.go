@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/Ravwvil/feedback/internal/bundle"
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+)
+
+const importChunkSize = 1024 * 64
+
+// runImport implements the "import" subcommand, it returns the process exit code
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:9090", "gRPC address of the feedback service")
+	dryRun := flags.Bool("dry-run", false, "validate bundles without creating feedback")
+	userID := flags.Int64("user-id", 0, "ID of the instructor the feedback is imported as")
+	role := flags.String("role", "instructor", "role of the importing user")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: feedback-service import [flags] <bundle.zip|directory>...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 || *userID <= 0 {
+		flags.Usage()
+		return 2
+	}
+
+	items, err := collectImportItems(flags.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to collect bundles: %v\n", err)
+		return 1
+	}
+	if len(items) == 0 {
+		fmt.Fprintln(os.Stderr, "No bundles found")
+		return 1
+	}
+
+	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect: %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+
+	// Without a gateway in front the caller is passed the way the gateway would
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"x-user-id", strconv.FormatInt(*userID, 10), "x-user-role", *role)
+
+	response, err := sendImport(ctx, proto.NewFeedbackServiceClient(conn), items, *dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Import failed: %v\n", err)
+		return 1
+	}
+
+	for _, result := range response.Results {
+		if result.Success {
+			fmt.Printf("OK    %s %s\n", result.Name, result.FeedbackId)
+		} else {
+			fmt.Printf("FAIL  %s: %s\n", result.Name, result.Error)
+		}
+	}
+
+	mode := ""
+	if response.DryRun {
+		mode = " (dry run)"
+	}
+	fmt.Printf("%d succeeded, %d failed%s\n", response.Succeeded, response.Failed, mode)
+
+	if response.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// collectImportItems expands arguments into zip files and unpacked bundle directories
+func collectImportItems(paths []string) ([]string, error) {
+	var items []string
+
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if entry.IsDir() {
+				if bundle.IsDir(path) {
+					items = append(items, path)
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if strings.EqualFold(filepath.Ext(path), ".zip") {
+				items = append(items, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return items, nil
+}
+
+func sendImport(ctx context.Context, client proto.FeedbackServiceClient, items []string, dryRun bool) (*proto.ImportFeedbackResponse, error) {
+	stream, err := client.ImportFeedback(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = stream.Send(&proto.ImportFeedbackRequest{
+		Data: &proto.ImportFeedbackRequest_Options{
+			Options: &proto.ImportOptions{DryRun: dryRun},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		if err := sendBundle(stream, item); err != nil {
+			return nil, fmt.Errorf("%s: %w", item, err)
+		}
+	}
+
+	return stream.CloseAndRecv()
+}
+
+func sendBundle(stream proto.FeedbackService_ImportFeedbackClient, item string) error {
+	// Zipped directories are streamed without a known size
+	header := &proto.ImportBundleHeader{Name: item}
+	if !bundle.IsDir(item) {
+		info, err := os.Stat(item)
+		if err != nil {
+			return err
+		}
+		header.TotalSize = info.Size()
+	}
+
+	err := stream.Send(&proto.ImportFeedbackRequest{
+		Data: &proto.ImportFeedbackRequest_Bundle{Bundle: header},
+	})
+	if err != nil {
+		return err
+	}
+
+	var reader io.Reader
+	if bundle.IsDir(item) {
+		// Directories are zipped on the fly
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(bundle.WriteDir(pw, item))
+		}()
+		defer pr.Close()
+		reader = pr
+	} else {
+		file, err := os.Open(item)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	buffer := make([]byte, importChunkSize)
+	for {
+		n, readErr := reader.Read(buffer)
+		if n > 0 {
+			err := stream.Send(&proto.ImportFeedbackRequest{
+				Data: &proto.ImportFeedbackRequest_Chunk{Chunk: buffer[:n]},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
@@ -1,21 +1,40 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net"
+	"os"
+	"sort"
 
+	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	
 	"github.com/Ravwvil/feedback/internal/config"
 	"github.com/Ravwvil/feedback/internal/database"
+	"github.com/Ravwvil/feedback/internal/events"
 	pb "github.com/Ravwvil/feedback/internal/grpc/proto"
 	grpcServer "github.com/Ravwvil/feedback/internal/grpc"
+	"github.com/Ravwvil/feedback/internal/handlers"
+	"github.com/Ravwvil/feedback/internal/lookup"
+	"github.com/Ravwvil/feedback/internal/middleware"
+	"github.com/Ravwvil/feedback/internal/notify"
+	"github.com/Ravwvil/feedback/internal/outbox"
 	"github.com/Ravwvil/feedback/internal/repository"
+	"github.com/Ravwvil/feedback/internal/scanner"
 	"github.com/Ravwvil/feedback/internal/service"
 	"github.com/Ravwvil/feedback/internal/storage"
+	"github.com/Ravwvil/feedback/internal/thumbnail"
+	"github.com/Ravwvil/feedback/internal/webhook"
 )
 
 func main() {
+	// Subcommands run as clients of an already running service
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(runImport(os.Args[2:]))
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -44,8 +63,217 @@ func main() {
 		log.Fatalf("Failed to initialize MinIO client: %v", err)
 	}
 
+	// Initialize thumbnail generator
+	var thumbnails *thumbnail.Generator
+	if len(cfg.ThumbnailSizes) > 0 {
+		sizes := append([]int(nil), cfg.ThumbnailSizes...)
+		sort.Ints(sizes)
+		thumbnails = thumbnail.NewGenerator(sizes, cfg.ThumbnailMaxPixels)
+	}
+
+	// Initialize malware scanner
+	var assetScanner scanner.AssetScanner
+	switch cfg.AssetScanner {
+	case "signature":
+		assetScanner = scanner.NewSignatureScanner()
+	case "clamd":
+		assetScanner, err = scanner.NewClamdScanner(cfg.ClamdAddress, cfg.ClamdTimeout)
+		if err != nil {
+			log.Fatalf("Failed to initialize clamd scanner: %v", err)
+		}
+	case "none":
+	default:
+		log.Fatalf("Unknown asset scanner %q", cfg.AssetScanner)
+	}
+
+	// Initialize change events, with several replicas they travel through PostgreSQL
+	eventBus := events.NewBus(int(cfg.EventsBufferSize))
+	var eventPublisher events.Publisher
+	switch cfg.EventsBackend {
+	case "local":
+	case "postgres":
+		eventPublisher = events.NewPostgresPublisher(db)
+		// Listen only returns once its context is cancelled, failures are retried in the background
+		go events.Listen(context.Background(), database.DSN(cfg), eventBus)
+	default:
+		log.Fatalf("Unknown events backend %q", cfg.EventsBackend)
+	}
+
+	// Validate user and lab ids against the external services, caching their answers
+	lookupCache := lookup.CacheOptions{
+		PositiveTTL: cfg.LookupPositiveTTL,
+		NegativeTTL: cfg.LookupNegativeTTL,
+		Capacity:    int(cfg.LookupCacheSize),
+	}
+	var users lookup.UserService
+	if cfg.UserServiceAddress != "" {
+		conn, err := grpc.NewClient(cfg.UserServiceAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Fatalf("Failed to initialize user service client: %v", err)
+		}
+		defer conn.Close()
+		users = lookup.NewCachedUsers(grpcServer.NewUserClient(conn, cfg.DependencyTimeout), lookupCache)
+	}
+	var labs lookup.LabService
+	if cfg.LabServiceAddress != "" {
+		conn, err := grpc.NewClient(cfg.LabServiceAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Fatalf("Failed to initialize lab service client: %v", err)
+		}
+		defer conn.Close()
+		labs = lookup.NewCachedLabs(grpcServer.NewLabClient(conn, cfg.DependencyTimeout), lookupCache)
+	}
+
 	// Initialize service
-	feedbackService := service.NewFeedbackService(feedbackRepo, minioClient)
+	feedbackService := service.NewFeedbackService(feedbackRepo, minioClient, service.Options{
+		Quotas: service.QuotaLimits{
+			MaxAssetsPerFeedback: cfg.MaxAssetsPerFeedback,
+			MaxBytesPerFeedback:  cfg.MaxBytesPerFeedback,
+			MaxBytesPerUser:      cfg.MaxBytesPerUser,
+			MaxAssetSize:         cfg.MaxAssetSize,
+		},
+		Thumbnails:           thumbnails,
+		ThumbnailConcurrency: int(cfg.ThumbnailConcurrency),
+		AllowedContentTypes:  cfg.AllowedAssetTypes,
+		Scanner:              assetScanner,
+		RenderCacheSize:      int(cfg.RenderCacheSize),
+		AssetURLs: service.AssetURLOptions{
+			Mode:          cfg.AssetURLMode,
+			BaseURL:       cfg.AssetBaseURL,
+			PresignExpiry: cfg.AssetPresignExpiry,
+		},
+		TrashRetention: cfg.TrashRetention,
+		Batch: service.BatchOptions{
+			MaxItems:    int(cfg.BatchMaxItems),
+			Concurrency: int(cfg.BatchConcurrency),
+		},
+		IdempotencyTTL: cfg.IdempotencyTTL,
+		EventBus:       eventBus,
+		EventPublisher: eventPublisher,
+		Users:          users,
+		Labs:           labs,
+		FailOpen:       cfg.DependencyFailOpen,
+	})
+
+	// Permanently remove feedback whose trash retention has expired
+	if cfg.TrashRetention > 0 && cfg.TrashPurgeInterval > 0 {
+		go feedbackService.RunPurgeJob(context.Background(), cfg.TrashPurgeInterval)
+	}
+	if cfg.IdempotencyCleanupInterval > 0 {
+		go feedbackService.RunIdempotencyCleanup(context.Background(), cfg.IdempotencyCleanupInterval)
+	}
+	// Scan assets again whose scan failed while the scanner was unavailable
+	if assetScanner != nil && cfg.AssetRescanInterval > 0 {
+		go feedbackService.RunRescanJob(context.Background(), cfg.AssetRescanInterval)
+	}
+
+	// Relay domain events from the outbox to the configured publishers
+	var outboxPublishers outbox.MultiPublisher
+	switch cfg.OutboxPublisher {
+	case "file":
+		filePublisher, err := outbox.NewFilePublisher(cfg.OutboxFile)
+		if err != nil {
+			log.Fatalf("Failed to initialize outbox publisher: %v", err)
+		}
+		defer filePublisher.Close()
+		outboxPublishers = append(outboxPublishers, filePublisher)
+	case "none":
+	default:
+		log.Fatalf("Unknown outbox publisher %q", cfg.OutboxPublisher)
+	}
+
+	// Webhook deliveries are queued by the relay and sent by the dispatcher
+	if cfg.WebhooksEnabled {
+		outboxPublishers = append(outboxPublishers, webhook.NewPublisher(feedbackRepo))
+		dispatcher := webhook.NewDispatcher(feedbackRepo, webhook.DispatcherOptions{
+			Timeout:      cfg.WebhookTimeout,
+			MaxAttempts:  int(cfg.WebhookMaxAttempts),
+			DisableAfter: int(cfg.WebhookDisableAfter),
+			Concurrency:  int(cfg.WebhookConcurrency),
+			Retention:    cfg.WebhookDeliveryRetention,
+		})
+		go dispatcher.Run(context.Background())
+	}
+
+	// Notifications are queued by the relay, immediate ones are emailed by the sender
+	if cfg.NotificationsEnabled {
+		var directory notify.Directory
+		switch cfg.NotifyDirectory {
+		case "file":
+			directory, err = notify.LoadDirectoryFile(cfg.NotifyDirectoryFile)
+		case "pattern":
+			directory, err = notify.NewPatternDirectory(cfg.NotifyEmailPattern)
+		default:
+			log.Fatalf("Unknown notification directory %q", cfg.NotifyDirectory)
+		}
+		if err != nil {
+			log.Fatalf("Failed to initialize notification directory: %v", err)
+		}
+
+		mailer, err := notify.NewSMTPMailer(notify.SMTPOptions{
+			Host:     cfg.SMTPHost,
+			Port:     int(cfg.SMTPPort),
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			StartTLS: cfg.SMTPStartTLS,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize mailer: %v", err)
+		}
+
+		renderer, err := notify.NewRenderer()
+		if err != nil {
+			log.Fatalf("Failed to load notification templates: %v", err)
+		}
+
+		weekday, err := notify.ParseWeekday(cfg.DigestWeekday)
+		if err != nil {
+			log.Fatalf("Invalid DIGEST_WEEKDAY: %v", err)
+		}
+		digests, err := notify.NewDigestJob(feedbackRepo, directory, mailer, renderer, notify.DigestOptions{
+			Hour:        int(cfg.DigestHour),
+			Weekday:     weekday,
+			Interval:    cfg.DigestInterval,
+			MaxAttempts: int(cfg.NotifyMaxAttempts),
+			FeedbackURL: cfg.NotifyFeedbackURL,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize digest job: %v", err)
+		}
+
+		outboxPublishers = append(outboxPublishers, notify.NewNotifier(feedbackRepo))
+		sender := notify.NewSender(feedbackRepo, directory, mailer, renderer, notify.SenderOptions{
+			MaxAttempts: int(cfg.NotifyMaxAttempts),
+			FeedbackURL: cfg.NotifyFeedbackURL,
+		})
+		go sender.Run(context.Background())
+		go digests.Run(context.Background())
+	}
+
+	if len(outboxPublishers) > 0 {
+		relay := outbox.NewRelay(feedbackRepo, outboxPublishers, outbox.RelayOptions{
+			BatchSize:    int(cfg.OutboxBatchSize),
+			PollInterval: cfg.OutboxPollInterval,
+			Retention:    cfg.OutboxRetention,
+		})
+		go relay.Run(context.Background())
+	}
+
+	// Asset downloads and exports are served over HTTP behind the same gateway as gRPC
+	if cfg.HTTPPort != "" {
+		gin.SetMode(gin.ReleaseMode)
+		router := gin.New()
+		router.Use(gin.Recovery(), middleware.Caller())
+		handlers.NewFeedbackFileHandler(feedbackService).RegisterRoutes(router)
+
+		log.Printf("Starting HTTP file server on port %s", cfg.HTTPPort)
+		go func() {
+			if err := router.Run(":" + cfg.HTTPPort); err != nil {
+				log.Fatalf("Failed to start HTTP server: %v", err)
+			}
+		}()
+	}
 
 	// Initialize gRPC server
 	grpcListener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
@@ -53,9 +281,14 @@ func main() {
 		log.Fatalf("Failed to listen on gRPC port: %v", err)
 	}
 
-	grpcSrv := grpc.NewServer()
+	// The caller must be known before idempotency keys, which are scoped per caller, are looked up
+	idempotency := grpcServer.NewIdempotencyInterceptor(feedbackService)
+	grpcSrv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(grpcServer.UnaryCallerInterceptor, idempotency.Unary),
+		grpc.ChainStreamInterceptor(grpcServer.StreamCallerInterceptor, idempotency.Stream),
+	)
 	feedbackGRPCServer := grpcServer.NewFeedbackGRPCServer(feedbackService)
-	proto.RegisterFeedbackServiceServer(grpcSrv, feedbackGRPCServer)
+	pb.RegisterFeedbackServiceServer(grpcSrv, feedbackGRPCServer)
 
 	log.Printf("Starting Minimal Feedback Service (gRPC) on port %s", cfg.GRPCPort)
 	log.Printf("Database: %s:%s/%s", cfg.DBHost, cfg.DBPort, cfg.DBName)
@@ -0,0 +1,91 @@
+package bundle
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Layout of a feedback bundle
+const (
+	ContentFile  = "content.md"
+	AssetsDir    = "assets/"
+	MetadataFile = "metadata.json"
+)
+
+// Metadata is stored as metadata.json and describes the bundled feedback
+type Metadata struct {
+	ID          string    `json:"id,omitempty"`
+	UserID      int64     `json:"user_id"`
+	LabID       int64     `json:"lab_id"`
+	Title       string    `json:"title"`
+	ContentHash string    `json:"content_hash"`
+	Status      string    `json:"status,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Assets      []Asset   `json:"assets"`
+}
+
+// Asset describes a bundled asset file
+type Asset struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+}
+
+// Writer streams a bundle as a zip archive, entries are compressed and written as they are added
+type Writer struct {
+	zw *zip.Writer
+}
+
+// NewWriter creates a bundle writer on top of w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		zw: zip.NewWriter(w),
+	}
+}
+
+// WriteFile copies r into the archive under name and returns the number of bytes and their SHA-256
+func (w *Writer) WriteFile(name string, modified time.Time, r io.Reader) (int64, string, error) {
+	entry, err := w.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create %s: %w", name, err)
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(entry, hash), r)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return size, fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// Close writes metadata.json and finishes the archive
+func (w *Writer) Close(metadata *Metadata) error {
+	entry, err := w.zw.CreateHeader(&zip.FileHeader{
+		Name:     MetadataFile,
+		Method:   zip.Deflate,
+		Modified: metadata.UpdatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", MetadataFile, err)
+	}
+
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(metadata); err != nil {
+		return fmt.Errorf("failed to write %s: %w", MetadataFile, err)
+	}
+
+	return w.zw.Close()
+}
@@ -0,0 +1,63 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriter(t *testing.T) {
+	modified := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	content := "# Feedback\n\nWell done.\n"
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+
+	size, hash, err := w.WriteFile(ContentFile, modified, strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != int64(len(content)) || hash != fmt.Sprintf("%x", sha256.Sum256([]byte(content))) {
+		t.Errorf("got size %d hash %s", size, hash)
+	}
+
+	metadata := &Metadata{Title: "Lab 1", UserID: 7, LabID: 3, ContentHash: hash, UpdatedAt: modified}
+	if err := w.Close(metadata); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != 2 || zr.File[0].Name != ContentFile || zr.File[1].Name != MetadataFile {
+		t.Fatalf("unexpected entries %v", zr.File)
+	}
+	if !zr.File[0].Modified.Equal(modified) {
+		t.Errorf("got modified %v, want %v", zr.File[0].Modified, modified)
+	}
+
+	rc, err := zr.File[1].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Metadata
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Title != "Lab 1" || decoded.UserID != 7 || decoded.ContentHash != hash {
+		t.Errorf("got metadata %+v", decoded)
+	}
+}
@@ -0,0 +1,68 @@
+package bundle
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// IsDir reports whether dir is an unpacked bundle, i.e. contains content.md
+func IsDir(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, ContentFile))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// WriteDir packs an unpacked bundle directory into a zip archive written to w
+func WriteDir(w io.Writer, dir string) error {
+	zw := zip.NewWriter(w)
+
+	names := []string{ContentFile, MetadataFile}
+
+	assetEntries, err := os.ReadDir(filepath.Join(dir, AssetsDir))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read assets of %s: %w", dir, err)
+	}
+	for _, entry := range assetEntries {
+		if entry.Type().IsRegular() {
+			names = append(names, AssetsDir+entry.Name())
+		}
+	}
+
+	for _, name := range names {
+		if err := addFile(zw, dir, name); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func addFile(zw *zip.Writer, dir, name string) error {
+	file, err := os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", name, err)
+	}
+
+	entry, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: info.ModTime(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+
+	if _, err := io.Copy(entry, file); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package bundle
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrInvalidBundle is returned when a bundle does not follow the expected layout
+var ErrInvalidBundle = errors.New("invalid bundle")
+
+// maxMetadataSize bounds metadata.json to protect against oversized entries
+const maxMetadataSize = 1 << 20
+
+// Limits applied to every bundle, whatever the caller configures
+const (
+	MaxFileSize         = 64 << 20  // Largest content.md or asset
+	MaxUncompressedSize = 256 << 20 // Largest total of all files read from one bundle
+)
+
+// Reader gives access to the validated contents of a bundle
+type Reader struct {
+	Metadata *Metadata
+	Content  []byte
+
+	assets    map[string]*zip.File
+	remaining int64 // Bytes that may still be decompressed
+}
+
+// Open reads and validates a zip bundle. maxFileSize bounds content.md and each asset, it is capped
+// at MaxFileSize and 0 means MaxFileSize.
+func Open(r io.ReaderAt, size int64, maxFileSize int64) (*Reader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+
+	var contentFile, metadataFile *zip.File
+	assets := make(map[string]*zip.File)
+
+	for _, file := range zr.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		name := file.Name
+		if !isSafePath(name) {
+			return nil, fmt.Errorf("%w: unsafe path %q", ErrInvalidBundle, name)
+		}
+
+		switch {
+		case name == ContentFile:
+			contentFile = file
+		case name == MetadataFile:
+			metadataFile = file
+		case strings.HasPrefix(name, AssetsDir):
+			filename := strings.TrimPrefix(name, AssetsDir)
+			if strings.Contains(filename, "/") {
+				return nil, fmt.Errorf("%w: nested asset %q", ErrInvalidBundle, name)
+			}
+			assets[filename] = file
+		default:
+			return nil, fmt.Errorf("%w: unexpected file %q", ErrInvalidBundle, name)
+		}
+	}
+
+	if contentFile == nil {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidBundle, ContentFile)
+	}
+	if metadataFile == nil {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidBundle, MetadataFile)
+	}
+
+	reader := &Reader{
+		assets:    assets,
+		remaining: MaxUncompressedSize,
+	}
+
+	rawMetadata, err := reader.readFile(metadataFile, maxMetadataSize)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &Metadata{}
+	if err := json.Unmarshal(rawMetadata, metadata); err != nil {
+		return nil, fmt.Errorf("%w: malformed %s: %v", ErrInvalidBundle, MetadataFile, err)
+	}
+	if err := validateMetadata(metadata, assets); err != nil {
+		return nil, err
+	}
+
+	content, err := reader.readFile(contentFile, maxFileSize)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.ContentHash != "" && metadata.ContentHash != fmt.Sprintf("%x", sha256.Sum256(content)) {
+		return nil, fmt.Errorf("%w: %s does not match content_hash", ErrInvalidBundle, ContentFile)
+	}
+
+	reader.Metadata = metadata
+	reader.Content = content
+	return reader, nil
+}
+
+// ReadAsset returns the contents of an asset listed in the metadata, verifying its hash
+func (r *Reader) ReadAsset(asset Asset, maxSize int64) ([]byte, error) {
+	file, ok := r.assets[asset.Filename]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing asset %q", ErrInvalidBundle, asset.Filename)
+	}
+
+	data, err := r.readFile(file, maxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if asset.SHA256 != "" && asset.SHA256 != fmt.Sprintf("%x", sha256.Sum256(data)) {
+		return nil, fmt.Errorf("%w: asset %q does not match its sha256", ErrInvalidBundle, asset.Filename)
+	}
+
+	return data, nil
+}
+
+func validateMetadata(metadata *Metadata, assets map[string]*zip.File) error {
+	if metadata.UserID <= 0 {
+		return fmt.Errorf("%w: user_id is required", ErrInvalidBundle)
+	}
+	if strings.TrimSpace(metadata.Title) == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidBundle)
+	}
+	if !metadata.UpdatedAt.IsZero() && metadata.UpdatedAt.Before(metadata.CreatedAt) {
+		return fmt.Errorf("%w: updated_at is before created_at", ErrInvalidBundle)
+	}
+
+	// Every asset file has to be described in metadata.json and vice versa
+	listed := make(map[string]bool, len(metadata.Assets))
+	for _, asset := range metadata.Assets {
+		if listed[asset.Filename] {
+			return fmt.Errorf("%w: duplicate asset %q", ErrInvalidBundle, asset.Filename)
+		}
+		if _, ok := assets[asset.Filename]; !ok {
+			return fmt.Errorf("%w: missing asset %q", ErrInvalidBundle, asset.Filename)
+		}
+		listed[asset.Filename] = true
+	}
+	for filename := range assets {
+		if !listed[filename] {
+			return fmt.Errorf("%w: asset %q is not listed in %s", ErrInvalidBundle, filename, MetadataFile)
+		}
+	}
+
+	return nil
+}
+
+// readFile decompresses a file within both its own limit and what is left of the bundle limit
+func (r *Reader) readFile(file *zip.File, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 || maxSize > MaxFileSize {
+		maxSize = MaxFileSize
+	}
+	if file.UncompressedSize64 > uint64(maxSize) {
+		return nil, fmt.Errorf("%w: %s exceeds %d bytes", ErrInvalidBundle, file.Name, maxSize)
+	}
+
+	limit := min(maxSize, r.remaining)
+
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to open %s: %v", ErrInvalidBundle, file.Name, err)
+	}
+	defer rc.Close()
+
+	// The declared size may lie, never read more than the limit
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read %s: %v", ErrInvalidBundle, file.Name, err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%w: %s exceeds %d bytes", ErrInvalidBundle, file.Name, maxSize)
+	}
+	if int64(len(data)) > r.remaining {
+		return nil, fmt.Errorf("%w: bundle exceeds %d uncompressed bytes", ErrInvalidBundle, int64(MaxUncompressedSize))
+	}
+
+	r.remaining -= int64(len(data))
+	return data, nil
+}
+
+func isSafePath(name string) bool {
+	return name != "" && !strings.HasPrefix(name, "/") && !strings.Contains(name, "\\") &&
+		path.Clean(name) == name && !strings.HasPrefix(name, "../")
+}
@@ -0,0 +1,150 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testBundle writes a bundle with one asset, edit adjusts the metadata before it is written
+func testBundle(t *testing.T, edit func(*Metadata)) []byte {
+	t.Helper()
+
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+
+	_, contentHash, err := w.WriteFile(ContentFile, created, strings.NewReader("# Lab 1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	size, assetHash, err := w.WriteFile(AssetsDir+"plot.png", created, strings.NewReader("png data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := &Metadata{
+		UserID:      7,
+		LabID:       3,
+		Title:       "Lab 1",
+		ContentHash: contentHash,
+		CreatedAt:   created,
+		UpdatedAt:   created,
+		Assets:      []Asset{{Filename: "plot.png", ContentType: "image/png", Size: size, SHA256: assetHash}},
+	}
+	if edit != nil {
+		edit(metadata)
+	}
+	if err := w.Close(metadata); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func openBytes(data []byte, maxFileSize int64) (*Reader, error) {
+	return Open(bytes.NewReader(data), int64(len(data)), maxFileSize)
+}
+
+func TestOpenRoundTrip(t *testing.T) {
+	reader, err := openBytes(testBundle(t, nil), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(reader.Content) != "# Lab 1\n" || reader.Metadata.Title != "Lab 1" {
+		t.Errorf("got content %q, metadata %+v", reader.Content, reader.Metadata)
+	}
+
+	data, err := reader.ReadAsset(reader.Metadata.Assets[0], 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "png data" {
+		t.Errorf("got asset %q", data)
+	}
+}
+
+func TestOpenRejectsInvalidBundles(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(*Metadata)
+	}{
+		{"missing user", func(m *Metadata) { m.UserID = 0 }},
+		{"missing title", func(m *Metadata) { m.Title = " " }},
+		{"content hash mismatch", func(m *Metadata) { m.ContentHash = "00" }},
+		{"unlisted asset", func(m *Metadata) { m.Assets = nil }},
+		{"missing asset", func(m *Metadata) { m.Assets = append(m.Assets, Asset{Filename: "other.png"}) }},
+		{"updated before created", func(m *Metadata) { m.UpdatedAt = m.CreatedAt.Add(-time.Hour) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := openBytes(testBundle(t, tt.edit), 0); !errors.Is(err, ErrInvalidBundle) {
+				t.Errorf("got %v, want ErrInvalidBundle", err)
+			}
+		})
+	}
+}
+
+func TestOpenRejectsUnsafePaths(t *testing.T) {
+	for _, name := range []string{"../content.md", "/etc/passwd", "assets/../../x", `assets\x.png`, "assets/sub/x.png", "notes.txt"} {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		if _, err := zw.Create(name); err != nil {
+			t.Fatal(err)
+		}
+		zw.Close()
+
+		if _, err := openBytes(buf.Bytes(), 0); !errors.Is(err, ErrInvalidBundle) {
+			t.Errorf("%q: got %v, want ErrInvalidBundle", name, err)
+		}
+	}
+}
+
+func TestReadAssetVerifiesHash(t *testing.T) {
+	reader, err := openBytes(testBundle(t, nil), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	asset := reader.Metadata.Assets[0]
+	asset.SHA256 = fmt.Sprintf("%x", sha256.Sum256([]byte("other")))
+	if _, err := reader.ReadAsset(asset, 0); !errors.Is(err, ErrInvalidBundle) {
+		t.Errorf("got %v, want ErrInvalidBundle", err)
+	}
+}
+
+func TestFileSizeLimits(t *testing.T) {
+	data := testBundle(t, nil)
+
+	if _, err := openBytes(data, 4); !errors.Is(err, ErrInvalidBundle) {
+		t.Errorf("content over the limit: got %v, want ErrInvalidBundle", err)
+	}
+
+	reader, err := openBytes(data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.ReadAsset(reader.Metadata.Assets[0], 4); !errors.Is(err, ErrInvalidBundle) {
+		t.Errorf("asset over the limit: got %v, want ErrInvalidBundle", err)
+	}
+
+	// Whatever is left of the bundle limit bounds the next file
+	reader.remaining = 4
+	if _, err := reader.ReadAsset(reader.Metadata.Assets[0], 0); !errors.Is(err, ErrInvalidBundle) {
+		t.Errorf("bundle over the limit: got %v, want ErrInvalidBundle", err)
+	}
+
+	reader.remaining = MaxUncompressedSize
+	if _, err := reader.ReadAsset(reader.Metadata.Assets[0], 0); err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(MaxUncompressedSize - len("png data")); reader.remaining != want {
+		t.Errorf("got %d bytes remaining, want %d", reader.remaining, want)
+	}
+}
@@ -1,13 +1,15 @@
 package config
 
 import (
-	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	GRPCPort string
+	HTTPPort string
 	
 	DBHost     string
 	DBPort     string
@@ -20,11 +22,83 @@ type Config struct {
 	MinIOSecretKey  string
 	MinIOBucketName string
 	MinIOUseSSL     bool
+	
+	MaxAssetsPerFeedback int64
+	MaxBytesPerFeedback  int64
+	MaxBytesPerUser      int64
+	MaxAssetSize         int64
+	
+	ThumbnailSizes       []int
+	ThumbnailMaxPixels   int64
+	ThumbnailConcurrency int64
+	
+	AllowedAssetTypes []string
+	
+	AssetScanner        string
+	ClamdAddress        string
+	ClamdTimeout        time.Duration
+	AssetRescanInterval time.Duration
+	
+	RenderCacheSize int64
+	
+	AssetURLMode       string
+	AssetBaseURL       string
+	AssetPresignExpiry time.Duration
+	
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+	
+	BatchMaxItems    int64
+	BatchConcurrency int64
+	
+	IdempotencyTTL             time.Duration
+	IdempotencyCleanupInterval time.Duration
+	
+	EventsBackend    string
+	EventsBufferSize int64
+	
+	OutboxPublisher    string
+	OutboxFile         string
+	OutboxBatchSize    int64
+	OutboxPollInterval time.Duration
+	OutboxRetention    time.Duration
+	
+	WebhooksEnabled          bool
+	WebhookTimeout           time.Duration
+	WebhookMaxAttempts       int64
+	WebhookDisableAfter      int64
+	WebhookConcurrency       int64
+	WebhookDeliveryRetention time.Duration
+	
+	NotificationsEnabled bool
+	NotifyDirectory      string
+	NotifyDirectoryFile  string
+	NotifyEmailPattern   string
+	NotifyFeedbackURL    string
+	NotifyMaxAttempts    int64
+	DigestHour           int64
+	DigestWeekday        string
+	DigestInterval       time.Duration
+	SMTPHost             string
+	SMTPPort             int64
+	SMTPUsername         string
+	SMTPPassword         string
+	SMTPFrom             string
+	SMTPStartTLS         bool
+	
+	UserServiceAddress string
+	LabServiceAddress  string
+	DependencyTimeout  time.Duration
+	DependencyFailOpen bool
+	LookupPositiveTTL  time.Duration
+	LookupNegativeTTL  time.Duration
+	LookupCacheSize    int64
 }
 
 func Load() (*Config, error) {
 	cfg := &Config{
 		GRPCPort: getEnv("GRPC_PORT", "9090"),
+		HTTPPort: getEnv("HTTP_PORT", "8080"),
 		
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "5432"),
@@ -37,6 +111,98 @@ func Load() (*Config, error) {
 		MinIOSecretKey:  getEnv("MINIO_SECRET_KEY", "minioadmin"),
 		MinIOBucketName: getEnv("MINIO_BUCKET_NAME", "feedback-bucket"),
 		MinIOUseSSL:     getEnvBool("MINIO_USE_SSL", false),
+		
+		// Storage quotas, 0 disables the corresponding limit
+		MaxAssetsPerFeedback: getEnvInt64("MAX_ASSETS_PER_FEEDBACK", 50),
+		MaxBytesPerFeedback:  getEnvInt64("MAX_BYTES_PER_FEEDBACK", 100<<20),
+		MaxBytesPerUser:      getEnvInt64("MAX_BYTES_PER_USER", 1<<30),
+		MaxAssetSize:         getEnvInt64("MAX_ASSET_SIZE", 20<<20),
+		
+		// Bounding box sizes of generated image thumbnails, empty disables them
+		ThumbnailSizes:       getEnvIntList("THUMBNAIL_SIZES", []int{128, 512}),
+		ThumbnailMaxPixels:   getEnvInt64("THUMBNAIL_MAX_PIXELS", 40_000_000),
+		ThumbnailConcurrency: getEnvInt64("THUMBNAIL_CONCURRENCY", 2),
+		
+		// Detected asset types accepted on upload, wildcards like image/* are supported
+		AllowedAssetTypes: getEnvList("ALLOWED_ASSET_TYPES", []string{
+			"image/png", "image/jpeg", "image/gif", "image/webp",
+			"application/pdf", "application/zip", "text/plain",
+		}),
+		
+		// Malware scanner: "signature", "clamd" or "none"
+		AssetScanner:        getEnv("ASSET_SCANNER", "signature"),
+		ClamdAddress:        getEnv("CLAMD_ADDRESS", "tcp://localhost:3310"),
+		ClamdTimeout:        getEnvDuration("CLAMD_TIMEOUT", 30*time.Second),
+		AssetRescanInterval: getEnvDuration("ASSET_RESCAN_INTERVAL", 10*time.Minute),
+		
+		// Number of rendered markdown documents cached in memory
+		RenderCacheSize: getEnvInt64("RENDER_CACHE_SIZE", 1024),
+		
+		// Asset references in rendered markdown become "rest" or "presigned" URLs
+		AssetURLMode:       getEnv("ASSET_URL_MODE", "rest"),
+		AssetBaseURL:       getEnv("ASSET_BASE_URL", "/feedback/files"),
+		AssetPresignExpiry: getEnvDuration("ASSET_PRESIGN_EXPIRY", 15*time.Minute),
+		
+		// Deleted feedback stays restorable for the retention period, 0 disables the purge job
+		TrashRetention:     getEnvDuration("TRASH_RETENTION", 30*24*time.Hour),
+		TrashPurgeInterval: getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour),
+		
+		// Batch creation, items are created against the database and MinIO in parallel
+		BatchMaxItems:    getEnvInt64("BATCH_MAX_ITEMS", 500),
+		BatchConcurrency: getEnvInt64("BATCH_CONCURRENCY", 8),
+		
+		// Window in which a retried request with the same idempotency key is answered from storage
+		IdempotencyTTL:             getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		IdempotencyCleanupInterval: getEnvDuration("IDEMPOTENCY_CLEANUP_INTERVAL", time.Hour),
+		
+		// Change events for watch streams: "local" for a single instance or "postgres" to fan out
+		// across replicas with LISTEN/NOTIFY
+		EventsBackend:    getEnv("EVENTS_BACKEND", "local"),
+		EventsBufferSize: getEnvInt64("EVENTS_BUFFER_SIZE", 64),
+		
+		// Domain events are relayed from the outbox table: "file" appends them to OutboxFile,
+		// "none" leaves them in the table
+		OutboxPublisher:    getEnv("OUTBOX_PUBLISHER", "none"),
+		OutboxFile:         getEnv("OUTBOX_FILE", "domain-events.jsonl"),
+		OutboxBatchSize:    getEnvInt64("OUTBOX_BATCH_SIZE", 100),
+		OutboxPollInterval: getEnvDuration("OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxRetention:    getEnvDuration("OUTBOX_RETENTION", 7*24*time.Hour),
+		
+		// Webhook deliveries are retried with exponential backoff, an endpoint failing
+		// WebhookDisableAfter attempts in a row is disabled
+		WebhooksEnabled:          getEnvBool("WEBHOOKS_ENABLED", false),
+		WebhookTimeout:           getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookMaxAttempts:       getEnvInt64("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookDisableAfter:      getEnvInt64("WEBHOOK_DISABLE_AFTER", 20),
+		WebhookConcurrency:       getEnvInt64("WEBHOOK_CONCURRENCY", 4),
+		WebhookDeliveryRetention: getEnvDuration("WEBHOOK_DELIVERY_RETENTION", 30*24*time.Hour),
+		
+		// Email notifications, recipient addresses come from a JSON "file" of users or a
+		// "pattern" such as {id}@students.example.edu
+		NotificationsEnabled: getEnvBool("NOTIFICATIONS_ENABLED", false),
+		NotifyDirectory:      getEnv("NOTIFY_DIRECTORY", "pattern"),
+		NotifyDirectoryFile:  getEnv("NOTIFY_DIRECTORY_FILE", "recipients.json"),
+		NotifyEmailPattern:   getEnv("NOTIFY_EMAIL_PATTERN", ""),
+		NotifyFeedbackURL:    getEnv("NOTIFY_FEEDBACK_URL", ""),
+		NotifyMaxAttempts:    getEnvInt64("NOTIFY_MAX_ATTEMPTS", 5),
+		DigestHour:           getEnvInt64("DIGEST_HOUR", 7),
+		DigestWeekday:        getEnv("DIGEST_WEEKDAY", "monday"),
+		DigestInterval:       getEnvDuration("DIGEST_INTERVAL", 15*time.Minute),
+		SMTPHost:             getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:             getEnvInt64("SMTP_PORT", 25),
+		SMTPUsername:         getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:         getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:             getEnv("SMTP_FROM", "feedback@localhost"),
+		SMTPStartTLS:         getEnvBool("SMTP_STARTTLS", true),
+		
+		// User and Lab Service validating user_id and lab_id, an empty address accepts any id
+		UserServiceAddress: getEnv("USER_SERVICE_ADDRESS", ""),
+		LabServiceAddress:  getEnv("LAB_SERVICE_ADDRESS", ""),
+		DependencyTimeout:  getEnvDuration("DEPENDENCY_TIMEOUT", 2*time.Second),
+		DependencyFailOpen: getEnvBool("DEPENDENCY_FAIL_OPEN", false),
+		LookupPositiveTTL:  getEnvDuration("LOOKUP_POSITIVE_TTL", 10*time.Minute),
+		LookupNegativeTTL:  getEnvDuration("LOOKUP_NEGATIVE_TTL", time.Minute),
+		LookupCacheSize:    getEnvInt64("LOOKUP_CACHE_SIZE", 10000),
 	}
 	
 	return cfg, nil
@@ -63,3 +229,70 @@ func getEnvBool(key string, defaultValue bool) bool {
 	
 	return boolValue
 }
+
+func getEnvInt64(key string, defaultValue int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	intValue, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return defaultValue
+	}
+
+	return intValue
+}
+
+func getEnvIntList(key string, defaultValue []int) []int {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+
+	var values []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		intValue, err := strconv.Atoi(part)
+		if err != nil || intValue <= 0 {
+			return defaultValue
+		}
+		values = append(values, intValue)
+	}
+
+	return values
+}
+
+func getEnvList(key string, defaultValue []string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+
+	return values
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultValue
+	}
+
+	return duration
+}
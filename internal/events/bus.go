@@ -0,0 +1,106 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// DefaultBufferSize is the number of events a subscriber may fall behind before it is dropped
+const DefaultBufferSize = 64
+
+// ErrSubscriberLagged is reported by subscriptions closed because they did not keep up
+var ErrSubscriberLagged = errors.New("subscriber fell behind")
+
+// Bus fans events out to the subscribers of this instance
+type Bus struct {
+	mu          sync.Mutex
+	bufferSize  int
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription receives the events matching its filter until it is closed
+type Subscription struct {
+	bus    *Bus
+	match  func(Event) bool
+	events chan Event
+	err    error
+}
+
+// NewBus creates a bus whose subscribers buffer up to bufferSize events
+func NewBus(bufferSize int) *Bus {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+
+	return &Bus{
+		bufferSize:  bufferSize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe registers a subscriber for the events accepted by match
+func (b *Bus) Subscribe(match func(Event) bool) *Subscription {
+	sub := &Subscription{
+		bus:    b,
+		match:  match,
+		events: make(chan Event, b.bufferSize),
+	}
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub
+}
+
+// Publish delivers event to every matching subscriber without blocking. A subscriber
+// whose buffer is full is closed with ErrSubscriberLagged rather than missing events silently.
+func (b *Bus) Publish(_ context.Context, event Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscribers {
+		if !sub.match(event) {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			b.remove(sub, ErrSubscriberLagged)
+		}
+	}
+
+	return nil
+}
+
+// remove closes a subscription, the caller holds the lock
+func (b *Bus) remove(sub *Subscription, err error) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+
+	delete(b.subscribers, sub)
+	sub.err = err
+	close(sub.events)
+}
+
+// Events returns the channel events are delivered on, it is closed when the subscription ends
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err returns why the events channel was closed, nil after Close
+func (s *Subscription) Err() error {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	return s.err
+}
+
+// Close unsubscribes from the bus
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.remove(s, nil)
+}
@@ -0,0 +1,36 @@
+// Package events distributes feedback change events to watchers.
+//
+// Every instance keeps an in-process Bus that watch streams subscribe to. With a single
+// instance the service publishes straight to the bus, with several replicas events are
+// published through PostgreSQL NOTIFY and every instance feeds its bus from LISTEN.
+package events
+
+import (
+	"context"
+	"time"
+)
+
+// Event types
+const (
+	TypeCreated    = "created"
+	TypeUpdated    = "updated"
+	TypeDeleted    = "deleted"
+	TypeAssetAdded = "asset_added"
+)
+
+// Event describes a change of one feedback
+type Event struct {
+	Type       string    `json:"type"`
+	FeedbackID string    `json:"feedback_id"`
+	LabID      int64     `json:"lab_id"`
+	UserID     int64     `json:"user_id"`
+	AuthorID   int64     `json:"author_id,omitempty"`
+	Status     string    `json:"status,omitempty"`
+	Filename   string    `json:"filename,omitempty"` // Set for asset events
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// Publisher delivers events to the watchers of all instances
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
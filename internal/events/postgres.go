@@ -0,0 +1,109 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Channel is the PostgreSQL notification channel events are sent on
+const Channel = "feedback_events"
+
+// listenerPingInterval is how often an idle LISTEN connection is checked
+const listenerPingInterval = 90 * time.Second
+
+// listenerRestartDelay is how long a failed listener waits before it starts over
+const listenerRestartDelay = 5 * time.Second
+
+// PostgresPublisher publishes events with NOTIFY so every replica receives them
+type PostgresPublisher struct {
+	db *sql.DB
+}
+
+// NewPostgresPublisher creates a publisher sending notifications over db
+func NewPostgresPublisher(db *sql.DB) *PostgresPublisher {
+	return &PostgresPublisher{
+		db: db,
+	}
+}
+
+func (p *PostgresPublisher) Publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	_, err = p.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", Channel, string(payload))
+	if err != nil {
+		return fmt.Errorf("failed to notify: %w", err)
+	}
+	return nil
+}
+
+// Listen forwards the events published by any replica, this one included, to bus
+// until ctx is cancelled. The listener reconnects on its own and starts over when
+// it fails, notifications sent while it is disconnected are lost.
+func Listen(ctx context.Context, dsn string, bus *Bus) error {
+	return restartOnFailure(ctx, listenerRestartDelay, func(ctx context.Context) error {
+		return listen(ctx, dsn, bus)
+	})
+}
+
+// restartOnFailure runs fn again after delay whenever it fails, until ctx is cancelled
+func restartOnFailure(ctx context.Context, delay time.Duration, fn func(context.Context) error) error {
+	for {
+		err := fn(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("Event listener failed, restarting in %s: %v", delay, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func listen(ctx context.Context, dsn string, bus *Bus) error {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(eventType pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Event listener: %v", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(Channel); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", Channel, err)
+	}
+
+	ticker := time.NewTicker(listenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case notification := <-listener.Notify:
+			// A nil notification signals a reconnect
+			if notification == nil {
+				continue
+			}
+
+			var event Event
+			if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+				log.Printf("Failed to decode event: %v", err)
+				continue
+			}
+			bus.Publish(ctx, event)
+		case <-ticker.C:
+			go listener.Ping()
+		}
+	}
+}
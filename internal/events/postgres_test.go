@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRestartOnFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	attempts := 0
+	err := restartOnFailure(ctx, time.Millisecond, func(ctx context.Context) error {
+		attempts++
+		if attempts == 3 {
+			cancel()
+			return ctx.Err()
+		}
+		return errors.New("connection refused")
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+	if attempts != 3 {
+		t.Errorf("got %d attempts, want 3", attempts)
+	}
+}
+
+func TestRestartOnFailureStopsWhileWaiting(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error)
+	go func() {
+		done <- restartOnFailure(ctx, time.Hour, func(ctx context.Context) error {
+			return errors.New("connection refused")
+		})
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("restart delay was not interrupted by cancellation")
+	}
+}
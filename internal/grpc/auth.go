@@ -0,0 +1,74 @@
+package grpc
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+// Metadata keys set by the API gateway for authenticated requests
+const (
+	userIDMetadataKey   = "x-user-id"
+	userRoleMetadataKey = "x-user-role"
+)
+
+// UnaryCallerInterceptor attaches the caller identified by request metadata to the context
+func UnaryCallerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := withCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamCallerInterceptor is the streaming counterpart of UnaryCallerInterceptor
+func StreamCallerInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := withCaller(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &callerStream{ServerStream: stream, ctx: ctx})
+}
+
+// withCaller reads the caller from metadata, requests without a user ID stay anonymous
+func withCaller(ctx context.Context) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx, nil
+	}
+
+	values := md.Get(userIDMetadataKey)
+	if len(values) == 0 || values[0] == "" {
+		return ctx, nil
+	}
+
+	userID, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil || userID <= 0 {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid %s metadata", userIDMetadataKey)
+	}
+
+	caller := service.Caller{UserID: userID, Role: service.RoleStudent}
+	if roles := md.Get(userRoleMetadataKey); len(roles) > 0 && roles[0] != "" {
+		if !service.ValidRole(roles[0]) {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid %s metadata", userRoleMetadataKey)
+		}
+		caller.Role = roles[0]
+	}
+
+	return service.WithCaller(ctx, caller), nil
+}
+
+type callerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *callerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+func TestWithCaller(t *testing.T) {
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want service.Caller
+		code codes.Code
+	}{
+		{"no metadata", nil, service.Caller{}, codes.OK},
+		{"anonymous", metadata.Pairs(userRoleMetadataKey, "admin"), service.Caller{}, codes.OK},
+		{"student by default", metadata.Pairs(userIDMetadataKey, "7"), service.Caller{UserID: 7, Role: service.RoleStudent}, codes.OK},
+		{"instructor", metadata.Pairs(userIDMetadataKey, "7", userRoleMetadataKey, "instructor"), service.Caller{UserID: 7, Role: service.RoleInstructor}, codes.OK},
+		{"invalid user id", metadata.Pairs(userIDMetadataKey, "abc"), service.Caller{}, codes.Unauthenticated},
+		{"negative user id", metadata.Pairs(userIDMetadataKey, "-1"), service.Caller{}, codes.Unauthenticated},
+		{"unknown role", metadata.Pairs(userIDMetadataKey, "7", userRoleMetadataKey, "superuser"), service.Caller{}, codes.Unauthenticated},
+		{"role with different case", metadata.Pairs(userIDMetadataKey, "7", userRoleMetadataKey, "Admin"), service.Caller{}, codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+
+			ctx, err := withCaller(ctx)
+			if status.Code(err) != tt.code {
+				t.Fatalf("got %v, want %v", err, tt.code)
+			}
+			if err != nil {
+				return
+			}
+			if got := service.CallerFromContext(ctx); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
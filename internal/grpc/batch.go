@@ -0,0 +1,62 @@
+package grpc
+
+import (
+	"context"
+	"log"
+
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+func (s *FeedbackGRPCServer) BatchCreateFeedback(ctx context.Context, req *proto.BatchCreateFeedbackRequest) (*proto.BatchCreateFeedbackResponse, error) {
+	items := make([]*service.BatchItem, len(req.Items))
+	for i, item := range req.Items {
+		feedback := item.Feedback
+		if feedback == nil {
+			feedback = &proto.CreateFeedbackRequest{}
+		}
+		items[i] = &service.BatchItem{
+			Key:       item.Key,
+			UserID:    feedback.UserId,
+			LabID:     feedback.LabId,
+			Title:     feedback.Title,
+			Content:   feedback.Content,
+			Variables: item.Variables,
+		}
+	}
+
+	result, err := s.feedbackService.BatchCreateFeedback(ctx, &service.BatchCreateParams{
+		BatchID:      req.BatchId,
+		TemplateID:   req.TemplateId,
+		AllOrNothing: req.AllOrNothing,
+		Publish:      req.Publish,
+		Items:        items,
+	})
+	if err != nil {
+		log.Printf("Failed to create feedback batch: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	results := make([]*proto.BatchFeedbackResult, len(result.Items))
+	for i, item := range result.Items {
+		results[i] = &proto.BatchFeedbackResult{
+			Key:       item.Key,
+			Success:   item.Err == nil,
+			Duplicate: item.Duplicate,
+		}
+		if item.Err != nil {
+			results[i].Error = itemError(item.Err)
+		}
+		if item.Feedback != nil {
+			results[i].Feedback = toProtoFeedback(item.Feedback)
+			results[i].Warnings = item.Feedback.Warnings
+		}
+	}
+
+	return &proto.BatchCreateFeedbackResponse{
+		Results:    results,
+		Succeeded:  int32(result.Succeeded),
+		Failed:     int32(result.Failed),
+		RolledBack: result.RolledBack,
+	}, nil
+}
@@ -0,0 +1,54 @@
+package grpc
+
+import (
+	"context"
+	"log"
+
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+func (s *FeedbackGRPCServer) AddComment(ctx context.Context, req *proto.AddCommentRequest) (*proto.AddCommentResponse, error) {
+	comment, err := s.feedbackService.AddComment(ctx, &service.AddCommentParams{
+		FeedbackID: req.FeedbackId,
+		ParentID:   req.ParentId,
+		Content:    req.Content,
+	})
+	if err != nil {
+		log.Printf("Failed to add comment: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.AddCommentResponse{
+		Comment: toProtoComment(comment),
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) ListComments(ctx context.Context, req *proto.ListCommentsRequest) (*proto.ListCommentsResponse, error) {
+	comments, err := s.feedbackService.ListComments(ctx, req.FeedbackId)
+	if err != nil {
+		log.Printf("Failed to list comments: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	protoComments := make([]*proto.Comment, len(comments))
+	for i, comment := range comments {
+		protoComments[i] = toProtoComment(comment)
+	}
+
+	return &proto.ListCommentsResponse{
+		Comments: protoComments,
+	}, nil
+}
+
+func toProtoComment(comment *models.FeedbackComment) *proto.Comment {
+	return &proto.Comment{
+		Id:         comment.ID,
+		FeedbackId: comment.FeedbackID,
+		ParentId:   comment.ParentID,
+		AuthorId:   comment.AuthorID,
+		Content:    comment.Content,
+		CreatedAt:  comment.CreatedAt.Unix(),
+	}
+}
@@ -0,0 +1,62 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+// toStatusError maps service errors to gRPC status errors
+func toStatusError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, service.ErrContentTypeRejected):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrAssetQuarantined):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, service.ErrThumbnailNotFound), errors.Is(err, service.ErrFeedbackNotFound),
+		errors.Is(err, service.ErrRubricNotFound), errors.Is(err, service.ErrScoreNotFound),
+		errors.Is(err, service.ErrTemplateNotFound), errors.Is(err, service.ErrWebhookNotFound),
+		errors.Is(err, service.ErrCommentNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidTransition), errors.Is(err, service.ErrRubricVersionMismatch),
+		errors.Is(err, service.ErrBatchItemInProgress):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, service.ErrIdempotencyInProgress), errors.Is(err, service.ErrRubricConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, service.ErrInvalidStatus), errors.Is(err, service.ErrInvalidTag),
+		errors.Is(err, service.ErrInvalidRubric), errors.Is(err, service.ErrInvalidScore),
+		errors.Is(err, service.ErrInvalidTemplate), errors.Is(err, service.ErrTemplateRender),
+		errors.Is(err, service.ErrInvalidBatch), errors.Is(err, service.ErrIdempotencyKeyReused),
+		errors.Is(err, service.ErrInvalidPageToken), errors.Is(err, service.ErrInvalidFilter),
+		errors.Is(err, service.ErrInvalidFieldMask), errors.Is(err, service.ErrInvalidWebhook),
+		errors.Is(err, service.ErrInvalidNotificationMode), errors.Is(err, service.ErrUnknownUser),
+		errors.Is(err, service.ErrUnknownLab), errors.Is(err, service.ErrInvalidComment):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrIdempotencyAnonymous):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, service.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, service.ErrDependencyUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return err
+	}
+}
+
+// itemError formats the error of a single batch item like a status error. Errors without a
+// domain meaning are logged and reported generically, so storage and database details stay internal.
+func itemError(err error) string {
+	st, ok := status.FromError(toStatusError(err))
+	if !ok || st.Code() == codes.Unknown {
+		log.Printf("Batch item failed: %v", err)
+		return codes.Internal.String() + ": internal error"
+	}
+	return fmt.Sprintf("%s: %s", st.Code(), st.Message())
+}
@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+func TestToStatusError(t *testing.T) {
+	tests := map[error]codes.Code{
+		service.ErrQuotaExceeded:         codes.ResourceExhausted,
+		service.ErrAssetQuarantined:      codes.FailedPrecondition,
+		service.ErrFeedbackNotFound:      codes.NotFound,
+		service.ErrCommentNotFound:       codes.NotFound,
+		service.ErrInvalidTransition:     codes.FailedPrecondition,
+		service.ErrRubricConflict:        codes.Aborted,
+		service.ErrIdempotencyInProgress: codes.Aborted,
+		service.ErrInvalidRubric:         codes.InvalidArgument,
+		service.ErrUnknownUser:           codes.InvalidArgument,
+		service.ErrInvalidComment:        codes.InvalidArgument,
+		service.ErrPermissionDenied:      codes.PermissionDenied,
+		service.ErrDependencyUnavailable: codes.Unavailable,
+	}
+
+	for err, want := range tests {
+		wrapped := fmt.Errorf("%w: detail", err)
+		if got := status.Code(toStatusError(wrapped)); got != want {
+			t.Errorf("%v: got %v, want %v", err, got, want)
+		}
+	}
+
+	if got := status.Code(toStatusError(errors.New("boom"))); got != codes.Unknown {
+		t.Errorf("unmapped error: got %v, want Unknown", got)
+	}
+}
+
+func TestItemError(t *testing.T) {
+	if got, want := itemError(fmt.Errorf("%w: key reused", service.ErrIdempotencyKeyReused)),
+		"InvalidArgument: idempotency key reused with a different request: key reused"; got != want {
+		t.Errorf("domain error: got %q, want %q", got, want)
+	}
+
+	if got := itemError(errors.New("dial tcp 10.0.0.5:5432: connection refused")); got != "Internal: internal error" {
+		t.Errorf("unmapped error: got %q", got)
+	}
+}
@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+// feedbackReadMask selects the FeedbackFile fields returned by reads, a nil mask returns every field
+type feedbackReadMask struct {
+	paths map[string]bool
+}
+
+// newFeedbackReadMask validates the paths of a read mask against FeedbackFile
+func newFeedbackReadMask(mask *fieldmaskpb.FieldMask) (*feedbackReadMask, error) {
+	if len(mask.GetPaths()) == 0 {
+		return nil, nil
+	}
+	if !mask.IsValid(&proto.FeedbackFile{}) {
+		return nil, fmt.Errorf("%w: read_mask %v does not match FeedbackFile", service.ErrInvalidFieldMask, mask.GetPaths())
+	}
+
+	paths := make(map[string]bool, len(mask.GetPaths()))
+	for _, path := range mask.GetPaths() {
+		paths[path] = true
+	}
+	return &feedbackReadMask{paths: paths}, nil
+}
+
+// includes reports whether field is requested, defaultValue applies without a mask
+func (m *feedbackReadMask) includes(field string, defaultValue bool) bool {
+	if m == nil {
+		return defaultValue
+	}
+	return m.paths[field]
+}
+
+// apply clears the fields of feedback that are not in the mask, the id is always kept
+func (m *feedbackReadMask) apply(feedback *proto.FeedbackFile) *proto.FeedbackFile {
+	if m == nil {
+		return feedback
+	}
+
+	message := feedback.ProtoReflect()
+	fields := message.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if name := string(fields.Get(i).Name()); name != "id" && !m.paths[name] {
+			message.Clear(fields.Get(i))
+		}
+	}
+	return feedback
+}
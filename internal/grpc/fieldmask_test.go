@@ -0,0 +1,72 @@
+package grpc
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+func TestNewFeedbackReadMask(t *testing.T) {
+	for _, mask := range []*fieldmaskpb.FieldMask{nil, {}} {
+		readMask, err := newFeedbackReadMask(mask)
+		if err != nil || readMask != nil {
+			t.Errorf("%v: got %v, %v, want no mask", mask, readMask, err)
+		}
+	}
+
+	for _, paths := range [][]string{{"author"}, {"title", "content_md"}, {"created_at.seconds"}} {
+		if _, err := newFeedbackReadMask(&fieldmaskpb.FieldMask{Paths: paths}); !errors.Is(err, service.ErrInvalidFieldMask) {
+			t.Errorf("%v: got %v, want %v", paths, err, service.ErrInvalidFieldMask)
+		}
+	}
+}
+
+func TestFeedbackReadMaskIncludes(t *testing.T) {
+	var all *feedbackReadMask
+	if !all.includes("content", true) || all.includes("rendered_html", false) {
+		t.Error("without a mask the defaults apply")
+	}
+
+	mask, err := newFeedbackReadMask(&fieldmaskpb.FieldMask{Paths: []string{"title", "content"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mask.includes("content", false) || mask.includes("rendered_html", true) {
+		t.Error("with a mask only its paths are included")
+	}
+}
+
+func TestFeedbackReadMaskApply(t *testing.T) {
+	feedback := func() *proto.FeedbackFile {
+		return &proto.FeedbackFile{
+			Id:        "f1",
+			UserId:    2,
+			Title:     "Lab 1",
+			Content:   "# Lab 1",
+			Status:    "published",
+			Tags:      []string{"style"},
+			CreatedAt: 1700000000,
+		}
+	}
+
+	var all *feedbackReadMask
+	if got := all.apply(feedback()); got.Content != "# Lab 1" || got.UserId != 2 {
+		t.Errorf("without a mask got %v", got)
+	}
+
+	mask, err := newFeedbackReadMask(&fieldmaskpb.FieldMask{Paths: []string{"title", "tags"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := mask.apply(feedback())
+	if got.Id != "f1" || got.Title != "Lab 1" || len(got.Tags) != 1 {
+		t.Errorf("masked fields or the id were cleared: %v", got)
+	}
+	if got.UserId != 0 || got.Content != "" || got.Status != "" || got.CreatedAt != 0 {
+		t.Errorf("fields outside the mask were kept: %v", got)
+	}
+}
@@ -0,0 +1,279 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+// idempotencyKeyMetadata is the request metadata carrying the client chosen idempotency key
+const idempotencyKeyMetadata = "idempotency-key"
+
+// maxIdempotentStreamBytes bounds client streams buffered in memory to compute their fingerprint
+const maxIdempotentStreamBytes = 8 << 20
+
+const methodPrefix = "/feedback.FeedbackService/"
+
+// idempotentMethods are the mutating unary RPCs that honor idempotency keys
+var idempotentMethods = map[string]bool{
+	methodPrefix + "CreateFeedback":             true,
+	methodPrefix + "BatchCreateFeedback":        true,
+	methodPrefix + "UpdateFeedback":             true,
+	methodPrefix + "DeleteFeedback":             true,
+	methodPrefix + "PublishFeedback":            true,
+	methodPrefix + "AcknowledgeFeedback":        true,
+	methodPrefix + "ResolveFeedback":            true,
+	methodPrefix + "ArchiveFeedback":            true,
+	methodPrefix + "RestoreFeedback":            true,
+	methodPrefix + "AddTags":                    true,
+	methodPrefix + "RemoveTags":                 true,
+	methodPrefix + "AddComment":                 true,
+	methodPrefix + "DefineRubric":               true,
+	methodPrefix + "ScoreFeedback":              true,
+	methodPrefix + "CreateTemplate":             true,
+	methodPrefix + "UpdateTemplate":             true,
+	methodPrefix + "DeleteTemplate":             true,
+	methodPrefix + "CreateFeedbackFromTemplate": true,
+}
+
+// idempotentStreams are the mutating client streaming RPCs with their request message type
+var idempotentStreams = map[string]func() protobuf.Message{
+	methodPrefix + "UploadAsset":    func() protobuf.Message { return &proto.UploadAssetRequest{} },
+	methodPrefix + "ImportFeedback": func() protobuf.Message { return &proto.ImportFeedbackRequest{} },
+}
+
+// IdempotencyInterceptor replays the stored response when a mutating RPC is retried with the
+// same idempotency key and rejects keys reused for a different request
+type IdempotencyInterceptor struct {
+	feedbackService *service.FeedbackService
+}
+
+func NewIdempotencyInterceptor(feedbackService *service.FeedbackService) *IdempotencyInterceptor {
+	return &IdempotencyInterceptor{
+		feedbackService: feedbackService,
+	}
+}
+
+func (i *IdempotencyInterceptor) Unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	key := idempotencyKey(ctx)
+	message, ok := req.(protobuf.Message)
+	if key == "" || !ok || !idempotentMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	if err := requireIdempotencyCaller(ctx); err != nil {
+		return nil, err
+	}
+
+	fingerprint, err := fingerprintMessages([]protobuf.Message{message})
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := i.feedbackService.BeginIdempotentRequest(ctx, info.FullMethod, key, fingerprint)
+	if err != nil {
+		log.Printf("Failed to begin idempotent request: %v", err)
+		return nil, toStatusError(err)
+	}
+	if stored != nil {
+		return decodeResponse(stored)
+	}
+
+	resp, err := handler(ctx, req)
+	if err != nil {
+		i.release(ctx, info.FullMethod, key)
+		return nil, err
+	}
+
+	if message, ok := resp.(protobuf.Message); ok {
+		i.complete(ctx, info.FullMethod, key, message)
+	}
+	return resp, nil
+}
+
+// Stream buffers client streams with an idempotency key so that their fingerprint is known
+// before the handler runs, the handler then receives the buffered messages
+func (i *IdempotencyInterceptor) Stream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := stream.Context()
+	key := idempotencyKey(ctx)
+	newRequest, ok := idempotentStreams[info.FullMethod]
+	if key == "" || !ok {
+		return handler(srv, stream)
+	}
+	if err := requireIdempotencyCaller(ctx); err != nil {
+		return err
+	}
+
+	var messages []protobuf.Message
+	var size int
+	for {
+		message := newRequest()
+		err := stream.RecvMsg(message)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		size += protobuf.Size(message)
+		if size > maxIdempotentStreamBytes {
+			return status.Errorf(codes.ResourceExhausted, "streams over %d bytes cannot use an idempotency key", maxIdempotentStreamBytes)
+		}
+		messages = append(messages, message)
+	}
+
+	fingerprint, err := fingerprintMessages(messages)
+	if err != nil {
+		return err
+	}
+
+	stored, err := i.feedbackService.BeginIdempotentRequest(ctx, info.FullMethod, key, fingerprint)
+	if err != nil {
+		log.Printf("Failed to begin idempotent request: %v", err)
+		return toStatusError(err)
+	}
+	if stored != nil {
+		resp, err := decodeResponse(stored)
+		if err != nil {
+			return err
+		}
+		return stream.SendMsg(resp)
+	}
+
+	replay := &replayStream{ServerStream: stream, messages: messages}
+	if err := handler(srv, replay); err != nil {
+		i.release(ctx, info.FullMethod, key)
+		return err
+	}
+
+	if replay.response != nil {
+		i.complete(ctx, info.FullMethod, key, replay.response)
+	}
+	return nil
+}
+
+// complete stores the response even when the client has gone away. On failure the key stays in
+// progress until its lease passes, then a retry runs the request again.
+func (i *IdempotencyInterceptor) complete(ctx context.Context, method, key string, resp protobuf.Message) {
+	stored, err := encodeResponse(resp)
+	if err == nil {
+		err = i.feedbackService.CompleteIdempotentRequest(context.WithoutCancel(ctx), method, key, stored)
+	}
+	if err != nil {
+		log.Printf("Failed to store idempotent response of %s: %v", method, err)
+	}
+}
+
+// release frees the key of a failed request so that the client can retry it, a request failing
+// because its client cancelled it must still free the key
+func (i *IdempotencyInterceptor) release(ctx context.Context, method, key string) {
+	if err := i.feedbackService.ReleaseIdempotentRequest(context.WithoutCancel(ctx), method, key); err != nil {
+		log.Printf("Failed to release idempotency key of %s: %v", method, err)
+	}
+}
+
+// requireIdempotencyCaller refuses keys of anonymous callers before their request is buffered
+func requireIdempotencyCaller(ctx context.Context) error {
+	if service.CallerFromContext(ctx).UserID == 0 {
+		return toStatusError(service.ErrIdempotencyAnonymous)
+	}
+	return nil
+}
+
+func idempotencyKey(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(idempotencyKeyMetadata); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// fingerprintMessages hashes the deterministic encoding of the request messages
+func fingerprintMessages(messages []protobuf.Message) (string, error) {
+	hash := sha256.New()
+	options := protobuf.MarshalOptions{Deterministic: true}
+
+	for _, message := range messages {
+		data, err := options.Marshal(message)
+		if err != nil {
+			return "", status.Errorf(codes.Internal, "failed to fingerprint request: %v", err)
+		}
+
+		// Length prefixes keep different message boundaries from hashing alike
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(data)))
+		hash.Write(length[:])
+		hash.Write(data)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// encodeResponse stores the response with its type so it can be decoded without knowing the method
+func encodeResponse(resp protobuf.Message) ([]byte, error) {
+	wrapped, err := anypb.New(resp)
+	if err != nil {
+		return nil, err
+	}
+	return protobuf.Marshal(wrapped)
+}
+
+func decodeResponse(stored []byte) (protobuf.Message, error) {
+	wrapped := &anypb.Any{}
+	if err := protobuf.Unmarshal(stored, wrapped); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to decode stored response: %v", err)
+	}
+
+	resp, err := wrapped.UnmarshalNew()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to decode stored response: %v", err)
+	}
+	return resp, nil
+}
+
+// replayStream feeds buffered requests to the handler and records its response
+type replayStream struct {
+	grpc.ServerStream
+	messages []protobuf.Message
+	next     int
+	response protobuf.Message
+}
+
+func (s *replayStream) RecvMsg(m interface{}) error {
+	if s.next >= len(s.messages) {
+		return io.EOF
+	}
+
+	message, ok := m.(protobuf.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "unexpected message type %T", m)
+	}
+	protobuf.Reset(message)
+	protobuf.Merge(message, s.messages[s.next])
+	s.next++
+	return nil
+}
+
+func (s *replayStream) SendMsg(m interface{}) error {
+	if message, ok := m.(protobuf.Message); ok {
+		s.response = message
+	}
+	return s.ServerStream.SendMsg(m)
+}
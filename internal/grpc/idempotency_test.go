@@ -0,0 +1,91 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+// RecvMsg lets the idempotency interceptor read the stream like the generated server does
+func (s *uploadStream) RecvMsg(m interface{}) error {
+	req, err := s.Recv()
+	if err != nil {
+		return err
+	}
+	protobuf.Merge(m.(protobuf.Message), req)
+	return nil
+}
+
+func keyedContext(caller service.Caller) context.Context {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(idempotencyKeyMetadata, "k1"))
+	return service.WithCaller(ctx, caller)
+}
+
+func TestIdempotencyRefusesAnonymousCallers(t *testing.T) {
+	interceptor := NewIdempotencyInterceptor(service.NewFeedbackService(nil, nil, service.Options{}))
+	ctx := keyedContext(service.Caller{})
+
+	called := false
+	unary := &grpc.UnaryServerInfo{FullMethod: methodPrefix + "CreateFeedback"}
+	_, err := interceptor.Unary(ctx, &proto.CreateFeedbackRequest{}, unary, func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+	if status.Code(err) != codes.Unauthenticated || called {
+		t.Errorf("unary: got %v, handler called %v", err, called)
+	}
+
+	stream := &uploadStream{ctx: ctx, requests: []*proto.UploadAssetRequest{{}}}
+	info := &grpc.StreamServerInfo{FullMethod: methodPrefix + "UploadAsset"}
+	err = interceptor.Stream(nil, stream, info, func(srv interface{}, stream grpc.ServerStream) error {
+		called = true
+		return nil
+	})
+	if status.Code(err) != codes.Unauthenticated || called {
+		t.Errorf("stream: got %v, handler called %v", err, called)
+	}
+	if len(stream.requests) != 1 {
+		t.Error("stream of an anonymous caller was buffered")
+	}
+}
+
+func TestIdempotencyWithoutKeyPassesThrough(t *testing.T) {
+	interceptor := NewIdempotencyInterceptor(service.NewFeedbackService(nil, nil, service.Options{}))
+	unary := &grpc.UnaryServerInfo{FullMethod: methodPrefix + "CreateFeedback"}
+
+	resp, err := interceptor.Unary(context.Background(), &proto.CreateFeedbackRequest{}, unary, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	if err != nil || resp != "ok" {
+		t.Errorf("got %v, %v", resp, err)
+	}
+}
+
+func TestIdempotentStreamSizeIsCapped(t *testing.T) {
+	interceptor := NewIdempotencyInterceptor(service.NewFeedbackService(nil, nil, service.Options{}))
+
+	chunk := make([]byte, 1<<20)
+	stream := &uploadStream{ctx: keyedContext(service.Caller{UserID: 7, Role: service.RoleStudent})}
+	for i := 0; i <= maxIdempotentStreamBytes/len(chunk); i++ {
+		stream.requests = append(stream.requests, &proto.UploadAssetRequest{
+			Data: &proto.UploadAssetRequest_Chunk{Chunk: chunk},
+		})
+	}
+
+	info := &grpc.StreamServerInfo{FullMethod: methodPrefix + "UploadAsset"}
+	err := interceptor.Stream(nil, stream, info, func(srv interface{}, stream grpc.ServerStream) error {
+		t.Error("handler called for an oversized stream")
+		return nil
+	})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("got %v, want %v", err, codes.ResourceExhausted)
+	}
+}
@@ -0,0 +1,167 @@
+package grpc
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Ravwvil/feedback/internal/bundle"
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+// maxImportBundleSize bounds the bytes buffered for one streamed bundle
+const maxImportBundleSize = bundle.MaxUncompressedSize
+
+// pendingBundle buffers a streamed bundle on disk, zip archives need random access
+type pendingBundle struct {
+	name     string
+	file     *os.File
+	declared int64 // total_size from the header, 0 if unknown
+	written  int64
+	err      error // Set once the bundle is rejected, its remaining chunks are dropped
+}
+
+func (s *FeedbackGRPCServer) ImportFeedback(stream proto.FeedbackService_ImportFeedbackServer) error {
+	if !service.CallerFromContext(stream.Context()).IsStaff() {
+		return status.Error(codes.PermissionDenied, "only instructors can import feedback")
+	}
+
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	options := req.GetOptions()
+	if options == nil {
+		return fmt.Errorf("first message must contain options")
+	}
+
+	response := &proto.ImportFeedbackResponse{
+		DryRun: options.DryRun,
+	}
+
+	var current *pendingBundle
+	defer func() {
+		if current != nil {
+			current.discard()
+		}
+	}()
+
+	finish := func() {
+		if current == nil {
+			return
+		}
+		result := s.importBundle(stream, current, options.DryRun)
+		current.discard()
+		current = nil
+
+		response.Results = append(response.Results, result)
+		if result.Success {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch data := req.Data.(type) {
+		case *proto.ImportFeedbackRequest_Bundle:
+			finish()
+
+			current = &pendingBundle{name: data.Bundle.Name, declared: data.Bundle.TotalSize}
+			if current.declared < 0 || current.declared > maxImportBundleSize {
+				current.err = fmt.Errorf("invalid total size %d, bundles are limited to %d bytes", current.declared, int64(maxImportBundleSize))
+				continue
+			}
+
+			file, err := os.CreateTemp("", "feedback-import-*.zip")
+			if err != nil {
+				return fmt.Errorf("failed to buffer bundle: %w", err)
+			}
+			current.file = file
+		case *proto.ImportFeedbackRequest_Chunk:
+			if current == nil {
+				return fmt.Errorf("chunk received before bundle header")
+			}
+			if err := current.write(data.Chunk); err != nil {
+				return fmt.Errorf("failed to buffer bundle: %w", err)
+			}
+		default:
+			return fmt.Errorf("unexpected import message")
+		}
+	}
+	finish()
+
+	return stream.SendAndClose(response)
+}
+
+func (s *FeedbackGRPCServer) importBundle(stream proto.FeedbackService_ImportFeedbackServer, bundle *pendingBundle, dryRun bool) *proto.ImportResult {
+	result := &proto.ImportResult{
+		Name: bundle.name,
+	}
+
+	if bundle.err == nil && bundle.declared > 0 && bundle.written != bundle.declared {
+		bundle.err = fmt.Errorf("received %d of %d bytes", bundle.written, bundle.declared)
+	}
+	if bundle.err != nil {
+		log.Printf("Failed to import bundle %s: %v", bundle.name, bundle.err)
+		result.Error = bundle.err.Error()
+		return result
+	}
+
+	feedback, err := s.feedbackService.ImportBundle(stream.Context(), bundle.file, bundle.written, dryRun)
+	if err != nil {
+		log.Printf("Failed to import bundle %s: %v", bundle.name, err)
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Success = true
+	result.FeedbackId = feedback.ID
+	return result
+}
+
+// write buffers a chunk, a bundle over its limit is rejected and its file removed right away
+func (b *pendingBundle) write(chunk []byte) error {
+	if b.err != nil {
+		return nil
+	}
+
+	limit := int64(maxImportBundleSize)
+	if b.declared > 0 {
+		limit = b.declared
+	}
+	if b.written+int64(len(chunk)) > limit {
+		b.err = fmt.Errorf("bundle too large, more than %d bytes received", limit)
+		b.discard()
+		return nil
+	}
+
+	if _, err := b.file.Write(chunk); err != nil {
+		return err
+	}
+	b.written += int64(len(chunk))
+	return nil
+}
+
+func (b *pendingBundle) discard() {
+	if b.file == nil {
+		return
+	}
+	b.file.Close()
+	os.Remove(b.file.Name())
+	b.file = nil
+}
@@ -0,0 +1,108 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+// importStream replays requests to ImportFeedback
+type importStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	requests []*proto.ImportFeedbackRequest
+	response *proto.ImportFeedbackResponse
+}
+
+func (s *importStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *importStream) Recv() (*proto.ImportFeedbackRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
+
+func (s *importStream) SendAndClose(resp *proto.ImportFeedbackResponse) error {
+	s.response = resp
+	return nil
+}
+
+func importRequests(header *proto.ImportBundleHeader, chunks ...string) []*proto.ImportFeedbackRequest {
+	requests := []*proto.ImportFeedbackRequest{
+		{Data: &proto.ImportFeedbackRequest_Options{Options: &proto.ImportOptions{DryRun: true}}},
+		{Data: &proto.ImportFeedbackRequest_Bundle{Bundle: header}},
+	}
+	for _, chunk := range chunks {
+		requests = append(requests, &proto.ImportFeedbackRequest{
+			Data: &proto.ImportFeedbackRequest_Chunk{Chunk: []byte(chunk)},
+		})
+	}
+	return requests
+}
+
+func TestImportFeedbackRequiresStaff(t *testing.T) {
+	server := NewFeedbackGRPCServer(service.NewFeedbackService(nil, nil, service.Options{}))
+
+	for _, ctx := range []context.Context{
+		context.Background(),
+		service.WithCaller(context.Background(), service.Caller{UserID: 7, Role: service.RoleStudent}),
+	} {
+		stream := &importStream{ctx: ctx, requests: importRequests(&proto.ImportBundleHeader{Name: "a.zip"}, "zip")}
+		if err := server.ImportFeedback(stream); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("got %v, want PermissionDenied", err)
+		}
+	}
+}
+
+func TestImportFeedbackBoundsBundleSize(t *testing.T) {
+	server := NewFeedbackGRPCServer(service.NewFeedbackService(nil, nil, service.Options{}))
+	ctx := service.WithCaller(context.Background(), service.Caller{UserID: 1, Role: service.RoleInstructor})
+
+	tests := []struct {
+		name      string
+		totalSize int64
+		chunks    []string
+		want      string
+	}{
+		{"negative size", -1, []string{"zip"}, "invalid total size"},
+		{"over limit", maxImportBundleSize + 1, []string{"zip"}, "invalid total size"},
+		{"more than declared", 4, []string{"zip", "zip"}, "bundle too large"},
+		{"less than declared", 10, []string{"zip"}, "received 3 of 10 bytes"},
+		{"declared size reaches the bundle", 3, []string{"zip"}, "invalid bundle"},
+		{"unknown size reaches the bundle", 0, []string{"zip"}, "invalid bundle"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := &importStream{
+				ctx:      ctx,
+				requests: importRequests(&proto.ImportBundleHeader{Name: "a.zip", TotalSize: tt.totalSize}, tt.chunks...),
+			}
+
+			if err := server.ImportFeedback(stream); err != nil {
+				t.Fatal(err)
+			}
+
+			response := stream.response
+			if response.Failed != 1 || len(response.Results) != 1 {
+				t.Fatalf("got %+v", response)
+			}
+			if result := response.Results[0]; !strings.Contains(result.Error, tt.want) {
+				t.Errorf("got error %q, want %q", result.Error, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package grpc
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+func (s *FeedbackGRPCServer) PublishFeedback(ctx context.Context, req *proto.FeedbackTransitionRequest) (*proto.FeedbackTransitionResponse, error) {
+	return transitionResponse(s.feedbackService.PublishFeedback(ctx, req.Id))
+}
+
+func (s *FeedbackGRPCServer) AcknowledgeFeedback(ctx context.Context, req *proto.FeedbackTransitionRequest) (*proto.FeedbackTransitionResponse, error) {
+	return transitionResponse(s.feedbackService.AcknowledgeFeedback(ctx, req.Id))
+}
+
+func (s *FeedbackGRPCServer) ResolveFeedback(ctx context.Context, req *proto.FeedbackTransitionRequest) (*proto.FeedbackTransitionResponse, error) {
+	return transitionResponse(s.feedbackService.ResolveFeedback(ctx, req.Id))
+}
+
+func (s *FeedbackGRPCServer) ArchiveFeedback(ctx context.Context, req *proto.FeedbackTransitionRequest) (*proto.FeedbackTransitionResponse, error) {
+	return transitionResponse(s.feedbackService.ArchiveFeedback(ctx, req.Id))
+}
+
+func transitionResponse(feedback *models.FeedbackFile, err error) (*proto.FeedbackTransitionResponse, error) {
+	if err != nil {
+		log.Printf("Failed to change feedback status: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.FeedbackTransitionResponse{
+		Feedback: toProtoFeedback(feedback),
+	}, nil
+}
+
+// toProtoFeedback converts feedback metadata and any loaded content to its protobuf form
+func toProtoFeedback(feedback *models.FeedbackFile) *proto.FeedbackFile {
+	return &proto.FeedbackFile{
+		Id:             feedback.ID,
+		UserId:         feedback.UserID,
+		LabId:          feedback.LabID,
+		Title:          feedback.Title,
+		Content:        feedback.Content,
+		ContentHash:    feedback.ContentHash,
+		CreatedAt:      feedback.CreatedAt.Unix(),
+		UpdatedAt:      feedback.UpdatedAt.Unix(),
+		RenderedHtml:   feedback.RenderedHTML,
+		Status:         feedback.Status,
+		AuthorId:       feedback.AuthorID,
+		PublishedAt:    unixOrZero(feedback.PublishedAt),
+		AcknowledgedAt: unixOrZero(feedback.AcknowledgedAt),
+		ResolvedAt:     unixOrZero(feedback.ResolvedAt),
+		ArchivedAt:     unixOrZero(feedback.ArchivedAt),
+		DeletedAt:      unixOrZero(feedback.DeletedAt),
+		DeletedBy:      feedback.DeletedBy,
+		Tags:           feedback.Tags,
+	}
+}
+
+func unixOrZero(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
@@ -0,0 +1,72 @@
+package grpc
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+	"github.com/Ravwvil/feedback/internal/repository"
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+func (s *FeedbackGRPCServer) ListFeedbacks(ctx context.Context, req *proto.ListFeedbacksRequest) (*proto.ListFeedbacksResponse, error) {
+	mask, err := newFeedbackReadMask(req.ReadMask)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	page, err := s.feedbackService.ListFeedbacks(ctx, &service.ListFeedbacksParams{
+		UserIDs:           req.UserIds,
+		LabIDs:            req.LabIds,
+		Statuses:          req.Statuses,
+		Tags:              req.Tags,
+		TagMatch:          tagMatch(req.TagMatch),
+		CreatedAfter:      fromUnix(req.CreatedAfter),
+		CreatedBefore:     fromUnix(req.CreatedBefore),
+		UpdatedAfter:      fromUnix(req.UpdatedAfter),
+		UpdatedBefore:     fromUnix(req.UpdatedBefore),
+		TitleContains:     req.TitleContains,
+		HasAssets:         req.HasAssets,
+		SortBy:            sortField(req.SortBy),
+		SortAscending:     req.SortDirection == proto.SortDirection_SORT_DIRECTION_ASC,
+		IncludeContent:    mask.includes("content", false),
+		PageSize:          int(req.PageSize),
+		PageToken:         req.PageToken,
+		IncludeTotalCount: req.IncludeTotalCount,
+	})
+	if err != nil {
+		log.Printf("Failed to list feedbacks: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	protoFeedbacks := make([]*proto.FeedbackFile, len(page.Items))
+	for i, feedback := range page.Items {
+		protoFeedbacks[i] = mask.apply(toProtoFeedback(feedback))
+	}
+
+	return &proto.ListFeedbacksResponse{
+		Feedbacks:     protoFeedbacks,
+		TotalCount:    int32(page.TotalCount),
+		NextPageToken: page.NextPageToken,
+	}, nil
+}
+
+func sortField(field proto.FeedbackSortField) string {
+	switch field {
+	case proto.FeedbackSortField_FEEDBACK_SORT_FIELD_UPDATED_AT:
+		return repository.SortByUpdatedAt
+	case proto.FeedbackSortField_FEEDBACK_SORT_FIELD_TITLE:
+		return repository.SortByTitle
+	default:
+		return repository.SortByCreatedAt
+	}
+}
+
+// fromUnix converts an optional Unix timestamp, 0 yields the zero time
+func fromUnix(seconds int64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
@@ -0,0 +1,74 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+)
+
+// UserClient implements lookup.UserService over gRPC, NOT_FOUND and INVALID_ARGUMENT mean the user does not exist
+type UserClient struct {
+	client  proto.UserServiceClient
+	timeout time.Duration
+}
+
+func NewUserClient(conn grpc.ClientConnInterface, timeout time.Duration) *UserClient {
+	return &UserClient{
+		client:  proto.NewUserServiceClient(conn),
+		timeout: timeout,
+	}
+}
+
+func (c *UserClient) UserExists(ctx context.Context, userID int64) (bool, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	_, err := c.client.GetUser(ctx, &proto.GetUserRequest{UserId: userID})
+	return existence(err)
+}
+
+// LabClient implements lookup.LabService over gRPC, NOT_FOUND and INVALID_ARGUMENT mean the lab does not exist
+type LabClient struct {
+	client  proto.LabServiceClient
+	timeout time.Duration
+}
+
+func NewLabClient(conn grpc.ClientConnInterface, timeout time.Duration) *LabClient {
+	return &LabClient{
+		client:  proto.NewLabServiceClient(conn),
+		timeout: timeout,
+	}
+}
+
+func (c *LabClient) LabExists(ctx context.Context, labID int64) (bool, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	_, err := c.client.GetLab(ctx, &proto.GetLabRequest{LabId: labID})
+	return existence(err)
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// existence turns the outcome of a lookup call into an answer. NOT_FOUND, and INVALID_ARGUMENT
+// for ids the service rejects outright, mean the id does not exist; any other error leaves it open.
+func existence(err error) (bool, error) {
+	if err == nil {
+		return true, nil
+	}
+	switch status.Code(err) {
+	case codes.NotFound, codes.InvalidArgument:
+		return false, nil
+	}
+	return false, err
+}
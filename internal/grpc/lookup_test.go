@@ -0,0 +1,63 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+// fakeUserService answers GetUser with err for every user
+type fakeUserService struct {
+	err error
+}
+
+func (f fakeUserService) GetUser(context.Context, *proto.GetUserRequest, ...grpc.CallOption) (*proto.GetUserResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &proto.GetUserResponse{}, nil
+}
+
+func TestExistence(t *testing.T) {
+	tests := map[string]struct {
+		err     error
+		exists  bool
+		wantErr bool
+	}{
+		"found":             {nil, true, false},
+		"not found":         {status.Error(codes.NotFound, "no such user"), false, false},
+		"invalid argument":  {status.Error(codes.InvalidArgument, "user id must be positive"), false, false},
+		"unavailable":       {status.Error(codes.Unavailable, "connection refused"), false, true},
+		"deadline exceeded": {status.Error(codes.DeadlineExceeded, "timeout"), false, true},
+		"internal":          {status.Error(codes.Internal, "boom"), false, true},
+		"plain error":       {errors.New("boom"), false, true},
+	}
+
+	for name, tt := range tests {
+		exists, err := existence(tt.err)
+		if exists != tt.exists || (err != nil) != tt.wantErr {
+			t.Errorf("%s: got %v, %v", name, exists, err)
+		}
+	}
+}
+
+func TestUserClientRejectedIDIsUnknownUser(t *testing.T) {
+	users := &UserClient{client: fakeUserService{err: status.Error(codes.InvalidArgument, "user id must be positive")}}
+	feedbackService := service.NewFeedbackService(nil, nil, service.Options{Users: users})
+
+	ctx := service.WithCaller(context.Background(), service.Caller{UserID: 3, Role: service.RoleInstructor})
+	_, err := feedbackService.ListFeedbacks(ctx, &service.ListFeedbacksParams{UserIDs: []int64{-1}})
+	if !errors.Is(err, service.ErrUnknownUser) {
+		t.Fatalf("got %v, want ErrUnknownUser", err)
+	}
+	if code := status.Code(toStatusError(err)); code != codes.InvalidArgument {
+		t.Errorf("got %v, want InvalidArgument rather than Unavailable", code)
+	}
+}
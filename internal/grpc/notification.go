@@ -0,0 +1,37 @@
+package grpc
+
+import (
+	"context"
+	"log"
+
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+func (s *FeedbackGRPCServer) GetNotificationPreferences(ctx context.Context, req *proto.GetNotificationPreferencesRequest) (*proto.NotificationPreferences, error) {
+	pref, err := s.feedbackService.GetNotificationPreference(ctx, req.UserId)
+	if err != nil {
+		log.Printf("Failed to get notification preferences: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return toProtoNotificationPreferences(pref), nil
+}
+
+func (s *FeedbackGRPCServer) UpdateNotificationPreferences(ctx context.Context, req *proto.UpdateNotificationPreferencesRequest) (*proto.NotificationPreferences, error) {
+	pref, err := s.feedbackService.SetNotificationPreference(ctx, req.UserId, req.Mode)
+	if err != nil {
+		log.Printf("Failed to update notification preferences: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return toProtoNotificationPreferences(pref), nil
+}
+
+func toProtoNotificationPreferences(pref *models.NotificationPreference) *proto.NotificationPreferences {
+	return &proto.NotificationPreferences{
+		UserId:    pref.UserID,
+		Mode:      pref.Mode,
+		UpdatedAt: pref.UpdatedAt.Unix(),
+	}
+}
@@ -0,0 +1,164 @@
+package grpc
+
+import (
+	"context"
+	"log"
+
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+func (s *FeedbackGRPCServer) DefineRubric(ctx context.Context, req *proto.DefineRubricRequest) (*proto.DefineRubricResponse, error) {
+	criteria := make([]models.RubricCriterion, len(req.Criteria))
+	for i, criterion := range req.Criteria {
+		levels := make([]models.RubricLevel, len(criterion.Levels))
+		for j, level := range criterion.Levels {
+			levels[j] = models.RubricLevel{
+				Points:      level.Points,
+				Title:       level.Title,
+				Description: level.Description,
+			}
+		}
+		criteria[i] = models.RubricCriterion{
+			ID:          criterion.Id,
+			Title:       criterion.Title,
+			Description: criterion.Description,
+			Levels:      levels,
+		}
+	}
+
+	rubric, err := s.feedbackService.DefineRubric(ctx, &service.DefineRubricParams{
+		LabID:    req.LabId,
+		Title:    req.Title,
+		Criteria: criteria,
+	})
+	if err != nil {
+		log.Printf("Failed to define rubric: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.DefineRubricResponse{
+		Rubric: toProtoRubric(rubric),
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) GetRubric(ctx context.Context, req *proto.GetRubricRequest) (*proto.GetRubricResponse, error) {
+	rubric, err := s.feedbackService.GetRubric(ctx, req.LabId, int(req.Version))
+	if err != nil {
+		log.Printf("Failed to get rubric: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.GetRubricResponse{
+		Rubric: toProtoRubric(rubric),
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) ScoreFeedback(ctx context.Context, req *proto.ScoreFeedbackRequest) (*proto.ScoreFeedbackResponse, error) {
+	scores := make([]models.CriterionScore, len(req.Scores))
+	for i, score := range req.Scores {
+		scores[i] = models.CriterionScore{
+			CriterionID: score.CriterionId,
+			Points:      score.Points,
+			Comment:     score.Comment,
+		}
+	}
+
+	breakdown, err := s.feedbackService.ScoreFeedback(ctx, &service.ScoreFeedbackParams{
+		FeedbackID:    req.FeedbackId,
+		RubricVersion: int(req.RubricVersion),
+		Criteria:      scores,
+	})
+	if err != nil {
+		log.Printf("Failed to score feedback: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.ScoreFeedbackResponse{
+		Score: toProtoScoreBreakdown(breakdown),
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) GetFeedbackScore(ctx context.Context, req *proto.GetFeedbackScoreRequest) (*proto.GetFeedbackScoreResponse, error) {
+	breakdown, err := s.feedbackService.GetFeedbackScore(ctx, req.FeedbackId)
+	if err != nil {
+		log.Printf("Failed to get feedback score: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.GetFeedbackScoreResponse{
+		Score: toProtoScoreBreakdown(breakdown),
+	}, nil
+}
+
+func toProtoRubric(rubric *models.Rubric) *proto.Rubric {
+	criteria := make([]*proto.RubricCriterion, len(rubric.Criteria))
+	for i, criterion := range rubric.Criteria {
+		levels := make([]*proto.RubricLevel, len(criterion.Levels))
+		for j, level := range criterion.Levels {
+			levels[j] = &proto.RubricLevel{
+				Points:      level.Points,
+				Title:       level.Title,
+				Description: level.Description,
+			}
+		}
+		criteria[i] = &proto.RubricCriterion{
+			Id:          criterion.ID,
+			Title:       criterion.Title,
+			Description: criterion.Description,
+			Levels:      levels,
+		}
+	}
+
+	return &proto.Rubric{
+		Id:        rubric.ID,
+		LabId:     rubric.LabID,
+		Version:   int32(rubric.Version),
+		Title:     rubric.Title,
+		Criteria:  criteria,
+		CreatedBy: rubric.CreatedBy,
+		CreatedAt: rubric.CreatedAt.Unix(),
+	}
+}
+
+// toProtoScoreBreakdown lists scores in rubric order with the titles of criteria and awarded levels
+func toProtoScoreBreakdown(breakdown *service.ScoreBreakdown) *proto.ScoreBreakdown {
+	score := breakdown.Score
+
+	scores := make(map[string]models.CriterionScore, len(score.Criteria))
+	for _, criterionScore := range score.Criteria {
+		scores[criterionScore.CriterionID] = criterionScore
+	}
+
+	criteria := make([]*proto.CriterionBreakdown, 0, len(breakdown.Rubric.Criteria))
+	for _, criterion := range breakdown.Rubric.Criteria {
+		criterionScore := scores[criterion.ID]
+		item := &proto.CriterionBreakdown{
+			CriterionId: criterion.ID,
+			Title:       criterion.Title,
+			Points:      criterionScore.Points,
+			Comment:     criterionScore.Comment,
+		}
+		for _, level := range criterion.Levels {
+			if level.Points > item.MaxPoints {
+				item.MaxPoints = level.Points
+			}
+			if level.Points == criterionScore.Points {
+				item.LevelTitle = level.Title
+			}
+		}
+		criteria = append(criteria, item)
+	}
+
+	return &proto.ScoreBreakdown{
+		FeedbackId:    score.FeedbackID,
+		RubricId:      score.RubricID,
+		RubricVersion: int32(score.RubricVersion),
+		Criteria:      criteria,
+		Total:         score.Total,
+		MaxTotal:      score.MaxTotal,
+		ScoredBy:      score.ScoredBy,
+		ScoredAt:      score.ScoredAt.Unix(),
+	}
+}
@@ -1,16 +1,24 @@
 package grpc
 
 import (
+	"bufio"
 	"context"
 	"crypto/sha256"
 	"fmt"
 	"io"
 	"log"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	"github.com/Ravwvil/feedback/internal/grpc/proto"
 	"github.com/Ravwvil/feedback/internal/service"
+	"github.com/Ravwvil/feedback/internal/thumbnail"
 )
 
+// maxUploadPrealloc bounds the buffer allocated up front for the declared size of an upload
+const maxUploadPrealloc = 1 << 20
+
 type FeedbackGRPCServer struct {
 	proto.UnimplementedFeedbackServiceServer
 	feedbackService *service.FeedbackService
@@ -32,75 +40,109 @@ func (s *FeedbackGRPCServer) CreateFeedback(ctx context.Context, req *proto.Crea
 		Title:       req.Title,
 		Content:     req.Content,
 		ContentHash: contentHash,
+		Publish:     req.Publish,
 	})
 	if err != nil {
 		log.Printf("Failed to create feedback: %v", err)
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	return &proto.CreateFeedbackResponse{
-		Feedback: &proto.FeedbackFile{
-			Id:          feedback.ID,
-			UserId:      feedback.UserID,
-			LabId:       feedback.LabID,
-			Title:       feedback.Title,
-			Content:     feedback.Content,
-			ContentHash: feedback.ContentHash,
-			CreatedAt:   feedback.CreatedAt.Unix(),
-			UpdatedAt:   feedback.UpdatedAt.Unix(),
-		},
+		Feedback: toProtoFeedback(feedback),
+		Warnings: feedback.Warnings,
 	}, nil
 }
 
 func (s *FeedbackGRPCServer) GetFeedback(ctx context.Context, req *proto.GetFeedbackRequest) (*proto.GetFeedbackResponse, error) {
-	feedback, err := s.feedbackService.GetFeedback(ctx, req.Id)
+	mask, err := newFeedbackReadMask(req.ReadMask)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	// Rendering needs the content even when it is masked out of the response
+	includeContent := mask.includes("content", true) || req.RenderHtml
+	feedback, err := s.feedbackService.GetFeedback(ctx, req.Id, includeContent)
 	if err != nil {
 		log.Printf("Failed to get feedback: %v", err)
-		return nil, err
+		return nil, toStatusError(err)
+	}
+
+	if req.RenderHtml {
+		if err := s.feedbackService.RenderContent(ctx, feedback); err != nil {
+			log.Printf("Failed to render feedback: %v", err)
+			return nil, toStatusError(err)
+		}
 	}
 
 	return &proto.GetFeedbackResponse{
-		Feedback: &proto.FeedbackFile{
-			Id:          feedback.ID,
-			UserId:      feedback.UserID,
-			LabId:       feedback.LabID,
-			Title:       feedback.Title,
-			Content:     feedback.Content,
-			ContentHash: feedback.ContentHash,
-			CreatedAt:   feedback.CreatedAt.Unix(),
-			UpdatedAt:   feedback.UpdatedAt.Unix(),
-		},
+		Feedback: mask.apply(toProtoFeedback(feedback)),
 	}, nil
 }
 
-func (s *FeedbackGRPCServer) UpdateFeedback(ctx context.Context, req *proto.UpdateFeedbackRequest) (*proto.UpdateFeedbackResponse, error) {
-	var contentHash string
-	if req.Content != "" {
-		contentHash = fmt.Sprintf("%x", sha256.Sum256([]byte(req.Content)))
+func (s *FeedbackGRPCServer) BatchGetFeedback(ctx context.Context, req *proto.BatchGetFeedbackRequest) (*proto.BatchGetFeedbackResponse, error) {
+	mask, err := newFeedbackReadMask(req.ReadMask)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	items, err := s.feedbackService.BatchGetFeedback(ctx, req.Ids, mask.includes("content", true))
+	if err != nil {
+		log.Printf("Failed to batch get feedback: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	results := make([]*proto.BatchGetFeedbackResult, len(items))
+	for i, item := range items {
+		results[i] = &proto.BatchGetFeedbackResult{
+			Id:       item.ID,
+			NotFound: item.Feedback == nil,
+		}
+		if item.Feedback != nil {
+			results[i].Feedback = mask.apply(toProtoFeedback(item.Feedback))
+		}
+		if item.Err != nil {
+			results[i].Error = itemError(item.Err)
+		}
+	}
+
+	return &proto.BatchGetFeedbackResponse{
+		Results: results,
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) RenderFeedback(ctx context.Context, req *proto.RenderFeedbackRequest) (*proto.RenderFeedbackResponse, error) {
+	feedback, err := s.feedbackService.RenderFeedback(ctx, req.Id)
+	if err != nil {
+		log.Printf("Failed to render feedback: %v", err)
+		return nil, toStatusError(err)
 	}
 
+	return &proto.RenderFeedbackResponse{
+		Id:          feedback.ID,
+		ContentHash: feedback.ContentHash,
+		Html:        feedback.RenderedHTML,
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) UpdateFeedback(ctx context.Context, req *proto.UpdateFeedbackRequest) (*proto.UpdateFeedbackResponse, error) {
+	// Content set to empty through the update mask still gets a hash
+	contentHash := fmt.Sprintf("%x", sha256.Sum256([]byte(req.Content)))
+
 	feedback, err := s.feedbackService.UpdateFeedback(ctx, &service.UpdateFeedbackParams{
 		ID:          req.Id,
 		Title:       req.Title,
 		Content:     req.Content,
 		ContentHash: contentHash,
+		UpdateMask:  req.UpdateMask.GetPaths(),
 	})
 	if err != nil {
 		log.Printf("Failed to update feedback: %v", err)
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	return &proto.UpdateFeedbackResponse{
-		Feedback: &proto.FeedbackFile{
-			Id:          feedback.ID,
-			UserId:      feedback.UserID,
-			LabId:       feedback.LabID,
-			Title:       feedback.Title,
-			Content:     feedback.Content,
-			ContentHash: feedback.ContentHash,
-			CreatedAt:   feedback.CreatedAt.Unix(),
-			UpdatedAt:   feedback.UpdatedAt.Unix(),
-		},
+		Feedback: toProtoFeedback(feedback),
+		Warnings: feedback.Warnings,
 	}, nil
 }
 
@@ -108,7 +150,7 @@ func (s *FeedbackGRPCServer) DeleteFeedback(ctx context.Context, req *proto.Dele
 	err := s.feedbackService.DeleteFeedback(ctx, req.Id)
 	if err != nil {
 		log.Printf("Failed to delete feedback: %v", err)
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	return &proto.DeleteFeedbackResponse{
@@ -117,37 +159,67 @@ func (s *FeedbackGRPCServer) DeleteFeedback(ctx context.Context, req *proto.Dele
 }
 
 func (s *FeedbackGRPCServer) ListUserFeedbacks(ctx context.Context, req *proto.ListUserFeedbacksRequest) (*proto.ListUserFeedbacksResponse, error) {
-	feedbacks, totalCount, err := s.feedbackService.ListUserFeedbacks(ctx, &service.ListUserFeedbacksParams{
-		UserID: req.UserId,
-		LabID:  req.LabId,
-		Page:   int(req.Page),
-		Limit:  int(req.Limit),
+	mask, err := newFeedbackReadMask(req.ReadMask)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	page, err := s.feedbackService.ListUserFeedbacks(ctx, &service.ListUserFeedbacksParams{
+		UserID:            req.UserId,
+		LabID:             req.LabId,
+		Statuses:          req.Statuses,
+		Tags:              req.Tags,
+		TagMatch:          tagMatch(req.TagMatch),
+		IncludeContent:    mask.includes("content", false),
+		PageSize:          int(req.PageSize),
+		PageToken:         req.PageToken,
+		IncludeTotalCount: req.IncludeTotalCount,
 	})
 	if err != nil {
 		log.Printf("Failed to list user feedbacks: %v", err)
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
-	protoFeedbacks := make([]*proto.FeedbackFile, len(feedbacks))
-	for i, feedback := range feedbacks {
-		protoFeedbacks[i] = &proto.FeedbackFile{
-			Id:          feedback.ID,
-			UserId:      feedback.UserID,
-			LabId:       feedback.LabID,
-			Title:       feedback.Title,
-			Content:     feedback.Content,
-			ContentHash: feedback.ContentHash,
-			CreatedAt:   feedback.CreatedAt.Unix(),
-			UpdatedAt:   feedback.UpdatedAt.Unix(),
-		}
+	protoFeedbacks := make([]*proto.FeedbackFile, len(page.Items))
+	for i, feedback := range page.Items {
+		protoFeedbacks[i] = mask.apply(toProtoFeedback(feedback))
 	}
 
 	return &proto.ListUserFeedbacksResponse{
-		Feedbacks:  protoFeedbacks,
-		TotalCount: int32(totalCount),
+		Feedbacks:     protoFeedbacks,
+		TotalCount:    int32(page.TotalCount),
+		NextPageToken: page.NextPageToken,
 	}, nil
 }
 
+func (s *FeedbackGRPCServer) ExportFeedback(req *proto.ExportFeedbackRequest, stream proto.FeedbackService_ExportFeedbackServer) error {
+	// Batch small zip writes into 64KB messages
+	writer := bufio.NewWriterSize(&exportStreamWriter{stream: stream}, 1024*64)
+
+	err := s.feedbackService.ExportFeedback(stream.Context(), req.Id, writer)
+	if err != nil {
+		log.Printf("Failed to export feedback: %v", err)
+		return toStatusError(err)
+	}
+
+	return writer.Flush()
+}
+
+// exportStreamWriter sends everything written to it as ExportFeedbackResponse chunks
+type exportStreamWriter struct {
+	stream proto.FeedbackService_ExportFeedbackServer
+}
+
+func (w *exportStreamWriter) Write(p []byte) (int, error) {
+	err := w.stream.Send(&proto.ExportFeedbackResponse{
+		Chunk: p,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
 func (s *FeedbackGRPCServer) UploadAsset(stream proto.FeedbackService_UploadAssetServer) error {
 	var feedbackID, filename, contentType string
 	var totalSize int64
@@ -164,11 +236,24 @@ func (s *FeedbackGRPCServer) UploadAsset(stream proto.FeedbackService_UploadAsse
 		filename = metadata.Filename
 		contentType = metadata.ContentType
 		totalSize = metadata.TotalSize
-		buffer = make([]byte, 0, totalSize)
 	} else {
 		return fmt.Errorf("first message must contain metadata")
 	}
 
+	// The declared size is only a hint, the limits are enforced on the bytes received
+	maxSize := s.feedbackService.MaxAssetSize()
+	if totalSize < 0 || (maxSize > 0 && totalSize > maxSize) {
+		return status.Errorf(codes.InvalidArgument, "invalid total size %d", totalSize)
+	}
+
+	// Reject uploads exceeding a quota before receiving any data
+	err = s.feedbackService.CheckAssetQuota(stream.Context(), feedbackID, filename, totalSize)
+	if err != nil {
+		log.Printf("Failed to upload asset: %v", err)
+		return toStatusError(err)
+	}
+	buffer = make([]byte, 0, min(totalSize, maxUploadPrealloc))
+
 	// Receive file chunks
 	for {
 		req, err := stream.Recv()
@@ -180,29 +265,43 @@ func (s *FeedbackGRPCServer) UploadAsset(stream proto.FeedbackService_UploadAsse
 		}
 
 		if chunk := req.GetChunk(); chunk != nil {
+			// Validate the content type as soon as the first bytes arrive
+			if len(buffer) == 0 {
+				if _, err := s.feedbackService.ResolveAssetType(contentType, chunk); err != nil {
+					log.Printf("Failed to upload asset: %v", err)
+					return toStatusError(err)
+				}
+			}
 			buffer = append(buffer, chunk...)
 		}
+
+		// Stop receiving as soon as the single-asset limit is crossed
+		if maxSize > 0 && int64(len(buffer)) > maxSize {
+			return toStatusError(fmt.Errorf("%w: asset exceeds limit of %d bytes", service.ErrQuotaExceeded, maxSize))
+		}
 	}
 
 	// Upload to MinIO
-	size, err := s.feedbackService.UploadAsset(context.Background(), feedbackID, filename, contentType, buffer)
+	asset, err := s.feedbackService.UploadAsset(stream.Context(), feedbackID, filename, contentType, buffer)
 	if err != nil {
 		log.Printf("Failed to upload asset: %v", err)
-		return err
+		return toStatusError(err)
 	}
 
 	return stream.SendAndClose(&proto.UploadAssetResponse{
-		Filename: filename,
-		Size:     size,
-		Success:  true,
+		Filename:    filename,
+		Size:        asset.Size,
+		Success:     true,
+		ContentType: asset.ContentType,
+		ScanStatus:  asset.ScanStatus,
 	})
 }
 
 func (s *FeedbackGRPCServer) DownloadAsset(req *proto.DownloadAssetRequest, stream proto.FeedbackService_DownloadAssetServer) error {
-	assetInfo, data, err := s.feedbackService.DownloadAsset(context.Background(), req.FeedbackId, req.Filename)
+	assetInfo, data, err := s.feedbackService.DownloadAsset(stream.Context(), req.FeedbackId, req.Filename)
 	if err != nil {
 		log.Printf("Failed to download asset: %v", err)
-		return err
+		return toStatusError(err)
 	}
 
 	// Send asset info first
@@ -242,23 +341,72 @@ func (s *FeedbackGRPCServer) DownloadAsset(req *proto.DownloadAssetRequest, stre
 }
 
 func (s *FeedbackGRPCServer) ListAssets(ctx context.Context, req *proto.ListAssetsRequest) (*proto.ListAssetsResponse, error) {
-	assets, err := s.feedbackService.ListAssets(ctx, req.FeedbackId)
+	page, err := s.feedbackService.ListAssetsPage(ctx, &service.ListAssetsParams{
+		FeedbackID:        req.FeedbackId,
+		PageSize:          int(req.PageSize),
+		PageToken:         req.PageToken,
+		IncludeTotalCount: req.IncludeTotalCount,
+	})
 	if err != nil {
 		log.Printf("Failed to list assets: %v", err)
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
-	protoAssets := make([]*proto.AssetInfo, len(assets))
-	for i, asset := range assets {
+	protoAssets := make([]*proto.AssetInfo, len(page.Items))
+	for i, asset := range page.Items {
+		thumbnailSizes := make([]int32, len(asset.ThumbnailSizes))
+		for j, size := range asset.ThumbnailSizes {
+			thumbnailSizes[j] = int32(size)
+		}
+
 		protoAssets[i] = &proto.AssetInfo{
-			Filename:    asset.Filename,
-			Size:        asset.Size,
-			ContentType: asset.ContentType,
-			UploadedAt:  asset.UploadedAt.Unix(),
+			Filename:       asset.Filename,
+			Size:           asset.Size,
+			ContentType:    asset.ContentType,
+			UploadedAt:     asset.UploadedAt.Unix(),
+			HasThumbnail:   len(thumbnailSizes) > 0,
+			ThumbnailSizes: thumbnailSizes,
+			ScanStatus:     asset.ScanStatus,
 		}
 	}
 
 	return &proto.ListAssetsResponse{
-		Assets: protoAssets,
+		Assets:        protoAssets,
+		NextPageToken: page.NextPageToken,
+		TotalCount:    int32(page.TotalCount),
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) GetAssetThumbnail(ctx context.Context, req *proto.GetAssetThumbnailRequest) (*proto.GetAssetThumbnailResponse, error) {
+	data, size, err := s.feedbackService.GetAssetThumbnail(ctx, req.FeedbackId, req.Filename, int(req.Size))
+	if err != nil {
+		log.Printf("Failed to get asset thumbnail: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.GetAssetThumbnailResponse{
+		Size:        int32(size),
+		ContentType: thumbnail.ContentType,
+		Data:        data,
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) GetUsage(ctx context.Context, req *proto.GetUsageRequest) (*proto.GetUsageResponse, error) {
+	usage, err := s.feedbackService.GetUsage(ctx, req.UserId)
+	if err != nil {
+		log.Printf("Failed to get usage: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.GetUsageResponse{
+		UserId:     usage.UserID,
+		AssetCount: usage.AssetCount,
+		TotalBytes: usage.TotalBytes,
+		Limits: &proto.QuotaLimits{
+			MaxAssetsPerFeedback: usage.Limits.MaxAssetsPerFeedback,
+			MaxBytesPerFeedback:  usage.Limits.MaxBytesPerFeedback,
+			MaxBytesPerUser:      usage.Limits.MaxBytesPerUser,
+			MaxAssetSize:         usage.Limits.MaxAssetSize,
+		},
 	}, nil
 }
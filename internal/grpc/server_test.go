@@ -0,0 +1,93 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+// uploadStream replays requests to UploadAsset
+type uploadStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	requests []*proto.UploadAssetRequest
+	response *proto.UploadAssetResponse
+}
+
+func (s *uploadStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *uploadStream) Recv() (*proto.UploadAssetRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
+
+func (s *uploadStream) SendAndClose(resp *proto.UploadAssetResponse) error {
+	s.response = resp
+	return nil
+}
+
+func TestUploadAssetRejectsInvalidTotalSize(t *testing.T) {
+	// Without a repository the test fails loudly if the size reaches the quota check
+	feedbackService := service.NewFeedbackService(nil, nil, service.Options{
+		Quotas: service.QuotaLimits{MaxAssetSize: 1024},
+	})
+	server := NewFeedbackGRPCServer(feedbackService)
+
+	for _, size := range []int64{-1, 1025, 1 << 62} {
+		stream := &uploadStream{
+			ctx: context.Background(),
+			requests: []*proto.UploadAssetRequest{{
+				Data: &proto.UploadAssetRequest_Metadata{Metadata: &proto.AssetMetadata{
+					FeedbackId: "f",
+					Filename:   "a.png",
+					TotalSize:  size,
+				}},
+			}},
+		}
+
+		err := server.UploadAsset(stream)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("total size %d: got %v, want InvalidArgument", size, err)
+		}
+	}
+}
+
+// exportStream collects the chunks sent by ExportFeedback
+type exportStream struct {
+	grpc.ServerStream
+	chunks [][]byte
+}
+
+func (s *exportStream) Send(resp *proto.ExportFeedbackResponse) error {
+	s.chunks = append(s.chunks, append([]byte(nil), resp.Chunk...))
+	return nil
+}
+
+func TestExportStreamWriterSendsChunks(t *testing.T) {
+	stream := &exportStream{}
+	w := &exportStreamWriter{stream: stream}
+
+	for _, part := range []string{"zip ", "bytes"} {
+		n, err := w.Write([]byte(part))
+		if err != nil || n != len(part) {
+			t.Fatalf("got %d, %v", n, err)
+		}
+	}
+
+	if len(stream.chunks) != 2 || string(stream.chunks[0])+string(stream.chunks[1]) != "zip bytes" {
+		t.Errorf("got chunks %q", stream.chunks)
+	}
+}
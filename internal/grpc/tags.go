@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"context"
+	"log"
+
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+	"github.com/Ravwvil/feedback/internal/repository"
+)
+
+func (s *FeedbackGRPCServer) AddTags(ctx context.Context, req *proto.ModifyTagsRequest) (*proto.ModifyTagsResponse, error) {
+	feedback, err := s.feedbackService.AddTags(ctx, req.Id, req.Tags)
+	if err != nil {
+		log.Printf("Failed to add tags: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.ModifyTagsResponse{
+		Feedback: toProtoFeedback(feedback),
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) RemoveTags(ctx context.Context, req *proto.ModifyTagsRequest) (*proto.ModifyTagsResponse, error) {
+	feedback, err := s.feedbackService.RemoveTags(ctx, req.Id, req.Tags)
+	if err != nil {
+		log.Printf("Failed to remove tags: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.ModifyTagsResponse{
+		Feedback: toProtoFeedback(feedback),
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) ListLabTags(ctx context.Context, req *proto.ListLabTagsRequest) (*proto.ListLabTagsResponse, error) {
+	counts, err := s.feedbackService.GetLabTags(ctx, req.LabId)
+	if err != nil {
+		log.Printf("Failed to list lab tags: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	tags := make([]*proto.TagCount, len(counts))
+	for i, count := range counts {
+		tags[i] = &proto.TagCount{
+			Name:  count.Name,
+			Count: count.Count,
+		}
+	}
+
+	return &proto.ListLabTagsResponse{
+		Tags: tags,
+	}, nil
+}
+
+func tagMatch(match proto.TagMatch) string {
+	if match == proto.TagMatch_TAG_MATCH_ALL {
+		return repository.TagMatchAll
+	}
+	return repository.TagMatchAny
+}
@@ -0,0 +1,118 @@
+package grpc
+
+import (
+	"context"
+	"log"
+
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+func (s *FeedbackGRPCServer) CreateTemplate(ctx context.Context, req *proto.CreateTemplateRequest) (*proto.TemplateResponse, error) {
+	tmpl, err := s.feedbackService.CreateTemplate(ctx, &service.TemplateParams{
+		LabID:       req.LabId,
+		Name:        req.Name,
+		Description: req.Description,
+		Body:        req.Body,
+	})
+	if err != nil {
+		log.Printf("Failed to create template: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.TemplateResponse{
+		Template: toProtoTemplate(tmpl),
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) GetTemplate(ctx context.Context, req *proto.GetTemplateRequest) (*proto.TemplateResponse, error) {
+	tmpl, err := s.feedbackService.GetTemplate(ctx, req.Id)
+	if err != nil {
+		log.Printf("Failed to get template: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.TemplateResponse{
+		Template: toProtoTemplate(tmpl),
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) UpdateTemplate(ctx context.Context, req *proto.UpdateTemplateRequest) (*proto.TemplateResponse, error) {
+	tmpl, err := s.feedbackService.UpdateTemplate(ctx, &service.TemplateParams{
+		ID:          req.Id,
+		Name:        req.Name,
+		Description: req.Description,
+		Body:        req.Body,
+	})
+	if err != nil {
+		log.Printf("Failed to update template: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.TemplateResponse{
+		Template: toProtoTemplate(tmpl),
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) DeleteTemplate(ctx context.Context, req *proto.DeleteTemplateRequest) (*proto.DeleteTemplateResponse, error) {
+	err := s.feedbackService.DeleteTemplate(ctx, req.Id)
+	if err != nil {
+		log.Printf("Failed to delete template: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.DeleteTemplateResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) ListTemplates(ctx context.Context, req *proto.ListTemplatesRequest) (*proto.ListTemplatesResponse, error) {
+	templates, err := s.feedbackService.ListTemplates(ctx, req.LabId, req.IncludeGlobal)
+	if err != nil {
+		log.Printf("Failed to list templates: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	protoTemplates := make([]*proto.FeedbackTemplate, len(templates))
+	for i, tmpl := range templates {
+		protoTemplates[i] = toProtoTemplate(tmpl)
+	}
+
+	return &proto.ListTemplatesResponse{
+		Templates: protoTemplates,
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) CreateFeedbackFromTemplate(ctx context.Context, req *proto.CreateFeedbackFromTemplateRequest) (*proto.CreateFeedbackResponse, error) {
+	feedback, err := s.feedbackService.CreateFeedbackFromTemplate(ctx, &service.CreateFeedbackFromTemplateParams{
+		TemplateID: req.TemplateId,
+		UserID:     req.UserId,
+		LabID:      req.LabId,
+		Title:      req.Title,
+		Variables:  req.Variables,
+		Publish:    req.Publish,
+	})
+	if err != nil {
+		log.Printf("Failed to create feedback from template: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.CreateFeedbackResponse{
+		Feedback: toProtoFeedback(feedback),
+		Warnings: feedback.Warnings,
+	}, nil
+}
+
+func toProtoTemplate(tmpl *models.FeedbackTemplate) *proto.FeedbackTemplate {
+	return &proto.FeedbackTemplate{
+		Id:          tmpl.ID,
+		LabId:       tmpl.LabID,
+		Name:        tmpl.Name,
+		Description: tmpl.Description,
+		Body:        tmpl.Body,
+		CreatedBy:   tmpl.CreatedBy,
+		CreatedAt:   tmpl.CreatedAt.Unix(),
+		UpdatedAt:   tmpl.UpdatedAt.Unix(),
+	}
+}
@@ -0,0 +1,46 @@
+package grpc
+
+import (
+	"context"
+	"log"
+
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+func (s *FeedbackGRPCServer) ListTrash(ctx context.Context, req *proto.ListTrashRequest) (*proto.ListTrashResponse, error) {
+	page, err := s.feedbackService.ListTrash(ctx, &service.ListTrashParams{
+		UserID:            req.UserId,
+		LabID:             req.LabId,
+		PageSize:          int(req.PageSize),
+		PageToken:         req.PageToken,
+		IncludeTotalCount: req.IncludeTotalCount,
+	})
+	if err != nil {
+		log.Printf("Failed to list trash: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	protoFeedbacks := make([]*proto.FeedbackFile, len(page.Items))
+	for i, feedback := range page.Items {
+		protoFeedbacks[i] = toProtoFeedback(feedback)
+	}
+
+	return &proto.ListTrashResponse{
+		Feedbacks:     protoFeedbacks,
+		TotalCount:    int32(page.TotalCount),
+		NextPageToken: page.NextPageToken,
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) RestoreFeedback(ctx context.Context, req *proto.RestoreFeedbackRequest) (*proto.RestoreFeedbackResponse, error) {
+	feedback, err := s.feedbackService.RestoreFeedback(ctx, req.Id)
+	if err != nil {
+		log.Printf("Failed to restore feedback: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.RestoreFeedbackResponse{
+		Feedback: toProtoFeedback(feedback),
+	}, nil
+}
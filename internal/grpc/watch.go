@@ -0,0 +1,71 @@
+package grpc
+
+import (
+	"context"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Ravwvil/feedback/internal/events"
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+)
+
+func (s *FeedbackGRPCServer) WatchFeedback(req *proto.WatchFeedbackRequest, stream proto.FeedbackService_WatchFeedbackServer) error {
+	sub, err := s.feedbackService.WatchFeedback(stream.Context(), req.Id)
+	if err != nil {
+		log.Printf("Failed to watch feedback: %v", err)
+		return toStatusError(err)
+	}
+	defer sub.Close()
+
+	return streamEvents(stream.Context(), sub, stream.Send)
+}
+
+func (s *FeedbackGRPCServer) WatchLab(req *proto.WatchLabRequest, stream proto.FeedbackService_WatchLabServer) error {
+	sub, err := s.feedbackService.WatchLab(stream.Context(), req.LabId)
+	if err != nil {
+		log.Printf("Failed to watch lab: %v", err)
+		return toStatusError(err)
+	}
+	defer sub.Close()
+
+	return streamEvents(stream.Context(), sub, stream.Send)
+}
+
+// streamEvents sends events until the client goes away or the subscription is dropped
+func streamEvents(ctx context.Context, sub *events.Subscription, send func(*proto.FeedbackEvent) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Clients that fell behind reconnect and reload the current state
+				return status.Error(codes.Unavailable, sub.Err().Error())
+			}
+			if err := send(toProtoEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toProtoEvent(event events.Event) *proto.FeedbackEvent {
+	return &proto.FeedbackEvent{
+		Type:       eventTypes[event.Type],
+		FeedbackId: event.FeedbackID,
+		LabId:      event.LabID,
+		UserId:     event.UserID,
+		Status:     event.Status,
+		Filename:   event.Filename,
+		OccurredAt: event.OccurredAt.Unix(),
+	}
+}
+
+var eventTypes = map[string]proto.FeedbackEventType{
+	events.TypeCreated:    proto.FeedbackEventType_FEEDBACK_EVENT_TYPE_CREATED,
+	events.TypeUpdated:    proto.FeedbackEventType_FEEDBACK_EVENT_TYPE_UPDATED,
+	events.TypeDeleted:    proto.FeedbackEventType_FEEDBACK_EVENT_TYPE_DELETED,
+	events.TypeAssetAdded: proto.FeedbackEventType_FEEDBACK_EVENT_TYPE_ASSET_ADDED,
+}
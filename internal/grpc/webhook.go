@@ -0,0 +1,139 @@
+package grpc
+
+import (
+	"context"
+	"log"
+
+	"github.com/Ravwvil/feedback/internal/grpc/proto"
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+func (s *FeedbackGRPCServer) CreateWebhook(ctx context.Context, req *proto.CreateWebhookRequest) (*proto.WebhookResponse, error) {
+	sub, err := s.feedbackService.CreateWebhook(ctx, &service.WebhookParams{
+		URL:        req.Url,
+		Secret:     req.Secret,
+		EventTypes: req.EventTypes,
+		LabID:      req.LabId,
+	})
+	if err != nil {
+		log.Printf("Failed to create webhook: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.WebhookResponse{
+		Webhook: toProtoWebhook(sub),
+		Secret:  sub.Secret,
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) GetWebhook(ctx context.Context, req *proto.GetWebhookRequest) (*proto.WebhookResponse, error) {
+	sub, err := s.feedbackService.GetWebhook(ctx, req.Id)
+	if err != nil {
+		log.Printf("Failed to get webhook: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.WebhookResponse{
+		Webhook: toProtoWebhook(sub),
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) UpdateWebhook(ctx context.Context, req *proto.UpdateWebhookRequest) (*proto.WebhookResponse, error) {
+	sub, err := s.feedbackService.UpdateWebhook(ctx, &service.WebhookParams{
+		ID:         req.Id,
+		URL:        req.Url,
+		Secret:     req.Secret,
+		EventTypes: req.EventTypes,
+		Enabled:    req.Enabled,
+	})
+	if err != nil {
+		log.Printf("Failed to update webhook: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.WebhookResponse{
+		Webhook: toProtoWebhook(sub),
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) DeleteWebhook(ctx context.Context, req *proto.DeleteWebhookRequest) (*proto.DeleteWebhookResponse, error) {
+	err := s.feedbackService.DeleteWebhook(ctx, req.Id)
+	if err != nil {
+		log.Printf("Failed to delete webhook: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	return &proto.DeleteWebhookResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) ListWebhooks(ctx context.Context, req *proto.ListWebhooksRequest) (*proto.ListWebhooksResponse, error) {
+	subs, err := s.feedbackService.ListWebhooks(ctx, req.LabId)
+	if err != nil {
+		log.Printf("Failed to list webhooks: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	protoWebhooks := make([]*proto.Webhook, len(subs))
+	for i, sub := range subs {
+		protoWebhooks[i] = toProtoWebhook(sub)
+	}
+
+	return &proto.ListWebhooksResponse{
+		Webhooks: protoWebhooks,
+	}, nil
+}
+
+func (s *FeedbackGRPCServer) ListWebhookDeliveries(ctx context.Context, req *proto.ListWebhookDeliveriesRequest) (*proto.ListWebhookDeliveriesResponse, error) {
+	page, err := s.feedbackService.ListWebhookDeliveries(ctx, &service.ListWebhookDeliveriesParams{
+		WebhookID:         req.WebhookId,
+		PageSize:          int(req.PageSize),
+		PageToken:         req.PageToken,
+		IncludeTotalCount: req.IncludeTotalCount,
+	})
+	if err != nil {
+		log.Printf("Failed to list webhook deliveries: %v", err)
+		return nil, toStatusError(err)
+	}
+
+	protoDeliveries := make([]*proto.WebhookDelivery, len(page.Items))
+	for i, delivery := range page.Items {
+		protoDeliveries[i] = &proto.WebhookDelivery{
+			Id:            delivery.ID,
+			WebhookId:     delivery.SubscriptionID,
+			EventId:       delivery.EventID,
+			EventType:     delivery.EventType,
+			Status:        delivery.Status,
+			Attempts:      int32(delivery.Attempts),
+			ResponseCode:  int32(delivery.ResponseCode),
+			LastError:     delivery.LastError,
+			NextAttemptAt: delivery.NextAttemptAt.Unix(),
+			CreatedAt:     delivery.CreatedAt.Unix(),
+			CompletedAt:   unixOrZero(delivery.CompletedAt),
+		}
+	}
+
+	return &proto.ListWebhookDeliveriesResponse{
+		Deliveries:    protoDeliveries,
+		TotalCount:    int32(page.TotalCount),
+		NextPageToken: page.NextPageToken,
+	}, nil
+}
+
+// toProtoWebhook leaves out the secret, it is only returned on creation
+func toProtoWebhook(sub *models.WebhookSubscription) *proto.Webhook {
+	return &proto.Webhook{
+		Id:                  sub.ID,
+		Url:                 sub.URL,
+		EventTypes:          sub.EventTypes,
+		LabId:               sub.LabID,
+		Enabled:             sub.Enabled,
+		ConsecutiveFailures: int32(sub.ConsecutiveFailures),
+		DisabledAt:          unixOrZero(sub.DisabledAt),
+		CreatedBy:           sub.CreatedBy,
+		CreatedAt:           sub.CreatedAt.Unix(),
+		UpdatedAt:           sub.UpdatedAt.Unix(),
+	}
+}
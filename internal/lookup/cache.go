@@ -0,0 +1,139 @@
+package lookup
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// CacheOptions configures how long answers of the external services are reused
+type CacheOptions struct {
+	PositiveTTL time.Duration // How long an existing id is trusted, 0 disables caching it
+	NegativeTTL time.Duration // How long an unknown id stays unknown, 0 disables caching it
+	Capacity    int           // Ids kept per service, the least recently used are evicted first
+}
+
+// existenceCache keeps the most recently used answers keyed by id. Failed lookups are never cached.
+type existenceCache struct {
+	mu      sync.Mutex
+	opts    CacheOptions
+	order   *list.List
+	entries map[int64]*list.Element
+}
+
+type cacheEntry struct {
+	id      int64
+	exists  bool
+	expires time.Time
+}
+
+func newExistenceCache(opts CacheOptions) *existenceCache {
+	if opts.Capacity <= 0 {
+		opts.Capacity = 10000
+	}
+	return &existenceCache{
+		opts:    opts,
+		order:   list.New(),
+		entries: make(map[int64]*list.Element),
+	}
+}
+
+// lookup answers from the cache when possible and asks fetch otherwise
+func (c *existenceCache) lookup(ctx context.Context, id int64, fetch func(context.Context, int64) (bool, error)) (bool, error) {
+	if exists, ok := c.get(id); ok {
+		return exists, nil
+	}
+
+	exists, err := fetch(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	c.put(id, exists)
+	return exists, nil
+}
+
+func (c *existenceCache) get(id int64) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[id]
+	if !ok {
+		return false, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(element)
+		delete(c.entries, id)
+		return false, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.exists, true
+}
+
+func (c *existenceCache) put(id int64, exists bool) {
+	ttl := c.opts.NegativeTTL
+	if exists {
+		ttl = c.opts.PositiveTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := time.Now().Add(ttl)
+	if element, ok := c.entries[id]; ok {
+		entry := element.Value.(*cacheEntry)
+		entry.exists = exists
+		entry.expires = expires
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[id] = c.order.PushFront(&cacheEntry{id: id, exists: exists, expires: expires})
+
+	if c.order.Len() > c.opts.Capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).id)
+	}
+}
+
+// CachedUsers caches the answers of a UserService
+type CachedUsers struct {
+	users UserService
+	cache *existenceCache
+}
+
+func NewCachedUsers(users UserService, opts CacheOptions) *CachedUsers {
+	return &CachedUsers{
+		users: users,
+		cache: newExistenceCache(opts),
+	}
+}
+
+func (c *CachedUsers) UserExists(ctx context.Context, userID int64) (bool, error) {
+	return c.cache.lookup(ctx, userID, c.users.UserExists)
+}
+
+// CachedLabs caches the answers of a LabService
+type CachedLabs struct {
+	labs  LabService
+	cache *existenceCache
+}
+
+func NewCachedLabs(labs LabService, opts CacheOptions) *CachedLabs {
+	return &CachedLabs{
+		labs:  labs,
+		cache: newExistenceCache(opts),
+	}
+}
+
+func (c *CachedLabs) LabExists(ctx context.Context, labID int64) (bool, error) {
+	return c.cache.lookup(ctx, labID, c.labs.LabExists)
+}
@@ -0,0 +1,167 @@
+package lookup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// countingFetch answers from a fixed set of ids and counts the calls per id
+type countingFetch struct {
+	ids   map[int64]bool
+	err   error
+	calls map[int64]int
+}
+
+func newCountingFetch(ids ...int64) *countingFetch {
+	fetch := &countingFetch{ids: make(map[int64]bool), calls: make(map[int64]int)}
+	for _, id := range ids {
+		fetch.ids[id] = true
+	}
+	return fetch
+}
+
+func (f *countingFetch) exists(_ context.Context, id int64) (bool, error) {
+	f.calls[id]++
+	if f.err != nil {
+		return false, f.err
+	}
+	return f.ids[id], nil
+}
+
+// lookupN looks id up n times and checks every answer
+func lookupN(t *testing.T, cache *existenceCache, fetch *countingFetch, id int64, n int, want bool) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		exists, err := cache.lookup(context.Background(), id, fetch.exists)
+		if err != nil || exists != want {
+			t.Fatalf("id %d: got %v, %v, want %v", id, exists, err, want)
+		}
+	}
+}
+
+// expire makes the cached answer of id outdated
+func expire(cache *existenceCache, id int64) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.entries[id].Value.(*cacheEntry).expires = time.Now().Add(-time.Second)
+}
+
+func TestExistenceCacheTTLs(t *testing.T) {
+	fetch := newCountingFetch(1)
+	cache := newExistenceCache(CacheOptions{PositiveTTL: time.Hour, NegativeTTL: time.Minute})
+
+	lookupN(t, cache, fetch, 1, 3, true)
+	lookupN(t, cache, fetch, 2, 3, false)
+	if fetch.calls[1] != 1 || fetch.calls[2] != 1 {
+		t.Errorf("got calls %v, want one per id", fetch.calls)
+	}
+
+	// Expired answers are fetched again and may have changed meanwhile
+	fetch.ids[2] = true
+	expire(cache, 2)
+	lookupN(t, cache, fetch, 2, 2, true)
+	if fetch.calls[2] != 2 {
+		t.Errorf("got %d calls for the expired id, want 2", fetch.calls[2])
+	}
+}
+
+func TestExistenceCacheZeroTTLDisablesCaching(t *testing.T) {
+	fetch := newCountingFetch(1)
+	cache := newExistenceCache(CacheOptions{PositiveTTL: time.Hour})
+
+	lookupN(t, cache, fetch, 1, 2, true)
+	lookupN(t, cache, fetch, 2, 2, false)
+	if fetch.calls[1] != 1 || fetch.calls[2] != 2 {
+		t.Errorf("got calls %v, want unknown ids fetched every time", fetch.calls)
+	}
+
+	cache = newExistenceCache(CacheOptions{NegativeTTL: time.Hour})
+	fetch = newCountingFetch(1)
+	lookupN(t, cache, fetch, 1, 2, true)
+	lookupN(t, cache, fetch, 2, 2, false)
+	if fetch.calls[1] != 2 || fetch.calls[2] != 1 {
+		t.Errorf("got calls %v, want known ids fetched every time", fetch.calls)
+	}
+}
+
+func TestExistenceCacheDoesNotCacheFailures(t *testing.T) {
+	fetch := newCountingFetch(1)
+	fetch.err = errors.New("unavailable")
+	cache := newExistenceCache(CacheOptions{PositiveTTL: time.Hour, NegativeTTL: time.Hour})
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.lookup(context.Background(), 1, fetch.exists); !errors.Is(err, fetch.err) {
+			t.Fatalf("got %v, want the fetch error", err)
+		}
+	}
+
+	fetch.err = nil
+	lookupN(t, cache, fetch, 1, 2, true)
+	if fetch.calls[1] != 3 {
+		t.Errorf("got %d calls, want the failures and one successful fetch", fetch.calls[1])
+	}
+}
+
+func TestExistenceCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	fetch := newCountingFetch(1, 2, 3)
+	cache := newExistenceCache(CacheOptions{PositiveTTL: time.Hour, Capacity: 2})
+
+	lookupN(t, cache, fetch, 1, 1, true)
+	lookupN(t, cache, fetch, 2, 1, true)
+	lookupN(t, cache, fetch, 1, 1, true) // 2 is now the least recently used
+	lookupN(t, cache, fetch, 3, 1, true)
+
+	if len(cache.entries) != 2 || cache.order.Len() != 2 {
+		t.Fatalf("got %d entries, want the capacity of 2", len(cache.entries))
+	}
+	if _, ok := cache.entries[2]; ok {
+		t.Error("least recently used id was kept")
+	}
+
+	lookupN(t, cache, fetch, 1, 1, true)
+	lookupN(t, cache, fetch, 2, 1, true)
+	if fetch.calls[1] != 1 || fetch.calls[2] != 2 || fetch.calls[3] != 1 {
+		t.Errorf("got calls %v, want only the evicted id fetched again", fetch.calls)
+	}
+}
+
+func TestNewExistenceCacheDefaultCapacity(t *testing.T) {
+	if cache := newExistenceCache(CacheOptions{}); cache.opts.Capacity != 10000 {
+		t.Errorf("got capacity %d, want 10000", cache.opts.Capacity)
+	}
+}
+
+func TestCachedServices(t *testing.T) {
+	ctx := context.Background()
+	opts := CacheOptions{PositiveTTL: time.Hour, NegativeTTL: time.Hour}
+
+	users := NewMemoryUsers(1)
+	cachedUsers := NewCachedUsers(users, opts)
+	if exists, err := cachedUsers.UserExists(ctx, 1); err != nil || !exists {
+		t.Fatalf("got %v, %v, want user 1", exists, err)
+	}
+	// Removed users stay known until their answer expires
+	users.Remove(1)
+	if exists, _ := cachedUsers.UserExists(ctx, 1); !exists {
+		t.Error("cached user was looked up again")
+	}
+
+	labs := NewMemoryLabs()
+	cachedLabs := NewCachedLabs(labs, opts)
+	if exists, err := cachedLabs.LabExists(ctx, 10); err != nil || exists {
+		t.Fatalf("got %v, %v, want lab 10 unknown", exists, err)
+	}
+	labs.Add(10)
+	if exists, _ := cachedLabs.LabExists(ctx, 10); exists {
+		t.Error("cached unknown lab was looked up again")
+	}
+
+	// Failures of the wrapped service pass through
+	labs.SetErr(errors.New("unavailable"))
+	if _, err := cachedLabs.LabExists(ctx, 11); err == nil {
+		t.Error("got no error from the unavailable lab service")
+	}
+}
@@ -0,0 +1,17 @@
+package lookup
+
+import (
+	"context"
+)
+
+// UserService tells whether a user exists in the external User Service
+type UserService interface {
+	// UserExists returns false for unknown users and an error when the answer is unknown
+	UserExists(ctx context.Context, userID int64) (bool, error)
+}
+
+// LabService tells whether a lab exists in the external Lab Service
+type LabService interface {
+	// LabExists returns false for unknown labs and an error when the answer is unknown
+	LabExists(ctx context.Context, labID int64) (bool, error)
+}
@@ -0,0 +1,100 @@
+package lookup
+
+import (
+	"context"
+	"sync"
+)
+
+// memorySet is a set of known ids that can be made to fail like an unreachable service
+type memorySet struct {
+	mu  sync.RWMutex
+	ids map[int64]bool
+	err error
+}
+
+func newMemorySet(ids []int64) *memorySet {
+	set := &memorySet{ids: make(map[int64]bool, len(ids))}
+	for _, id := range ids {
+		set.ids[id] = true
+	}
+	return set
+}
+
+func (s *memorySet) add(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids[id] = true
+}
+
+func (s *memorySet) remove(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.ids, id)
+}
+
+func (s *memorySet) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *memorySet) exists(id int64) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.err != nil {
+		return false, s.err
+	}
+	return s.ids[id], nil
+}
+
+// MemoryUsers is an in-memory UserService for development and tests
+type MemoryUsers struct {
+	set *memorySet
+}
+
+func NewMemoryUsers(userIDs ...int64) *MemoryUsers {
+	return &MemoryUsers{set: newMemorySet(userIDs)}
+}
+
+func (m *MemoryUsers) Add(userID int64) {
+	m.set.add(userID)
+}
+
+func (m *MemoryUsers) Remove(userID int64) {
+	m.set.remove(userID)
+}
+
+// SetErr makes every lookup fail with err until it is reset with nil
+func (m *MemoryUsers) SetErr(err error) {
+	m.set.setErr(err)
+}
+
+func (m *MemoryUsers) UserExists(ctx context.Context, userID int64) (bool, error) {
+	return m.set.exists(userID)
+}
+
+// MemoryLabs is an in-memory LabService for development and tests
+type MemoryLabs struct {
+	set *memorySet
+}
+
+func NewMemoryLabs(labIDs ...int64) *MemoryLabs {
+	return &MemoryLabs{set: newMemorySet(labIDs)}
+}
+
+func (m *MemoryLabs) Add(labID int64) {
+	m.set.add(labID)
+}
+
+func (m *MemoryLabs) Remove(labID int64) {
+	m.set.remove(labID)
+}
+
+// SetErr makes every lookup fail with err until it is reset with nil
+func (m *MemoryLabs) SetErr(err error) {
+	m.set.setErr(err)
+}
+
+func (m *MemoryLabs) LabExists(ctx context.Context, labID int64) (bool, error) {
+	return m.set.exists(labID)
+}
@@ -0,0 +1,67 @@
+package lookup
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMemoryUsers(t *testing.T) {
+	ctx := context.Background()
+	users := NewMemoryUsers(1, 2)
+
+	check := func(userID int64, want bool) {
+		t.Helper()
+		exists, err := users.UserExists(ctx, userID)
+		if err != nil || exists != want {
+			t.Errorf("user %d: got %v, %v, want %v", userID, exists, err, want)
+		}
+	}
+
+	check(1, true)
+	check(3, false)
+
+	users.Add(3)
+	users.Remove(1)
+	check(1, false)
+	check(3, true)
+
+	unavailable := errors.New("user service unavailable")
+	users.SetErr(unavailable)
+	if _, err := users.UserExists(ctx, 3); !errors.Is(err, unavailable) {
+		t.Errorf("got %v, want the configured error", err)
+	}
+
+	users.SetErr(nil)
+	check(3, true)
+}
+
+func TestMemoryLabs(t *testing.T) {
+	ctx := context.Background()
+	labs := NewMemoryLabs(10)
+
+	check := func(labID int64, want bool) {
+		t.Helper()
+		exists, err := labs.LabExists(ctx, labID)
+		if err != nil || exists != want {
+			t.Errorf("lab %d: got %v, %v, want %v", labID, exists, err, want)
+		}
+	}
+
+	check(10, true)
+	check(11, false)
+
+	labs.Add(11)
+	labs.Remove(10)
+	check(10, false)
+	check(11, true)
+
+	unavailable := errors.New("lab service unavailable")
+	labs.SetErr(unavailable)
+	if _, err := labs.LabExists(ctx, 11); !errors.Is(err, unavailable) {
+		t.Errorf("got %v, want the configured error", err)
+	}
+
+	labs.SetErr(nil)
+	check(11, true)
+}
@@ -0,0 +1,61 @@
+package markdown
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewCache(2)
+	cache.Put("a", "A", 0)
+	cache.Put("b", "B", 0)
+
+	// Reading a makes b the least recently used
+	if html, ok := cache.Get("a"); !ok || html != "A" {
+		t.Fatalf("got %q, %v", html, ok)
+	}
+	cache.Put("c", "C", 0)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("b was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+}
+
+func TestCacheExpiresEntries(t *testing.T) {
+	cache := NewCache(10)
+	cache.Put("short", "S", time.Millisecond)
+	cache.Put("forever", "F", 0)
+
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := cache.Get("short"); ok {
+		t.Error("expired entry was served")
+	}
+	if _, ok := cache.Get("forever"); !ok {
+		t.Error("entry without ttl expired")
+	}
+}
+
+func TestCacheUpdatesEntries(t *testing.T) {
+	cache := NewCache(10)
+	cache.Put("a", "old", 0)
+	cache.Put("a", "new", 0)
+
+	if html, _ := cache.Get("a"); html != "new" {
+		t.Errorf("got %q, want new", html)
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	cache := NewCache(0)
+	cache.Put("a", "A", 0)
+
+	if _, ok := cache.Get("a"); ok {
+		t.Error("cache without capacity stored an entry")
+	}
+}
@@ -0,0 +1,60 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+)
+
+// Renderer converts CommonMark/GFM documents into sanitized HTML
+type Renderer struct {
+	md     goldmark.Markdown
+	policy *bluemonday.Policy
+}
+
+// NewRenderer creates a renderer supporting GFM tables, task lists, strikethrough and autolinks
+func NewRenderer() *Renderer {
+	return &Renderer{
+		md:     goldmark.New(goldmark.WithExtensions(extension.GFM)),
+		policy: newPolicy(),
+	}
+}
+
+// newPolicy allows user generated content plus the markup produced by GFM extensions
+func newPolicy() *bluemonday.Policy {
+	policy := bluemonday.UGCPolicy()
+
+	// Fenced code blocks carry their language as a class
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[a-zA-Z0-9_+#-]+$`)).OnElements("code")
+
+	// Task list items are rendered as disabled checkboxes
+	policy.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	policy.AllowAttrs("checked", "disabled").OnElements("input")
+
+	// Table cell alignment
+	policy.AllowAttrs("style").Matching(regexp.MustCompile(`^text-align:\s*(left|right|center);?$`)).OnElements("th", "td")
+
+	policy.RequireNoReferrerOnLinks(true)
+	return policy
+}
+
+// Render converts markdown source into sanitized HTML, rewriting relative asset
+// references through resolve when it is not nil
+func (r *Renderer) Render(source []byte, resolve URLResolver) (string, error) {
+	doc := r.md.Parser().Parse(text.NewReader(source))
+	if resolve != nil {
+		rewriteAssetURLs(doc, resolve)
+	}
+
+	var buf bytes.Buffer
+	if err := r.md.Renderer().Render(&buf, source, doc); err != nil {
+		return "", fmt.Errorf("failed to render markdown: %w", err)
+	}
+
+	return r.policy.Sanitize(buf.String()), nil
+}
@@ -0,0 +1,72 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	renderer := NewRenderer()
+
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+		absent []string
+	}{
+		{
+			name:   "table with alignment",
+			source: "| a | b |\n|:--|--:|\n| 1 | 2 |\n",
+			want:   []string{"<table>", `<th style="text-align:left">a</th>`, `<td style="text-align:right">2</td>`},
+		},
+		{
+			name:   "task list",
+			source: "- [x] done\n- [ ] todo\n",
+			want:   []string{`<input checked="" disabled="" type="checkbox"`, `<input disabled="" type="checkbox"`},
+		},
+		{
+			name:   "fenced code keeps its language",
+			source: "```go\nfmt.Println(1)\n```\n",
+			want:   []string{`<code class="language-go">`},
+		},
+		{
+			name:   "strikethrough and autolinks",
+			source: "~~old~~ see https://example.com\n",
+			want:   []string{"<del>old</del>", `<a href="https://example.com" rel="nofollow noreferrer">`},
+		},
+		{
+			name:   "raw html and scripts are dropped",
+			source: "<script>alert(1)</script>\n\n<img src=x onerror=alert(1)>\n",
+			absent: []string{"<script", "onerror", "alert(1)</script>"},
+		},
+		{
+			name:   "javascript links are dropped",
+			source: "[click](javascript:alert(1))\n",
+			absent: []string{"javascript:"},
+		},
+		{
+			name:   "unexpected classes are dropped",
+			source: "```go\" onclick=\"x\nx\n```\n",
+			absent: []string{"onclick"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html, err := renderer.Render([]byte(tt.source), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(html, want) {
+					t.Errorf("%q does not contain %q", html, want)
+				}
+			}
+			for _, absent := range tt.absent {
+				if strings.Contains(html, absent) {
+					t.Errorf("%q contains %q", html, absent)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package markdown
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// AssetsPrefix is the relative path under which feedback assets are referenced
+const AssetsPrefix = "assets/"
+
+// URLResolver maps an asset filename to a fetchable URL, an empty URL leaves the reference as written
+type URLResolver func(filename string) string
+
+// AssetReferences returns the filenames of assets referenced by image and link destinations
+func (r *Renderer) AssetReferences(source []byte) []string {
+	doc := r.md.Parser().Parse(text.NewReader(source))
+
+	var filenames []string
+	seen := make(map[string]bool)
+
+	walkDestinations(doc, func(destination []byte) []byte {
+		if filename, ok := AssetFilename(string(destination)); ok && !seen[filename] {
+			seen[filename] = true
+			filenames = append(filenames, filename)
+		}
+		return nil
+	})
+
+	return filenames
+}
+
+// AssetFilename extracts the asset filename from a relative reference such as "assets/diagram.jpg"
+func AssetFilename(destination string) (string, bool) {
+	u, err := url.Parse(destination)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return "", false
+	}
+
+	name, ok := strings.CutPrefix(strings.TrimPrefix(u.Path, "./"), AssetsPrefix)
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+
+	return name, true
+}
+
+// walkDestinations calls fn for every link and image destination, a non-nil result replaces it
+func walkDestinations(doc ast.Node, fn func(destination []byte) []byte) {
+	ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := node.(type) {
+		case *ast.Image:
+			if replacement := fn(n.Destination); replacement != nil {
+				n.Destination = replacement
+			}
+		case *ast.Link:
+			if replacement := fn(n.Destination); replacement != nil {
+				n.Destination = replacement
+			}
+		}
+
+		return ast.WalkContinue, nil
+	})
+}
+
+// rewriteAssetURLs replaces relative asset references with URLs returned by resolve
+func rewriteAssetURLs(doc ast.Node, resolve URLResolver) {
+	walkDestinations(doc, func(destination []byte) []byte {
+		filename, ok := AssetFilename(string(destination))
+		if !ok {
+			return nil
+		}
+		if resolved := resolve(filename); resolved != "" {
+			return []byte(resolved)
+		}
+		return nil
+	})
+}
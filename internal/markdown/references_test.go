@@ -0,0 +1,60 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAssetFilename(t *testing.T) {
+	tests := []struct {
+		destination string
+		want        string
+		ok          bool
+	}{
+		{"assets/diagram.jpg", "diagram.jpg", true},
+		{"./assets/diagram.jpg", "diagram.jpg", true},
+		{"assets/my%20plot.png", "my plot.png", true},
+		{"assets/", "", false},
+		{"assets/sub/diagram.jpg", "", false},
+		{"https://example.com/assets/diagram.jpg", "", false},
+		{"//example.com/assets/diagram.jpg", "", false},
+		{"diagram.jpg", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := AssetFilename(tt.destination)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("AssetFilename(%q) = %q, %v, want %q, %v", tt.destination, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAssetReferences(t *testing.T) {
+	source := "![a](assets/a.png) [b](assets/b.pdf) ![again](assets/a.png) [web](https://example.com)\n"
+
+	got := NewRenderer().AssetReferences([]byte(source))
+	if want := []string{"a.png", "b.pdf"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRenderResolvesAssetReferences(t *testing.T) {
+	resolve := func(filename string) string {
+		if filename == "hidden.png" {
+			return ""
+		}
+		return "https://cdn.example.com/" + filename
+	}
+
+	html, err := NewRenderer().Render([]byte("![a](assets/a.png) ![h](assets/hidden.png) [web](https://example.com)\n"), resolve)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{`src="https://cdn.example.com/a.png"`, `src="assets/hidden.png"`, `href="https://example.com"`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("%q does not contain %q", html, want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+// Headers set by the API gateway for authenticated requests, the HTTP counterpart of the
+// x-user-id and x-user-role gRPC metadata
+const (
+	UserIDHeader   = "X-User-ID"
+	UserRoleHeader = "X-User-Role"
+)
+
+// Caller attaches the caller identified by the gateway headers to the request context.
+// Requests without a user ID stay anonymous, malformed headers are rejected.
+func Caller() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value := c.GetHeader(UserIDHeader)
+		if value == "" {
+			c.Next()
+			return
+		}
+
+		userID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || userID <= 0 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid " + UserIDHeader + " header"})
+			return
+		}
+
+		caller := service.Caller{UserID: userID, Role: service.RoleStudent}
+		if role := c.GetHeader(UserRoleHeader); role != "" {
+			if !service.ValidRole(role) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid " + UserRoleHeader + " header"})
+				return
+			}
+			caller.Role = role
+		}
+
+		c.Request = c.Request.WithContext(service.WithCaller(c.Request.Context(), caller))
+		c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ravwvil/feedback/internal/service"
+)
+
+func TestCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantStatus int
+		wantCaller service.Caller
+	}{
+		{"anonymous", nil, http.StatusOK, service.Caller{}},
+		{"student by default", map[string]string{UserIDHeader: "7"}, http.StatusOK, service.Caller{UserID: 7, Role: service.RoleStudent}},
+		{"instructor", map[string]string{UserIDHeader: "7", UserRoleHeader: "instructor"}, http.StatusOK, service.Caller{UserID: 7, Role: service.RoleInstructor}},
+		{"invalid user id", map[string]string{UserIDHeader: "seven"}, http.StatusUnauthorized, service.Caller{}},
+		{"non-positive user id", map[string]string{UserIDHeader: "0"}, http.StatusUnauthorized, service.Caller{}},
+		{"unknown role", map[string]string{UserIDHeader: "7", UserRoleHeader: "root"}, http.StatusUnauthorized, service.Caller{}},
+	}
+
+	for _, tt := range tests {
+		var got service.Caller
+		router := gin.New()
+		router.Use(Caller())
+		router.GET("/", func(c *gin.Context) {
+			got = service.CallerFromContext(c.Request.Context())
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for key, value := range tt.headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != tt.wantStatus || got != tt.wantCaller {
+			t.Errorf("%s: got %d %+v, want %d %+v", tt.name, rec.Code, got, tt.wantStatus, tt.wantCaller)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// FeedbackComment is a comment on a feedback, a reply points to the comment it answers
+type FeedbackComment struct {
+	ID         string    `json:"id" db:"id"`
+	FeedbackID string    `json:"feedback_id" db:"feedback_id"`
+	ParentID   string    `json:"parent_id,omitempty" db:"parent_id"` // Empty for top-level comments
+	AuthorID   int64     `json:"author_id" db:"author_id"`
+	Content    string    `json:"content" db:"content"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
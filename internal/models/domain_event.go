@@ -0,0 +1,33 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Domain event types published to other services
+const (
+	EventFeedbackCreated       = "feedback.created"
+	EventFeedbackUpdated       = "feedback.updated"
+	EventFeedbackStatusChanged = "feedback.status_changed"
+	EventFeedbackTagsChanged   = "feedback.tags_changed"
+	EventFeedbackScored        = "feedback.scored"
+	EventFeedbackDeleted       = "feedback.deleted"
+	EventFeedbackRestored      = "feedback.restored"
+	EventFeedbackPurged        = "feedback.purged"
+	EventAssetUploaded         = "asset.uploaded"
+	EventCommentCreated        = "comment.created"
+)
+
+// DomainEvent records a change of a feedback for other services. Delivery is at least once,
+// consumers deduplicate on ID; events of one feedback are delivered in order.
+type DomainEvent struct {
+	ID         string          `json:"id" db:"event_id"`
+	Type       string          `json:"type" db:"event_type"`
+	FeedbackID string          `json:"feedback_id" db:"feedback_id"`
+	LabID      int64           `json:"lab_id" db:"-"`
+	ActorID    int64           `json:"actor_id,omitempty" db:"-"`
+	OccurredAt time.Time       `json:"occurred_at" db:"created_at"`
+	Data       json.RawMessage `json:"data" db:"-"`
+	Attempts   int             `json:"-" db:"attempts"`
+}
@@ -14,12 +14,62 @@ type FeedbackFile struct {
 	ContentHash string    `json:"content_hash" db:"content_hash"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+
+	// Lifecycle state and the time of each transition
+	Status         string     `json:"status" db:"status"`
+	AuthorID       int64      `json:"author_id" db:"author_id"`
+	PublishedAt    *time.Time `json:"published_at,omitempty" db:"published_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty" db:"acknowledged_at"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+	ArchivedAt     *time.Time `json:"archived_at,omitempty" db:"archived_at"`
+
+	// Set while the feedback is in the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	DeletedBy int64      `json:"deleted_by,omitempty" db:"deleted_by"`
+
+	Tags []string `json:"tags,omitempty"` // Names from the lab tag vocabulary
+
+	RenderedHTML string   `json:"rendered_html,omitempty"` // Sanitized HTML, only set when requested
+	Warnings     []string `json:"warnings,omitempty"`      // Non-fatal problems found on create or update
 }
 
+// Feedback lifecycle states
+const (
+	StatusDraft        = "draft"
+	StatusPublished    = "published"
+	StatusAcknowledged = "acknowledged"
+	StatusResolved     = "resolved"
+	StatusArchived     = "archived"
+)
+
 // AssetInfo represents information about an uploaded asset
 type AssetInfo struct {
-	Filename    string    `json:"filename"`
-	Size        int64     `json:"size"`
-	ContentType string    `json:"content_type"`
-	UploadedAt  time.Time `json:"uploaded_at"`
+	Filename       string    `json:"filename"`
+	Size           int64     `json:"size"`
+	ContentType    string    `json:"content_type"`
+	UploadedAt     time.Time `json:"uploaded_at"`
+	ThumbnailSizes []int     `json:"thumbnail_sizes,omitempty"` // Generated thumbnail sizes in pixels
+	ScanStatus     string    `json:"scan_status,omitempty"`
+	ContentHash    string    `json:"content_hash,omitempty"` // SHA-256 of the stored content
+}
+
+// Asset scan states, assets are only downloadable once clean
+const (
+	ScanStatusPending  = "pending"
+	ScanStatusClean    = "clean"
+	ScanStatusInfected = "infected"
+	ScanStatusError    = "error"
+)
+
+// StorageUsage represents the asset storage consumed by a user
+type StorageUsage struct {
+	UserID     int64 `json:"user_id"`
+	AssetCount int64 `json:"asset_count"`
+	TotalBytes int64 `json:"total_bytes"`
+}
+
+// TagCount is a tag of a lab vocabulary with the number of feedbacks carrying it
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
 }
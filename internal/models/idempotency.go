@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// IdempotencyRecord remembers a mutating request so that a retry with the same key can be replayed
+type IdempotencyRecord struct {
+	CallerID    int64     `json:"caller_id" db:"caller_id"`
+	Method      string    `json:"method" db:"method"`
+	Key         string    `json:"idempotency_key" db:"idempotency_key"`
+	Fingerprint string    `json:"fingerprint" db:"fingerprint"` // SHA-256 of the request payload
+	Response    []byte    `json:"response" db:"response"`       // nil while the request is in progress
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at" db:"expires_at"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Notification modes a user can choose
+const (
+	NotifyImmediate    = "immediate"
+	NotifyDailyDigest  = "daily_digest"
+	NotifyWeeklyDigest = "weekly_digest"
+	NotifyOff          = "off"
+)
+
+// Digest periods
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// Notification states
+const (
+	NotificationPending = "pending"
+	NotificationSent    = "sent"
+	NotificationFailed  = "failed"  // Gave up after the last attempt
+	NotificationDigest  = "digest"  // Waiting for the recipient's next digest
+	NotificationSkipped = "skipped" // The feedback was deleted or hidden from the recipient before sending
+)
+
+// Notification kinds, each has its own email template
+const (
+	NotificationFeedbackPublished     = "feedback_published"
+	NotificationFeedbackStatusChanged = "feedback_status_changed" // Sent to the author when someone else moves their feedback
+	NotificationFeedbackComment       = "feedback_comment"        // Sent to the author when someone else comments on their feedback
+	NotificationCommentReply          = "comment_reply"           // Sent to the author of a comment someone else replied to
+)
+
+// NotificationPreference is how a user wants to be notified
+type NotificationPreference struct {
+	UserID    int64     `json:"user_id" db:"user_id"`
+	Mode      string    `json:"mode" db:"mode"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Notification tells one user about one event
+type Notification struct {
+	ID            int64           `json:"id" db:"id"`
+	EventID       string          `json:"event_id" db:"event_id"`
+	UserID        int64           `json:"user_id" db:"user_id"`
+	Kind          string          `json:"kind" db:"kind"`
+	FeedbackID    string          `json:"feedback_id" db:"feedback_id"`
+	Payload       json.RawMessage `json:"payload" db:"payload"` // Template data captured when the event happened
+	Status        string          `json:"status" db:"status"`
+	Attempts      int             `json:"attempts" db:"attempts"`
+	LastError     string          `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	SentAt        *time.Time      `json:"sent_at,omitempty" db:"sent_at"`
+	DigestID      int64           `json:"digest_id,omitempty" db:"digest_id"` // 0 until the notification is bundled into a digest
+}
+
+// Digest bundles the digest notifications of a user for one period. Its status
+// follows the notification states pending, sent and failed.
+type Digest struct {
+	ID                int64      `json:"id" db:"id"`
+	UserID            int64      `json:"user_id" db:"user_id"`
+	Period            string     `json:"period" db:"period"`
+	PeriodStart       time.Time  `json:"period_start" db:"period_start"`
+	PeriodEnd         time.Time  `json:"period_end" db:"period_end"`
+	NotificationCount int        `json:"notification_count" db:"notification_count"`
+	Status            string     `json:"status" db:"status"`
+	Attempts          int        `json:"attempts" db:"attempts"`
+	LastError         string     `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt     time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	SentAt            *time.Time `json:"sent_at,omitempty" db:"sent_at"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Rubric is a versioned grading scheme of a lab
+type Rubric struct {
+	ID        string            `json:"id" db:"id"`
+	LabID     int64             `json:"lab_id" db:"lab_id"`
+	Version   int               `json:"version" db:"version"`
+	Title     string            `json:"title" db:"title"`
+	Criteria  []RubricCriterion `json:"criteria" db:"criteria"`
+	CreatedBy int64             `json:"created_by" db:"created_by"`
+	CreatedAt time.Time         `json:"created_at" db:"created_at"`
+}
+
+// RubricCriterion is a graded aspect of a lab with its achievable point levels
+type RubricCriterion struct {
+	ID          string        `json:"id"`
+	Title       string        `json:"title"`
+	Description string        `json:"description,omitempty"`
+	Levels      []RubricLevel `json:"levels"`
+}
+
+// RubricLevel is one achievable outcome of a criterion
+type RubricLevel struct {
+	Points      float64 `json:"points"`
+	Title       string  `json:"title"`
+	Description string  `json:"description,omitempty"`
+}
+
+// FeedbackScore is the rubric grading attached to a feedback
+type FeedbackScore struct {
+	FeedbackID    string           `json:"feedback_id" db:"feedback_id"`
+	RubricID      string           `json:"rubric_id" db:"rubric_id"`
+	RubricVersion int              `json:"rubric_version"`
+	Criteria      []CriterionScore `json:"criteria" db:"criteria"`
+	Total         float64          `json:"total" db:"total"`
+	MaxTotal      float64          `json:"max_total" db:"max_total"`
+	ScoredBy      int64            `json:"scored_by" db:"scored_by"`
+	ScoredAt      time.Time        `json:"scored_at" db:"scored_at"`
+}
+
+// CriterionScore is the points awarded for one rubric criterion
+type CriterionScore struct {
+	CriterionID string  `json:"criterion_id"`
+	Points      float64 `json:"points"`
+	Comment     string  `json:"comment,omitempty"`
+}
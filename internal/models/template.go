@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// FeedbackTemplate is a markdown skeleton rendered into new feedback
+type FeedbackTemplate struct {
+	ID          string    `json:"id" db:"id"`
+	LabID       int64     `json:"lab_id" db:"lab_id"` // 0 for global templates
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	Body        string    `json:"body" db:"body"` // text/template source, e.g. "Hi {{student_name}}"
+	CreatedBy   int64     `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
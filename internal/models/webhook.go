@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Webhook delivery states
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed" // Gave up after the last attempt
+)
+
+// WebhookSubscription is an HTTP endpoint receiving domain events
+type WebhookSubscription struct {
+	ID                  string     `json:"id" db:"id"`
+	URL                 string     `json:"url" db:"url"`
+	Secret              string     `json:"-" db:"secret"`
+	EventTypes          []string   `json:"event_types" db:"event_types"` // Empty for every event type
+	LabID               int64      `json:"lab_id" db:"lab_id"`           // 0 for every lab
+	Enabled             bool       `json:"enabled" db:"enabled"`
+	ConsecutiveFailures int        `json:"consecutive_failures" db:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`
+	CreatedBy           int64      `json:"created_by" db:"created_by"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// WebhookDelivery is one event sent to one subscription, together with the outcome of its last attempt
+type WebhookDelivery struct {
+	ID             int64      `json:"id" db:"id"`
+	SubscriptionID string     `json:"subscription_id" db:"subscription_id"`
+	EventID        string     `json:"event_id" db:"event_id"`
+	EventType      string     `json:"event_type" db:"event_type"`
+	Payload        []byte     `json:"-" db:"payload"`
+	Status         string     `json:"status" db:"status"`
+	Attempts       int        `json:"attempts" db:"attempts"`
+	ResponseCode   int        `json:"response_code,omitempty" db:"response_code"` // 0 when no response was received
+	LastError      string     `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
@@ -0,0 +1,252 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+// digestKind is the template digests are rendered with
+const digestKind = "digest"
+
+// digestBatchSize bounds the users and digests handled per round
+const digestBatchSize = 50
+
+// DigestStore holds digests and the notifications waiting for them, implemented by
+// repository.FeedbackRepository
+type DigestStore interface {
+	ListDigestDueUsers(ctx context.Context, period string, periodEnd time.Time, limit int) ([]int64, error)
+	CreateDigest(ctx context.Context, userID int64, period string, periodEnd time.Time) (*models.Digest, error)
+	ClaimDigests(ctx context.Context, limit int, lease time.Duration) ([]*models.Digest, error)
+	ListDigestNotifications(ctx context.Context, digestID int64) ([]*models.Notification, error)
+	RecordDigestAttempt(ctx context.Context, digest *models.Digest) error
+}
+
+// DigestOptions configures the digest job
+type DigestOptions struct {
+	Hour        int           // Hour of the day in UTC at which digest periods end
+	Weekday     time.Weekday  // Day on which weekly digest periods end
+	Interval    time.Duration // Pause between rounds
+	MaxAttempts int           // A digest fails for good after this many attempts
+	FeedbackURL string        // Link to a feedback, "{id}" is replaced by its id; empty sends no link
+}
+
+// DigestActivity is one notification listed in a digest
+type DigestActivity struct {
+	Kind   string
+	Status string // Status of the feedback when it happened
+	At     time.Time
+}
+
+// DigestFeedback groups the activity of one feedback in a digest
+type DigestFeedback struct {
+	FeedbackInfo
+	URL      string
+	Activity []DigestActivity
+}
+
+// DigestData is passed to the digest templates
+type DigestData struct {
+	Recipient   *Recipient
+	Period      string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Count       int
+	Feedbacks   []*DigestFeedback
+}
+
+// DigestJob bundles the notifications of digest users into daily and weekly digests and emails
+// them. Digests are recorded before they are sent, so a restart neither repeats nor skips one.
+type DigestJob struct {
+	repo      DigestStore
+	directory Directory
+	mailer    Mailer
+	renderer  *Renderer
+	opts      DigestOptions
+}
+
+func NewDigestJob(repo DigestStore, directory Directory, mailer Mailer, renderer *Renderer, opts DigestOptions) (*DigestJob, error) {
+	if opts.Hour < 0 || opts.Hour > 23 {
+		return nil, fmt.Errorf("digest hour %d is not between 0 and 23", opts.Hour)
+	}
+	if opts.Weekday < time.Sunday || opts.Weekday > time.Saturday {
+		return nil, fmt.Errorf("invalid digest weekday %d", opts.Weekday)
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 15 * time.Minute
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+
+	return &DigestJob{
+		repo:      repo,
+		directory: directory,
+		mailer:    mailer,
+		renderer:  renderer,
+		opts:      opts,
+	}, nil
+}
+
+// Run creates and sends due digests until ctx is cancelled
+func (j *DigestJob) Run(ctx context.Context) {
+	for {
+		created, sent, err := j.RunOnce(ctx, time.Now())
+		if err != nil {
+			log.Printf("Failed to process digests: %v", err)
+		}
+
+		// Keep going while there is work, otherwise wait for the next round
+		if created > 0 || sent > 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(j.opts.Interval):
+		}
+	}
+}
+
+// RunOnce creates one batch of digests for the periods that ended by now and sends one batch
+// of due digests. It returns how many digests were created and attempted.
+func (j *DigestJob) RunOnce(ctx context.Context, now time.Time) (int, int, error) {
+	created := 0
+	for _, period := range []string{models.DigestDaily, models.DigestWeekly} {
+		end := j.periodEnd(now, period)
+
+		userIDs, err := j.repo.ListDigestDueUsers(ctx, period, end, digestBatchSize)
+		if err != nil {
+			return created, 0, fmt.Errorf("failed to list %s digest users: %w", period, err)
+		}
+
+		for _, userID := range userIDs {
+			digest, err := j.repo.CreateDigest(ctx, userID, period, end)
+			if err != nil {
+				return created, 0, fmt.Errorf("failed to create digest of user %d: %w", userID, err)
+			}
+			if digest != nil {
+				created++
+			}
+		}
+	}
+
+	digests, err := j.repo.ClaimDigests(ctx, digestBatchSize, sendLease)
+	if err != nil {
+		return created, 0, fmt.Errorf("failed to claim digests: %w", err)
+	}
+
+	for _, digest := range digests {
+		err := j.send(ctx, digest)
+
+		switch {
+		case err == nil:
+			digest.Status = models.NotificationSent
+			digest.LastError = ""
+		case errors.Is(err, errFeedbackHidden):
+			digest.Status = models.NotificationSkipped
+			digest.LastError = err.Error()
+		case errors.Is(err, ErrUnknownRecipient) || digest.Attempts >= j.opts.MaxAttempts:
+			digest.Status = models.NotificationFailed
+			digest.LastError = err.Error()
+		default:
+			digest.NextAttemptAt = time.Now().Add(retryDelay(digest.Attempts))
+			digest.LastError = err.Error()
+		}
+
+		if err := j.repo.RecordDigestAttempt(ctx, digest); err != nil {
+			log.Printf("Failed to record digest %d: %v", digest.ID, err)
+		}
+	}
+
+	return created, len(digests), nil
+}
+
+// periodEnd returns the end of the latest period that ended at or before now
+func (j *DigestJob) periodEnd(now time.Time, period string) time.Time {
+	now = now.UTC()
+	end := time.Date(now.Year(), now.Month(), now.Day(), j.opts.Hour, 0, 0, 0, time.UTC)
+	if end.After(now) {
+		end = end.AddDate(0, 0, -1)
+	}
+	if period == models.DigestWeekly {
+		end = end.AddDate(0, 0, -((int(end.Weekday()) - int(j.opts.Weekday) + 7) % 7))
+	}
+	return end
+}
+
+func (j *DigestJob) send(ctx context.Context, digest *models.Digest) error {
+	recipient, err := j.directory.Lookup(ctx, digest.UserID)
+	if err != nil {
+		return err
+	}
+
+	notifications, err := j.repo.ListDigestNotifications(ctx, digest.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list digest notifications: %w", err)
+	}
+	// Everything the digest bundled was deleted or hidden from the recipient since
+	if len(notifications) == 0 {
+		return errFeedbackHidden
+	}
+
+	data := &DigestData{
+		Recipient:   recipient,
+		Period:      digest.Period,
+		PeriodStart: digest.PeriodStart.UTC(),
+		PeriodEnd:   digest.PeriodEnd.UTC(),
+		Count:       len(notifications),
+	}
+
+	byID := make(map[string]*DigestFeedback)
+	for _, notification := range notifications {
+		var info FeedbackInfo
+		if err := json.Unmarshal(notification.Payload, &info); err != nil {
+			return fmt.Errorf("failed to decode notification %d: %w", notification.ID, err)
+		}
+
+		feedback, ok := byID[info.ID]
+		if !ok {
+			feedback = &DigestFeedback{FeedbackInfo: info, URL: j.feedbackURL(info.ID)}
+			byID[info.ID] = feedback
+			data.Feedbacks = append(data.Feedbacks, feedback)
+		}
+		// The latest title wins, the activity keeps the status of each step
+		feedback.Title = info.Title
+		feedback.Activity = append(feedback.Activity, DigestActivity{
+			Kind:   notification.Kind,
+			Status: info.Status,
+			At:     notification.CreatedAt.UTC(),
+		})
+	}
+
+	msg, err := j.renderer.Render(digestKind, recipient, data)
+	if err != nil {
+		return err
+	}
+
+	return j.mailer.Send(ctx, msg)
+}
+
+func (j *DigestJob) feedbackURL(id string) string {
+	if j.opts.FeedbackURL == "" {
+		return ""
+	}
+	return strings.ReplaceAll(j.opts.FeedbackURL, "{id}", id)
+}
+
+// ParseWeekday parses an English weekday name such as "monday"
+func ParseWeekday(name string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), name) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday %q", name)
+}
@@ -0,0 +1,410 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+// fakeDigestStore keeps digests and notifications in memory with the semantics of the repository:
+// a digest per user and period end, bundling in CreateDigest and leases in ClaimDigests.
+type fakeDigestStore struct {
+	mu            sync.Mutex
+	clock         time.Time // Current time of the store, due and lease times are compared to it
+	modes         map[int64]string
+	hidden        map[string]bool // Feedback deleted or hidden from its recipients since
+	notifications []*models.Notification
+	digests       []*models.Digest
+}
+
+func newFakeDigestStore(modes map[int64]string) *fakeDigestStore {
+	return &fakeDigestStore{clock: time.Now(), modes: modes}
+}
+
+// add queues a digest notification of a user
+func (s *fakeDigestStore) add(t *testing.T, userID int64, kind string, info FeedbackInfo, at time.Time) {
+	t.Helper()
+
+	encoded, err := json.Marshal(payload{FeedbackInfo: info})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifications = append(s.notifications, &models.Notification{
+		ID:         int64(len(s.notifications) + 1),
+		UserID:     userID,
+		Kind:       kind,
+		FeedbackID: info.ID,
+		Payload:    encoded,
+		Status:     models.NotificationDigest,
+		CreatedAt:  at,
+	})
+}
+
+func (s *fakeDigestStore) ListDigestDueUsers(_ context.Context, period string, periodEnd time.Time, limit int) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := make(map[int64]bool)
+	for _, n := range s.notifications {
+		weekly := s.modes[n.UserID] == models.NotifyWeeklyDigest
+		if n.Status == models.NotificationDigest && n.DigestID == 0 && n.CreatedAt.Before(periodEnd) &&
+			weekly == (period == models.DigestWeekly) && !s.hasDigestSince(n.UserID, periodEnd) {
+			due[n.UserID] = true
+		}
+	}
+
+	var userIDs []int64
+	for userID := range due {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+	if len(userIDs) > limit {
+		userIDs = userIDs[:limit]
+	}
+	return userIDs, nil
+}
+
+func (s *fakeDigestStore) hasDigestSince(userID int64, periodEnd time.Time) bool {
+	for _, d := range s.digests {
+		if d.UserID == userID && !d.PeriodEnd.Before(periodEnd) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *fakeDigestStore) CreateDigest(_ context.Context, userID int64, period string, periodEnd time.Time) (*models.Digest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var start time.Time
+	for _, d := range s.digests {
+		if d.UserID != userID {
+			continue
+		}
+		if d.PeriodEnd.Equal(periodEnd) {
+			return nil, nil
+		}
+		if d.PeriodEnd.After(start) {
+			start = d.PeriodEnd
+		}
+	}
+
+	var bundled []*models.Notification
+	for _, n := range s.notifications {
+		if n.UserID == userID && n.Status == models.NotificationDigest && n.DigestID == 0 && n.CreatedAt.Before(periodEnd) {
+			bundled = append(bundled, n)
+		}
+	}
+	if len(bundled) == 0 {
+		return nil, nil
+	}
+	if start.IsZero() {
+		start = bundled[0].CreatedAt
+	}
+
+	digest := &models.Digest{
+		ID:                int64(len(s.digests) + 1),
+		UserID:            userID,
+		Period:            period,
+		PeriodStart:       start,
+		PeriodEnd:         periodEnd,
+		NotificationCount: len(bundled),
+		Status:            models.NotificationPending,
+		NextAttemptAt:     s.clock,
+	}
+	for _, n := range bundled {
+		n.DigestID = digest.ID
+	}
+	s.digests = append(s.digests, digest)
+
+	created := *digest
+	return &created, nil
+}
+
+func (s *fakeDigestStore) ClaimDigests(_ context.Context, limit int, lease time.Duration) ([]*models.Digest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var claimed []*models.Digest
+	for _, d := range s.digests {
+		if len(claimed) == limit {
+			break
+		}
+		if d.Status == models.NotificationPending && !d.NextAttemptAt.After(s.clock) {
+			d.Attempts++
+			d.NextAttemptAt = s.clock.Add(lease)
+			copied := *d
+			claimed = append(claimed, &copied)
+		}
+	}
+	return claimed, nil
+}
+
+func (s *fakeDigestStore) ListDigestNotifications(_ context.Context, digestID int64) ([]*models.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var notifications []*models.Notification
+	for _, n := range s.notifications {
+		if n.DigestID == digestID && !s.hidden[n.FeedbackID] {
+			notifications = append(notifications, n)
+		}
+	}
+	return notifications, nil
+}
+
+func (s *fakeDigestStore) RecordDigestAttempt(_ context.Context, digest *models.Digest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.digests[digest.ID-1]
+	stored.Status = digest.Status
+	stored.LastError = digest.LastError
+	stored.NextAttemptAt = digest.NextAttemptAt
+	if digest.Status == models.NotificationPending {
+		return nil
+	}
+	for _, n := range s.notifications {
+		if n.DigestID == digest.ID {
+			n.Status = digest.Status
+		}
+	}
+	return nil
+}
+
+// digest returns a copy of a stored digest
+func (s *fakeDigestStore) digest(id int64) models.Digest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.digests[id-1]
+}
+
+func newTestDigestJob(t *testing.T, store DigestStore, mailer Mailer) *DigestJob {
+	t.Helper()
+
+	renderer, err := NewRenderer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := NewDigestJob(store, NewMemoryDirectory(ada, grace), mailer, renderer, DigestOptions{
+		Hour:        7,
+		Weekday:     time.Monday,
+		MaxAttempts: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+// runOnce runs one round of the job and checks how many digests were created and attempted
+func runOnce(t *testing.T, job *DigestJob, now time.Time, wantCreated, wantSent int) {
+	t.Helper()
+
+	created, sent, err := job.RunOnce(context.Background(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created != wantCreated || sent != wantSent {
+		t.Errorf("at %v: got %d created and %d attempted, want %d and %d", now, created, sent, wantCreated, wantSent)
+	}
+}
+
+func TestDigestJobPeriodBoundaries(t *testing.T) {
+	store := newFakeDigestStore(map[int64]string{2: models.NotifyDailyDigest, 1: models.NotifyWeeklyDigest})
+	mailer := NewMemoryMailer()
+	job := newTestDigestJob(t, store, mailer)
+
+	// Tuesday March 5th 2024, daily periods end at 07:00 UTC and weekly ones on Mondays
+	tuesday := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	store.add(t, 2, models.NotificationFeedbackPublished, labReview, tuesday.Add(5*time.Hour))
+	store.add(t, 2, models.NotificationCommentReply, labReview, tuesday.Add(6*time.Hour))
+	store.add(t, 1, models.NotificationCommentReply, labReview, tuesday.Add(6*time.Hour))
+	acknowledged := labReview
+	acknowledged.Status = models.StatusAcknowledged
+	store.add(t, 1, models.NotificationFeedbackStatusChanged, acknowledged, tuesday.Add(9*time.Hour))
+	store.add(t, 1, models.NotificationFeedbackComment, acknowledged, tuesday.Add(10*time.Hour))
+
+	// The period ending at 07:00 is still open at 06:59, the previous one had no activity
+	runOnce(t, job, tuesday.Add(7*time.Hour-time.Minute), 0, 0)
+
+	runOnce(t, job, tuesday.Add(7*time.Hour), 1, 1)
+	daily := store.digest(1)
+	if daily.UserID != 2 || daily.Period != models.DigestDaily || daily.NotificationCount != 2 ||
+		!daily.PeriodStart.Equal(tuesday.Add(5*time.Hour)) || !daily.PeriodEnd.Equal(tuesday.Add(7*time.Hour)) {
+		t.Errorf("got digest %+v", daily)
+	}
+	if daily.Status != models.NotificationSent {
+		t.Errorf("got digest %s (%s), want sent", daily.Status, daily.LastError)
+	}
+
+	messages := mailer.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	msg := messages[0]
+	if msg.To != ada || msg.Subject != "Your daily feedback digest: 2 updates" {
+		t.Errorf("got message to %v with subject %q", msg.To, msg.Subject)
+	}
+	for _, want := range []string{"Lab 1 review", "Mar 5 05:00: published to you", "Mar 5 06:00: new reply to your comment"} {
+		if !strings.Contains(msg.Text, want) || !strings.Contains(msg.HTML, want) {
+			t.Errorf("digest lacks %q:\n%s\n%s", want, msg.Text, msg.HTML)
+		}
+	}
+
+	// Activity after the boundary waits for the next period, which starts where this one ended
+	store.add(t, 2, models.NotificationFeedbackPublished, labReview, tuesday.Add(8*time.Hour))
+	runOnce(t, job, tuesday.Add(20*time.Hour), 0, 0)
+	runOnce(t, job, tuesday.AddDate(0, 0, 1).Add(7*time.Hour), 1, 1)
+	if next := store.digest(2); next.UserID != 2 || next.NotificationCount != 1 || !next.PeriodStart.Equal(daily.PeriodEnd) {
+		t.Errorf("got next digest %+v", next)
+	}
+
+	// The weekly user waits for Monday
+	sunday := time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC)
+	runOnce(t, job, sunday, 0, 0)
+	runOnce(t, job, sunday.Add(8*time.Hour), 1, 1)
+	if weekly := store.digest(3); weekly.UserID != 1 || weekly.Period != models.DigestWeekly ||
+		!weekly.PeriodEnd.Equal(time.Date(2024, 3, 11, 7, 0, 0, 0, time.UTC)) {
+		t.Errorf("got weekly digest %+v", weekly)
+	}
+	messages = mailer.Messages()
+	if len(messages) != 3 {
+		t.Fatalf("got %d messages, want 3", len(messages))
+	}
+	msg = messages[2]
+	for _, want := range []string{"Mar 5 06:00: new reply to your comment", "Mar 5 09:00: moved to acknowledged", "Mar 5 10:00: new comment on your feedback"} {
+		if !strings.Contains(msg.Text, want) || !strings.Contains(msg.HTML, want) {
+			t.Errorf("weekly digest lacks %q:\n%s\n%s", want, msg.Text, msg.HTML)
+		}
+	}
+}
+
+func TestDigestJobLeavesOutHiddenFeedback(t *testing.T) {
+	store := newFakeDigestStore(map[int64]string{2: models.NotifyDailyDigest})
+	mailer := NewMemoryMailer()
+	job := newTestDigestJob(t, store, mailer)
+
+	tuesday := time.Date(2024, 3, 5, 7, 0, 0, 0, time.UTC)
+	deleted := FeedbackInfo{ID: "f2", LabID: 7, Title: "Lab 2 review", AuthorID: 1, Status: models.StatusPublished}
+	store.add(t, 2, models.NotificationFeedbackPublished, labReview, tuesday.Add(-2*time.Hour))
+	store.add(t, 2, models.NotificationFeedbackPublished, deleted, tuesday.Add(-time.Hour))
+	store.hidden = map[string]bool{"f2": true}
+
+	runOnce(t, job, tuesday, 1, 1)
+	messages := mailer.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	if msg := messages[0]; strings.Contains(msg.Text, "Lab 2 review") || msg.Subject != "Your daily feedback digest: 1 update" {
+		t.Errorf("digest lists hidden feedback: %q\n%s", msg.Subject, msg.Text)
+	}
+
+	// A digest with nothing left to show is skipped
+	store.add(t, 2, models.NotificationFeedbackPublished, deleted, tuesday.Add(time.Hour))
+	runOnce(t, job, tuesday.AddDate(0, 0, 1), 1, 1)
+	if skipped := store.digest(2); skipped.Status != models.NotificationSkipped {
+		t.Errorf("got digest %s, want skipped", skipped.Status)
+	}
+	if len(mailer.Messages()) != 1 {
+		t.Errorf("got %d messages, want 1", len(mailer.Messages()))
+	}
+}
+
+func TestDigestJobResumesAfterRestart(t *testing.T) {
+	store := newFakeDigestStore(map[int64]string{2: models.NotifyDailyDigest})
+	tuesday := time.Date(2024, 3, 5, 7, 0, 0, 0, time.UTC)
+	store.add(t, 2, models.NotificationFeedbackPublished, labReview, tuesday.Add(-time.Hour))
+
+	// The first job fails to send and schedules a retry
+	runOnce(t, newTestDigestJob(t, store, failingMailer{}), tuesday, 1, 1)
+	failed := store.digest(1)
+	if failed.Status != models.NotificationPending || failed.LastError == "" {
+		t.Fatalf("got digest %+v, want a pending retry", failed)
+	}
+
+	// After a restart the digest of the period is neither created again nor sent before its retry
+	mailer := NewMemoryMailer()
+	restarted := newTestDigestJob(t, store, mailer)
+	runOnce(t, restarted, tuesday.Add(time.Minute), 0, 0)
+
+	store.clock = failed.NextAttemptAt
+	runOnce(t, restarted, tuesday.Add(2*time.Minute), 0, 1)
+	runOnce(t, restarted, tuesday.Add(3*time.Minute), 0, 0)
+	if len(mailer.Messages()) != 1 || store.digest(1).Status != models.NotificationSent {
+		t.Errorf("got %d messages and digest %s, want the digest sent once", len(mailer.Messages()), store.digest(1).Status)
+	}
+}
+
+func TestDigestJobRetriesDigestOfDeadJob(t *testing.T) {
+	store := newFakeDigestStore(map[int64]string{2: models.NotifyDailyDigest})
+	tuesday := time.Date(2024, 3, 5, 7, 0, 0, 0, time.UTC)
+	store.add(t, 2, models.NotificationFeedbackPublished, labReview, tuesday.Add(-time.Hour))
+
+	// A job dies after creating and claiming the digest, before the attempt was recorded
+	if _, err := store.CreateDigest(context.Background(), 2, models.DigestDaily, tuesday); err != nil {
+		t.Fatal(err)
+	}
+	if claimed, _ := store.ClaimDigests(context.Background(), 10, sendLease); len(claimed) != 1 {
+		t.Fatalf("got %d claimed digests, want 1", len(claimed))
+	}
+
+	mailer := NewMemoryMailer()
+	job := newTestDigestJob(t, store, mailer)
+	runOnce(t, job, tuesday.Add(time.Minute), 0, 0)
+
+	// Once the lease of the dead job ran out the digest is sent
+	store.clock = store.clock.Add(sendLease)
+	runOnce(t, job, tuesday.Add(sendLease), 0, 1)
+	if got := store.digest(1); got.Status != models.NotificationSent || got.Attempts != 2 || len(mailer.Messages()) != 1 {
+		t.Errorf("got digest %+v and %d messages, want it sent once on the second attempt", got, len(mailer.Messages()))
+	}
+}
+
+func TestNewDigestJobRejectsInvalidSchedule(t *testing.T) {
+	for _, opts := range []DigestOptions{{Hour: -1}, {Hour: 24}, {Hour: 7, Weekday: 7}} {
+		if _, err := NewDigestJob(newFakeDigestStore(nil), NewMemoryDirectory(), NewMemoryMailer(), nil, opts); err == nil {
+			t.Errorf("%+v: job was created", opts)
+		}
+	}
+
+	job, err := NewDigestJob(newFakeDigestStore(nil), NewMemoryDirectory(), NewMemoryMailer(), nil, DigestOptions{Hour: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.opts.Interval != 15*time.Minute || job.opts.MaxAttempts != 5 {
+		t.Errorf("got options %+v, want the defaults", job.opts)
+	}
+}
+
+func TestDigestPeriodEnd(t *testing.T) {
+	job := &DigestJob{opts: DigestOptions{Hour: 7, Weekday: time.Monday}}
+
+	tests := []struct {
+		now    time.Time
+		period string
+		want   time.Time
+	}{
+		{time.Date(2024, 3, 5, 6, 59, 0, 0, time.UTC), models.DigestDaily, time.Date(2024, 3, 4, 7, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 5, 7, 0, 0, 0, time.UTC), models.DigestDaily, time.Date(2024, 3, 5, 7, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 5, 9, 0, 0, 0, time.FixedZone("CET", 3600)), models.DigestDaily, time.Date(2024, 3, 5, 7, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 11, 6, 0, 0, 0, time.UTC), models.DigestWeekly, time.Date(2024, 3, 4, 7, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 11, 7, 0, 0, 0, time.UTC), models.DigestWeekly, time.Date(2024, 3, 11, 7, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 17, 23, 0, 0, 0, time.UTC), models.DigestWeekly, time.Date(2024, 3, 11, 7, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := job.periodEnd(tt.now, tt.period); !got.Equal(tt.want) {
+			t.Errorf("%s at %v: got %v, want %v", tt.period, tt.now, got, tt.want)
+		}
+	}
+}
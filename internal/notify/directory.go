@@ -0,0 +1,92 @@
+// Package notify emails users about feedback events.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownRecipient is returned by directories that have no address for a user
+var ErrUnknownRecipient = errors.New("unknown recipient")
+
+// Recipient is where notifications of a user are sent
+type Recipient struct {
+	UserID int64  `json:"user_id"`
+	Email  string `json:"email"`
+	Name   string `json:"name"`
+}
+
+// Directory resolves users to email addresses, typically backed by the User Service
+type Directory interface {
+	Lookup(ctx context.Context, userID int64) (*Recipient, error)
+}
+
+// MemoryDirectory holds a fixed set of recipients, for tests and small deployments
+type MemoryDirectory struct {
+	mu         sync.RWMutex
+	recipients map[int64]*Recipient
+}
+
+func NewMemoryDirectory(recipients ...*Recipient) *MemoryDirectory {
+	directory := &MemoryDirectory{recipients: make(map[int64]*Recipient)}
+	for _, recipient := range recipients {
+		directory.Add(recipient)
+	}
+	return directory
+}
+
+// LoadDirectoryFile reads a JSON array of recipients
+func LoadDirectoryFile(path string) (*MemoryDirectory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory file: %w", err)
+	}
+
+	var recipients []*Recipient
+	if err := json.Unmarshal(data, &recipients); err != nil {
+		return nil, fmt.Errorf("failed to parse directory file: %w", err)
+	}
+	return NewMemoryDirectory(recipients...), nil
+}
+
+// Add adds or replaces a recipient
+func (d *MemoryDirectory) Add(recipient *Recipient) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.recipients[recipient.UserID] = recipient
+}
+
+func (d *MemoryDirectory) Lookup(_ context.Context, userID int64) (*Recipient, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	recipient, ok := d.recipients[userID]
+	if !ok {
+		return nil, fmt.Errorf("%w: user %d", ErrUnknownRecipient, userID)
+	}
+	return recipient, nil
+}
+
+// PatternDirectory derives addresses from user ids, e.g. "{id}@students.example.edu"
+type PatternDirectory struct {
+	pattern string
+}
+
+func NewPatternDirectory(pattern string) (*PatternDirectory, error) {
+	if !strings.Contains(pattern, "{id}") || !strings.Contains(pattern, "@") {
+		return nil, fmt.Errorf("email pattern %q must contain {id} and @", pattern)
+	}
+	return &PatternDirectory{pattern: pattern}, nil
+}
+
+func (d *PatternDirectory) Lookup(_ context.Context, userID int64) (*Recipient, error) {
+	return &Recipient{
+		UserID: userID,
+		Email:  strings.ReplaceAll(d.pattern, "{id}", fmt.Sprint(userID)),
+	}, nil
+}
@@ -0,0 +1,301 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpMessage is a message accepted by the SMTP stub
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// smtpStub is a minimal in-process SMTP server. It rejects the first reject messages after
+// DATA with a transient error and accepts the rest.
+type smtpStub struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	reject   int
+	messages []smtpMessage
+}
+
+func newSMTPStub(t *testing.T, reject int) *smtpStub {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	stub := &smtpStub{listener: listener, reject: reject}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go stub.serve(conn)
+		}
+	}()
+	return stub
+}
+
+// mailer returns an SMTPMailer delivering to the stub
+func (s *smtpStub) mailer(t *testing.T) *SMTPMailer {
+	t.Helper()
+
+	addr := s.listener.Addr().(*net.TCPAddr)
+	mailer, err := NewSMTPMailer(SMTPOptions{
+		Host:     "127.0.0.1",
+		Port:     addr.Port,
+		From:     "Feedback Service <feedback@example.edu>",
+		StartTLS: true,
+		Timeout:  5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mailer
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 stub ESMTP")
+
+	var msg smtpMessage
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-stub")
+			tp.PrintfLine("250 8BITMIME")
+		case "MAIL":
+			msg = smtpMessage{from: addressOf(arg)}
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			msg.to = append(msg.to, addressOf(arg))
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.data = string(data)
+
+			s.mu.Lock()
+			rejected := s.reject > 0
+			if rejected {
+				s.reject--
+			} else {
+				s.messages = append(s.messages, msg)
+			}
+			s.mu.Unlock()
+
+			if rejected {
+				tp.PrintfLine("451 Try again later")
+			} else {
+				tp.PrintfLine("250 Queued")
+			}
+		case "RSET", "NOOP":
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// Messages returns the accepted messages
+func (s *smtpStub) Messages() []smtpMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]smtpMessage(nil), s.messages...)
+}
+
+// addressOf extracts the address of "FROM:<a@b> BODY=8BITMIME"
+func addressOf(arg string) string {
+	start, end := strings.Index(arg, "<"), strings.Index(arg, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return arg[start+1 : end]
+}
+
+var testMessage = &Message{
+	To:      &Recipient{UserID: 2, Email: "ada@example.edu", Name: "Ada Lovelace"},
+	Subject: "Neues Feedback: Übung 1",
+	Text:    "Hi Ada,\n\n" + strings.Repeat("a long line that needs soft breaks ", 5) + "\n",
+	HTML:    "<p>Hi Ada, café = 1</p>",
+}
+
+func TestSMTPMailerSendsMultipartMessage(t *testing.T) {
+	stub := newSMTPStub(t, 0)
+
+	if err := stub.mailer(t).Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := stub.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	if messages[0].from != "feedback@example.edu" || len(messages[0].to) != 1 || messages[0].to[0] != "ada@example.edu" {
+		t.Errorf("got envelope %q -> %q", messages[0].from, messages[0].to)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(messages[0].data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers := map[string]string{
+		"From":         `"Feedback Service" <feedback@example.edu>`,
+		"To":           `"Ada Lovelace" <ada@example.edu>`,
+		"Subject":      testMessage.Subject,
+		"MIME-Version": "1.0",
+	}
+	for name, want := range headers {
+		got := msg.Header.Get(name)
+		if name == "Subject" {
+			got = subject
+		}
+		if got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+	if id := msg.Header.Get("Message-ID"); !strings.HasSuffix(id, "@example.edu>") {
+		t.Errorf("got Message-ID %q, want one in the sender domain", id)
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("invalid Date header: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("got %s, want multipart/alternative", mediaType)
+	}
+
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for _, want := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", testMessage.Text},
+		{"text/html; charset=utf-8", testMessage.HTML},
+	} {
+		part, err := parts.NextRawPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := part.Header.Get("Content-Type"); got != want.contentType {
+			t.Errorf("got part %q, want %q", got, want.contentType)
+		}
+		if got := part.Header.Get("Content-Transfer-Encoding"); got != "quoted-printable" {
+			t.Errorf("%s: got encoding %q", want.contentType, got)
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != want.body {
+			t.Errorf("%s: got body %q, want %q", want.contentType, body, want.body)
+		}
+	}
+	if _, err := parts.NextRawPart(); err != io.EOF {
+		t.Errorf("got %v after the html part, want the end of the message", err)
+	}
+}
+
+func TestSMTPMailerSkipsEmptyParts(t *testing.T) {
+	stub := newSMTPStub(t, 0)
+
+	msg := *testMessage
+	msg.HTML = ""
+	if err := stub.mailer(t).Send(context.Background(), &msg); err != nil {
+		t.Fatal(err)
+	}
+
+	data := stub.Messages()[0].data
+	if strings.Contains(data, "text/html") || !strings.Contains(data, "text/plain") {
+		t.Errorf("got message without a single text part:\n%s", data)
+	}
+}
+
+func TestSMTPMailerReportsRejectedMessage(t *testing.T) {
+	stub := newSMTPStub(t, 1)
+	mailer := stub.mailer(t)
+
+	err := mailer.Send(context.Background(), testMessage)
+	var smtpErr *textproto.Error
+	if !errors.As(err, &smtpErr) || smtpErr.Code != 451 {
+		t.Fatalf("got %v, want the 451 of the server", err)
+	}
+	if len(stub.Messages()) != 0 {
+		t.Fatal("rejected message was stored")
+	}
+
+	// The next attempt goes through on a new connection
+	if err := mailer.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if len(stub.Messages()) != 1 {
+		t.Errorf("got %d messages, want 1", len(stub.Messages()))
+	}
+}
+
+func TestSMTPMailerConnectionRefused(t *testing.T) {
+	stub := newSMTPStub(t, 0)
+	mailer := stub.mailer(t)
+	stub.listener.Close()
+
+	if err := mailer.Send(context.Background(), testMessage); err == nil || !strings.Contains(err.Error(), "failed to connect") {
+		t.Errorf("got %v, want a connection error", err)
+	}
+}
+
+func TestNewSMTPMailer(t *testing.T) {
+	if _, err := NewSMTPMailer(SMTPOptions{From: "feedback@example.edu"}); err == nil {
+		t.Error("mailer without host was created")
+	}
+	if _, err := NewSMTPMailer(SMTPOptions{Host: "localhost", From: "not an address"}); err == nil {
+		t.Error("mailer with an invalid sender was created")
+	}
+
+	mailer, err := NewSMTPMailer(SMTPOptions{Host: "localhost", From: "Feedback <feedback@example.edu>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mailer.opts.Port != 25 || mailer.opts.From != "feedback@example.edu" || mailer.opts.FromName != "Feedback" {
+		t.Errorf("got options %+v", mailer.opts)
+	}
+}
@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+// eventData is the part of a domain event payload notifications are built from
+type eventData struct {
+	Feedback *struct {
+		FeedbackInfo
+		UserID int64 `json:"user_id"`
+	} `json:"feedback"`
+	Comment  *eventComment `json:"comment"`
+	Imported bool          `json:"imported"`
+}
+
+// eventComment is the comment of a comment event, replies name the author they answer
+type eventComment struct {
+	ReplyInfo
+	ParentAuthorID int64 `json:"parent_author_id"`
+}
+
+// maxExcerptLength bounds the part of a reply copied into its notification, in characters
+const maxExcerptLength = 280
+
+// NotifierStore holds preferences and queues notifications, implemented by
+// repository.FeedbackRepository
+type NotifierStore interface {
+	GetNotificationPreference(ctx context.Context, userID int64) (*models.NotificationPreference, error)
+	EnqueueNotification(ctx context.Context, notification *models.Notification) (bool, error)
+}
+
+// Notifier turns relayed domain events into notifications for the affected users, honouring
+// their preferences. It plugs into the outbox relay, the Sender emails immediate notifications.
+type Notifier struct {
+	repo NotifierStore
+}
+
+func NewNotifier(repo NotifierStore) *Notifier {
+	return &Notifier{
+		repo: repo,
+	}
+}
+
+// Publish queues the notifications of an event, publishing it again queues nothing new
+func (n *Notifier) Publish(ctx context.Context, event *models.DomainEvent) error {
+	switch event.Type {
+	case models.EventFeedbackCreated, models.EventFeedbackStatusChanged, models.EventCommentCreated:
+	default:
+		return nil
+	}
+
+	var data eventData
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+	}
+
+	// Imported feedback was handed out before, migrating it must not mail every student again
+	feedback := data.Feedback
+	if feedback == nil || data.Imported {
+		return nil
+	}
+
+	// Students hear about feedback once it is published to them, authors about
+	// everything else other users do with their feedback, such as acknowledging it,
+	// and commenters about replies to their comments
+	switch {
+	case event.Type == models.EventCommentCreated:
+		return n.notifyComment(ctx, event, feedback.FeedbackInfo, data.Comment)
+	case feedback.Status == models.StatusPublished:
+		if feedback.UserID == event.ActorID {
+			return nil
+		}
+		return n.notify(ctx, event, feedback.UserID, models.NotificationFeedbackPublished, payload{FeedbackInfo: feedback.FeedbackInfo})
+	case event.Type == models.EventFeedbackStatusChanged:
+		if feedback.AuthorID == 0 || feedback.AuthorID == event.ActorID {
+			return nil
+		}
+		return n.notify(ctx, event, feedback.AuthorID, models.NotificationFeedbackStatusChanged, payload{FeedbackInfo: feedback.FeedbackInfo})
+	}
+	return nil
+}
+
+// notifyComment tells the author of the comment replied to and the author of the feedback about
+// a new comment. An author whose own comment was answered only gets the reply notification.
+func (n *Notifier) notifyComment(ctx context.Context, event *models.DomainEvent, feedback FeedbackInfo, comment *eventComment) error {
+	if comment == nil {
+		return nil
+	}
+	reply := comment.ReplyInfo
+	reply.Content = excerpt(reply.Content)
+	data := payload{FeedbackInfo: feedback, Reply: &reply}
+
+	if comment.ParentAuthorID != 0 && comment.ParentAuthorID != event.ActorID {
+		if err := n.notify(ctx, event, comment.ParentAuthorID, models.NotificationCommentReply, data); err != nil {
+			return err
+		}
+	}
+	if feedback.AuthorID == 0 || feedback.AuthorID == event.ActorID || feedback.AuthorID == comment.ParentAuthorID {
+		return nil
+	}
+	return n.notify(ctx, event, feedback.AuthorID, models.NotificationFeedbackComment, data)
+}
+
+func (n *Notifier) notify(ctx context.Context, event *models.DomainEvent, userID int64, kind string, data payload) error {
+	pref, err := n.repo.GetNotificationPreference(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get notification preference: %w", err)
+	}
+
+	status := models.NotificationPending
+	switch pref.Mode {
+	case models.NotifyOff:
+		return nil
+	case models.NotifyDailyDigest, models.NotifyWeeklyDigest:
+		status = models.NotificationDigest
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	_, err = n.repo.EnqueueNotification(ctx, &models.Notification{
+		EventID:    event.ID,
+		UserID:     userID,
+		Kind:       kind,
+		FeedbackID: event.FeedbackID,
+		Payload:    encoded,
+		Status:     status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to queue notification: %w", err)
+	}
+	return nil
+}
+
+// excerpt shortens text to maxExcerptLength characters
+func excerpt(text string) string {
+	runes := []rune(text)
+	if len(runes) <= maxExcerptLength {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:maxExcerptLength])) + "…"
+}
@@ -0,0 +1,175 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+// fakeNotifierStore keeps preferences and queued notifications in memory
+type fakeNotifierStore struct {
+	mu            sync.Mutex
+	modes         map[int64]string
+	notifications []*models.Notification
+}
+
+func (s *fakeNotifierStore) GetNotificationPreference(_ context.Context, userID int64) (*models.NotificationPreference, error) {
+	mode, ok := s.modes[userID]
+	if !ok {
+		mode = models.NotifyImmediate
+	}
+	return &models.NotificationPreference{UserID: userID, Mode: mode}, nil
+}
+
+func (s *fakeNotifierStore) EnqueueNotification(_ context.Context, notification *models.Notification) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifications = append(s.notifications, notification)
+	return true, nil
+}
+
+func TestNotifierNotifiesAuthors(t *testing.T) {
+	const feedback = `"feedback": {"id": "f1", "user_id": 2, "author_id": 1, "status": "acknowledged"}`
+	tests := []struct {
+		name      string
+		eventType string
+		actorID   int64
+		data      string
+		want      map[int64]string // Kind queued per user
+	}{
+		{
+			name:      "acknowledged by the recipient",
+			eventType: models.EventFeedbackStatusChanged,
+			actorID:   2,
+			data:      `{` + feedback + `}`,
+			want:      map[int64]string{1: models.NotificationFeedbackStatusChanged},
+		},
+		{
+			name:      "top-level comment",
+			eventType: models.EventCommentCreated,
+			actorID:   2,
+			data:      `{` + feedback + `, "comment": {"id": "c1", "author_id": 2, "content": "Thanks"}}`,
+			want:      map[int64]string{1: models.NotificationFeedbackComment},
+		},
+		{
+			name:      "reply to the author",
+			eventType: models.EventCommentCreated,
+			actorID:   2,
+			data:      `{` + feedback + `, "comment": {"id": "c2", "author_id": 2, "parent_author_id": 1}}`,
+			want:      map[int64]string{1: models.NotificationCommentReply},
+		},
+		{
+			name:      "reply to another commenter",
+			eventType: models.EventCommentCreated,
+			actorID:   3,
+			data:      `{` + feedback + `, "comment": {"id": "c3", "author_id": 3, "parent_author_id": 2}}`,
+			want:      map[int64]string{1: models.NotificationFeedbackComment, 2: models.NotificationCommentReply},
+		},
+	}
+
+	for _, tt := range tests {
+		store := &fakeNotifierStore{}
+		event := &models.DomainEvent{ID: "e1", Type: tt.eventType, FeedbackID: "f1", ActorID: tt.actorID, Data: json.RawMessage(tt.data)}
+		if err := NewNotifier(store).Publish(context.Background(), event); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		got := make(map[int64]string)
+		for _, notification := range store.notifications {
+			got[notification.UserID] = notification.Kind
+			if notification.Status != models.NotificationPending {
+				t.Errorf("%s: got status %s, want pending", tt.name, notification.Status)
+			}
+		}
+		if len(got) != len(tt.want) || len(store.notifications) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for userID, kind := range tt.want {
+			if got[userID] != kind {
+				t.Errorf("%s: user %d got %q, want %q", tt.name, userID, got[userID], kind)
+			}
+		}
+	}
+}
+
+func TestNotifierHonoursDigestPreference(t *testing.T) {
+	store := &fakeNotifierStore{modes: map[int64]string{1: models.NotifyDailyDigest}}
+	event := &models.DomainEvent{
+		ID:         "e1",
+		Type:       models.EventFeedbackStatusChanged,
+		FeedbackID: "f1",
+		ActorID:    2,
+		Data:       []byte(`{"feedback": {"id": "f1", "user_id": 2, "author_id": 1, "status": "acknowledged"}}`),
+	}
+	if err := NewNotifier(store).Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.notifications) != 1 || store.notifications[0].Status != models.NotificationDigest {
+		t.Errorf("got %+v, want one notification waiting for the digest", store.notifications)
+	}
+}
+
+func TestNotifierIgnoresCommentsWithoutOtherRecipient(t *testing.T) {
+	// Without a repository any attempt to queue a notification would panic
+	notifier := NewNotifier(nil)
+
+	tests := map[string]string{
+		"top-level comment": `{"feedback": {"id": "f1", "user_id": 2, "author_id": 2}, "comment": {"id": "c1", "author_id": 2}}`,
+		"reply to self":     `{"feedback": {"id": "f1", "user_id": 2, "author_id": 2}, "comment": {"id": "c2", "author_id": 2, "parent_author_id": 2}}`,
+		"no comment":        `{"feedback": {"id": "f1", "user_id": 2, "author_id": 1}}`,
+	}
+	for name, data := range tests {
+		event := &models.DomainEvent{Type: models.EventCommentCreated, FeedbackID: "f1", ActorID: 2, Data: []byte(data)}
+		if err := notifier.Publish(context.Background(), event); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestNotifierIgnoresOwnStatusChanges(t *testing.T) {
+	notifier := NewNotifier(nil)
+
+	for _, status := range []string{models.StatusResolved, models.StatusArchived} {
+		event := &models.DomainEvent{
+			Type:       models.EventFeedbackStatusChanged,
+			FeedbackID: "f1",
+			ActorID:    1,
+			Data:       []byte(`{"feedback": {"id": "f1", "user_id": 2, "author_id": 1, "status": "` + status + `"}}`),
+		}
+		if err := notifier.Publish(context.Background(), event); err != nil {
+			t.Errorf("%s: %v", status, err)
+		}
+	}
+}
+
+func TestNotifierIgnoresImportedFeedback(t *testing.T) {
+	notifier := NewNotifier(nil)
+
+	event := &models.DomainEvent{
+		Type:       models.EventFeedbackCreated,
+		FeedbackID: "f1",
+		ActorID:    1,
+		Data:       []byte(`{"feedback": {"id": "f1", "user_id": 2, "author_id": 1, "status": "published"}, "imported": true}`),
+	}
+	if err := notifier.Publish(context.Background(), event); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	if got := excerpt("Why?"); got != "Why?" {
+		t.Errorf("got %q, want the short text unchanged", got)
+	}
+
+	long := strings.Repeat("ü", maxExcerptLength+10)
+	got := excerpt(long)
+	if utf8.RuneCountInString(got) != maxExcerptLength+1 || !strings.HasSuffix(got, "…") {
+		t.Errorf("got %d characters, want %d and an ellipsis", utf8.RuneCountInString(got), maxExcerptLength+1)
+	}
+}
@@ -0,0 +1,190 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+// Retry delays of failed notifications grow exponentially between these bounds
+const (
+	minRetryDelay = time.Minute
+	maxRetryDelay = time.Hour
+)
+
+// sendLease hides a claimed notification from other senders while it is being sent
+const sendLease = 5 * time.Minute
+
+// errFeedbackHidden is returned for notifications whose feedback the recipient can no longer see
+var errFeedbackHidden = errors.New("feedback was deleted or is no longer visible to the recipient")
+
+// SenderStore holds queued notifications, implemented by repository.FeedbackRepository
+type SenderStore interface {
+	ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]*models.Notification, error)
+	RecordNotificationAttempt(ctx context.Context, notification *models.Notification) error
+	GetByID(ctx context.Context, id string) (*models.FeedbackFile, error)
+}
+
+// SenderOptions configures the notification sender
+type SenderOptions struct {
+	MaxAttempts  int           // A notification fails for good after this many attempts
+	BatchSize    int           // Notifications claimed per round
+	PollInterval time.Duration // Pause after a round that found nothing to send
+	FeedbackURL  string        // Link to a feedback, "{id}" is replaced by its id; empty sends no link
+}
+
+// Sender emails queued immediate notifications
+type Sender struct {
+	repo      SenderStore
+	directory Directory
+	mailer    Mailer
+	renderer  *Renderer
+	opts      SenderOptions
+}
+
+func NewSender(repo SenderStore, directory Directory, mailer Mailer, renderer *Renderer, opts SenderOptions) *Sender {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 20
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 5 * time.Second
+	}
+
+	return &Sender{
+		repo:      repo,
+		directory: directory,
+		mailer:    mailer,
+		renderer:  renderer,
+		opts:      opts,
+	}
+}
+
+// Run sends due notifications until ctx is cancelled
+func (s *Sender) Run(ctx context.Context) {
+	for {
+		sent, err := s.SendOnce(ctx)
+		if err != nil {
+			log.Printf("Failed to send notifications: %v", err)
+		}
+
+		// Keep draining while there is work, otherwise wait for new notifications
+		if sent > 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.opts.PollInterval):
+		}
+	}
+}
+
+// SendOnce sends one batch of due notifications and returns how many were attempted
+func (s *Sender) SendOnce(ctx context.Context) (int, error) {
+	notifications, err := s.repo.ClaimNotifications(ctx, s.opts.BatchSize, sendLease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim notifications: %w", err)
+	}
+
+	for _, notification := range notifications {
+		err := s.send(ctx, notification)
+
+		switch {
+		case err == nil:
+			notification.Status = models.NotificationSent
+			notification.LastError = ""
+		case errors.Is(err, errFeedbackHidden):
+			notification.Status = models.NotificationSkipped
+			notification.LastError = err.Error()
+		case errors.Is(err, ErrUnknownRecipient) || notification.Attempts >= s.opts.MaxAttempts:
+			// Retrying cannot help users without an address
+			notification.Status = models.NotificationFailed
+			notification.LastError = err.Error()
+		default:
+			notification.NextAttemptAt = time.Now().Add(retryDelay(notification.Attempts))
+			notification.LastError = err.Error()
+		}
+
+		if err := s.repo.RecordNotificationAttempt(ctx, notification); err != nil {
+			log.Printf("Failed to record notification %d: %v", notification.ID, err)
+		}
+	}
+
+	return len(notifications), nil
+}
+
+func (s *Sender) send(ctx context.Context, notification *models.Notification) error {
+	// The notification was queued when its event happened, the feedback may have been
+	// deleted or moved out of sight of the recipient since
+	if err := s.checkVisible(ctx, notification); err != nil {
+		return err
+	}
+
+	recipient, err := s.directory.Lookup(ctx, notification.UserID)
+	if err != nil {
+		return err
+	}
+
+	var data payload
+	if err := json.Unmarshal(notification.Payload, &data); err != nil {
+		return fmt.Errorf("failed to decode notification: %w", err)
+	}
+
+	msg, err := s.renderer.Render(notification.Kind, recipient, &TemplateData{
+		Recipient: recipient,
+		Feedback:  data.FeedbackInfo,
+		Reply:     data.Reply,
+		URL:       s.feedbackURL(data.ID),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, msg)
+}
+
+// checkVisible returns errFeedbackHidden when the feedback of a notification is in the trash or
+// gone, or is a draft of someone other than the recipient
+func (s *Sender) checkVisible(ctx context.Context, notification *models.Notification) error {
+	feedback, err := s.repo.GetByID(ctx, notification.FeedbackID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errFeedbackHidden
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get feedback: %w", err)
+	}
+
+	if feedback.Status == models.StatusDraft && feedback.AuthorID != notification.UserID {
+		return errFeedbackHidden
+	}
+	return nil
+}
+
+func (s *Sender) feedbackURL(id string) string {
+	if s.opts.FeedbackURL == "" {
+		return ""
+	}
+	return strings.ReplaceAll(s.opts.FeedbackURL, "{id}", id)
+}
+
+// retryDelay doubles the delay with every failed attempt
+func retryDelay(attempts int) time.Duration {
+	delay := minRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,242 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+// fakeSenderStore hands out its pending notifications once and records the attempts
+type fakeSenderStore struct {
+	mu        sync.Mutex
+	pending   []*models.Notification
+	recorded  []models.Notification
+	feedbacks map[string]*models.FeedbackFile
+}
+
+func (s *fakeSenderStore) ClaimNotifications(_ context.Context, limit int, _ time.Duration) ([]*models.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	claimed := s.pending
+	if len(claimed) > limit {
+		claimed = claimed[:limit]
+	}
+	s.pending = s.pending[len(claimed):]
+	for _, notification := range claimed {
+		notification.Attempts++
+	}
+	return claimed, nil
+}
+
+func (s *fakeSenderStore) RecordNotificationAttempt(_ context.Context, notification *models.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recorded = append(s.recorded, *notification)
+	return nil
+}
+
+func (s *fakeSenderStore) GetByID(_ context.Context, id string) (*models.FeedbackFile, error) {
+	feedback, ok := s.feedbacks[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return feedback, nil
+}
+
+// failingMailer rejects every message
+type failingMailer struct{}
+
+func (failingMailer) Send(context.Context, *Message) error {
+	return errors.New("451 try again later")
+}
+
+var (
+	ada   = &Recipient{UserID: 2, Email: "ada@example.edu", Name: "Ada"}
+	grace = &Recipient{UserID: 1, Email: "grace@example.edu", Name: "Grace"}
+)
+
+func newTestSender(t *testing.T, store SenderStore, mailer Mailer) *Sender {
+	t.Helper()
+
+	renderer, err := NewRenderer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSender(store, NewMemoryDirectory(ada, grace), mailer, renderer, SenderOptions{
+		MaxAttempts: 3,
+		FeedbackURL: "https://lms.example.edu/feedback/{id}",
+	})
+}
+
+func newNotification(t *testing.T, userID int64, kind string, data payload) *models.Notification {
+	t.Helper()
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &models.Notification{
+		ID:         1,
+		UserID:     userID,
+		Kind:       kind,
+		FeedbackID: data.ID,
+		Payload:    encoded,
+		Status:     models.NotificationPending,
+	}
+}
+
+var labReview = FeedbackInfo{ID: "f1", LabID: 7, Title: "Lab 1 review", AuthorID: 1, Status: models.StatusPublished}
+
+func TestSenderSendsNotification(t *testing.T) {
+	store := &fakeSenderStore{
+		pending:   []*models.Notification{newNotification(t, 2, models.NotificationFeedbackPublished, payload{FeedbackInfo: labReview})},
+		feedbacks: map[string]*models.FeedbackFile{"f1": {ID: "f1", AuthorID: 1, UserID: 2, Status: models.StatusPublished}},
+	}
+	mailer := NewMemoryMailer()
+
+	sent, err := newTestSender(t, store, mailer).SendOnce(context.Background())
+	if err != nil || sent != 1 {
+		t.Fatalf("got %d, %v, want 1 notification attempted", sent, err)
+	}
+
+	messages := mailer.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	msg := messages[0]
+	if msg.To != ada || msg.Subject != "New feedback: Lab 1 review" {
+		t.Errorf("got message to %v with subject %q", msg.To, msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "https://lms.example.edu/feedback/f1") || !strings.Contains(msg.HTML, `href="https://lms.example.edu/feedback/f1"`) {
+		t.Errorf("message lacks the feedback link:\n%s\n%s", msg.Text, msg.HTML)
+	}
+
+	if got := store.recorded[0]; got.Status != models.NotificationSent || got.LastError != "" {
+		t.Errorf("got %s (%s), want sent", got.Status, got.LastError)
+	}
+}
+
+func TestSenderSendsCommentReply(t *testing.T) {
+	reply := &ReplyInfo{CommentID: "c2", AuthorID: 2, Content: "Why is <b>this</b> wrong?"}
+	store := &fakeSenderStore{
+		pending:   []*models.Notification{newNotification(t, 1, models.NotificationCommentReply, payload{FeedbackInfo: labReview, Reply: reply})},
+		feedbacks: map[string]*models.FeedbackFile{"f1": {ID: "f1", AuthorID: 1, UserID: 2, Status: models.StatusPublished}},
+	}
+	mailer := NewMemoryMailer()
+
+	if _, err := newTestSender(t, store, mailer).SendOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := mailer.Messages()[0]
+	if msg.To != grace || msg.Subject != "New reply on Lab 1 review" {
+		t.Errorf("got message to %v with subject %q", msg.To, msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "> Why is <b>this</b> wrong?") {
+		t.Errorf("text lacks the reply:\n%s", msg.Text)
+	}
+	if !strings.Contains(msg.HTML, "Why is &lt;b&gt;this&lt;/b&gt; wrong?") {
+		t.Errorf("html lacks the escaped reply:\n%s", msg.HTML)
+	}
+}
+
+func TestSenderSkipsHiddenFeedback(t *testing.T) {
+	tests := map[string]struct {
+		feedback *models.FeedbackFile
+		userID   int64
+		want     string
+	}{
+		"deleted":               {nil, 2, models.NotificationSkipped},
+		"draft of someone else": {&models.FeedbackFile{ID: "f1", AuthorID: 1, UserID: 2, Status: models.StatusDraft}, 2, models.NotificationSkipped},
+		"own draft":             {&models.FeedbackFile{ID: "f1", AuthorID: 1, UserID: 2, Status: models.StatusDraft}, 1, models.NotificationSent},
+		"archived":              {&models.FeedbackFile{ID: "f1", AuthorID: 1, UserID: 2, Status: models.StatusArchived}, 2, models.NotificationSent},
+	}
+	for name, tt := range tests {
+		store := &fakeSenderStore{
+			pending:   []*models.Notification{newNotification(t, tt.userID, models.NotificationCommentReply, payload{FeedbackInfo: labReview})},
+			feedbacks: map[string]*models.FeedbackFile{},
+		}
+		if tt.feedback != nil {
+			store.feedbacks["f1"] = tt.feedback
+		}
+		mailer := NewMemoryMailer()
+
+		if _, err := newTestSender(t, store, mailer).SendOnce(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := store.recorded[0].Status; got != tt.want {
+			t.Errorf("%s: got %s (%s), want %s", name, got, store.recorded[0].LastError, tt.want)
+		}
+		if sent := len(mailer.Messages()) == 1; sent != (tt.want == models.NotificationSent) {
+			t.Errorf("%s: got %d messages", name, len(mailer.Messages()))
+		}
+	}
+}
+
+func TestSenderRetriesFailedSends(t *testing.T) {
+	notification := newNotification(t, 2, models.NotificationFeedbackPublished, payload{FeedbackInfo: labReview})
+	store := &fakeSenderStore{
+		feedbacks: map[string]*models.FeedbackFile{"f1": {ID: "f1", AuthorID: 1, UserID: 2, Status: models.StatusPublished}},
+	}
+	sender := newTestSender(t, store, failingMailer{})
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		store.pending = []*models.Notification{notification}
+		before := time.Now()
+		if _, err := sender.SendOnce(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		got := store.recorded[attempt-1]
+		if got.LastError != "451 try again later" {
+			t.Errorf("attempt %d: got error %q", attempt, got.LastError)
+		}
+		if attempt < 3 {
+			delay := retryDelay(attempt)
+			if got.Status != models.NotificationPending || got.NextAttemptAt.Before(before.Add(delay)) {
+				t.Errorf("attempt %d: got %s at %v, want a retry after %v", attempt, got.Status, got.NextAttemptAt, delay)
+			}
+		} else if got.Status != models.NotificationFailed {
+			t.Errorf("last attempt: got %s, want failed", got.Status)
+		}
+	}
+}
+
+func TestSenderFailsUnknownRecipient(t *testing.T) {
+	store := &fakeSenderStore{
+		pending:   []*models.Notification{newNotification(t, 99, models.NotificationFeedbackPublished, payload{FeedbackInfo: labReview})},
+		feedbacks: map[string]*models.FeedbackFile{"f1": {ID: "f1", AuthorID: 1, UserID: 99, Status: models.StatusPublished}},
+	}
+
+	if _, err := newTestSender(t, store, NewMemoryMailer()).SendOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := store.recorded[0]; got.Status != models.NotificationFailed || !strings.Contains(got.LastError, "unknown recipient") {
+		t.Errorf("got %s (%s), want failed on the first attempt", got.Status, got.LastError)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := map[int]time.Duration{
+		1:  time.Minute,
+		2:  2 * time.Minute,
+		3:  4 * time.Minute,
+		7:  time.Hour,
+		20: time.Hour,
+	}
+	for attempts, want := range tests {
+		if got := retryDelay(attempts); got != want {
+			t.Errorf("%d attempts: got %v, want %v", attempts, got, want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Every kind has <kind>.txt defining "subject" and "text", and <kind>.html defining "html"
+//
+//go:embed templates/*.txt templates/*.html
+var templateFiles embed.FS
+
+// FeedbackInfo is the feedback a notification is about
+type FeedbackInfo struct {
+	ID       string `json:"id"`
+	LabID    int64  `json:"lab_id"`
+	Title    string `json:"title"`
+	AuthorID int64  `json:"author_id,omitempty"`
+	Status   string `json:"status"`
+}
+
+// ReplyInfo is the comment a comment_reply or feedback_comment notification is about
+type ReplyInfo struct {
+	CommentID string `json:"id"`
+	AuthorID  int64  `json:"author_id"`
+	Content   string `json:"content"` // Shortened to an excerpt
+}
+
+// payload is the template data a notification captures when its event happens. FeedbackInfo
+// is embedded so its fields stay at the top level of the stored JSON.
+type payload struct {
+	FeedbackInfo
+	Reply *ReplyInfo `json:"reply,omitempty"`
+}
+
+// TemplateData is passed to the templates of feedback notifications
+type TemplateData struct {
+	Recipient *Recipient
+	Feedback  FeedbackInfo
+	Reply     *ReplyInfo // Set for comment_reply and feedback_comment notifications
+	URL       string     // Link to the feedback, empty when no feedback URL is configured
+}
+
+type kindTemplates struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Renderer turns notifications into email messages
+type Renderer struct {
+	kinds map[string]*kindTemplates
+}
+
+func NewRenderer() (*Renderer, error) {
+	files, err := fs.Glob(templateFiles, "templates/*.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	renderer := &Renderer{kinds: make(map[string]*kindTemplates)}
+	for _, file := range files {
+		kind := strings.TrimSuffix(path.Base(file), ".txt")
+
+		text, err := texttemplate.ParseFS(templateFiles, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		html, err := htmltemplate.ParseFS(templateFiles, "templates/"+kind+".html")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse html template of %s: %w", kind, err)
+		}
+
+		renderer.kinds[kind] = &kindTemplates{text: text, html: html}
+	}
+
+	return renderer, nil
+}
+
+// Render executes the templates of kind for a recipient
+func (r *Renderer) Render(kind string, to *Recipient, data interface{}) (*Message, error) {
+	templates, ok := r.kinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("no template for notification kind %q", kind)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := templates.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render subject: %w", err)
+	}
+	if err := templates.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return nil, fmt.Errorf("failed to render text body: %w", err)
+	}
+	if err := templates.html.ExecuteTemplate(&html, "html", data); err != nil {
+		return nil, fmt.Errorf("failed to render html body: %w", err)
+	}
+
+	return &Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
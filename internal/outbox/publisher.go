@@ -0,0 +1,94 @@
+// Package outbox publishes the domain events stored in the transactional outbox.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+// Publisher delivers domain events to other services. A returned error makes the relay retry
+// the event later, so Publish must tolerate receiving the same event more than once.
+type Publisher interface {
+	Publish(ctx context.Context, event *models.DomainEvent) error
+}
+
+// MemoryPublisher keeps published events in memory, for tests and local development
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []*models.DomainEvent
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(_ context.Context, event *models.DomainEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events returns the events published so far in publication order
+func (p *MemoryPublisher) Events() []*models.DomainEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*models.DomainEvent(nil), p.events...)
+}
+
+// FilePublisher appends every event as a JSON line to a file
+type FilePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFilePublisher opens path for appending, creating it if needed
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+
+	return &FilePublisher{
+		file: file,
+	}, nil
+}
+
+func (p *FilePublisher) Publish(_ context.Context, event *models.DomainEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Events are only marked published once they reached the disk
+	if _, err := p.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return p.file.Sync()
+}
+
+// Close closes the underlying file
+func (p *FilePublisher) Close() error {
+	return p.file.Close()
+}
+
+// MultiPublisher hands every event to several publishers in order. When one of them fails
+// the event is retried for all of them, which at-least-once publishers tolerate.
+type MultiPublisher []Publisher
+
+func (p MultiPublisher) Publish(ctx context.Context, event *models.DomainEvent) error {
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
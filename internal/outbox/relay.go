@@ -0,0 +1,106 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+// Retry delays of failed events grow exponentially between these bounds
+const (
+	minRetryDelay = time.Second
+	maxRetryDelay = 10 * time.Minute
+)
+
+// claimLease is how long claimed events are hidden from other relays while they are published
+const claimLease = 5 * time.Minute
+
+// Store holds the outbox, implemented by repository.FeedbackRepository
+type Store interface {
+	ProcessOutbox(ctx context.Context, limit int, lease time.Duration, publish func(*models.DomainEvent) error, retryAfter func(attempts int) time.Duration) (published, failed int, err error)
+	DeletePublishedOutboxEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+// RelayOptions configures the outbox relay
+type RelayOptions struct {
+	BatchSize    int           // Events claimed per round
+	PollInterval time.Duration // Pause after a round that found nothing to publish
+	Retention    time.Duration // Published events are kept this long, 0 keeps them forever
+}
+
+// Relay moves events from the outbox table to a Publisher
+type Relay struct {
+	repo      Store
+	publisher Publisher
+	opts      RelayOptions
+}
+
+func NewRelay(repo Store, publisher Publisher, opts RelayOptions) *Relay {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+
+	return &Relay{
+		repo:      repo,
+		publisher: publisher,
+		opts:      opts,
+	}
+}
+
+// Run publishes pending events until ctx is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	lastCleanup := time.Now()
+
+	for {
+		published, err := r.RelayOnce(ctx)
+		if err != nil {
+			log.Printf("Failed to relay outbox events: %v", err)
+		}
+
+		if r.opts.Retention > 0 && time.Since(lastCleanup) > time.Hour {
+			lastCleanup = time.Now()
+			if _, err := r.repo.DeletePublishedOutboxEvents(ctx, time.Now().Add(-r.opts.Retention)); err != nil {
+				log.Printf("Failed to delete published outbox events: %v", err)
+			}
+		}
+
+		// Keep draining while there is work, otherwise wait for new events
+		if published > 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.opts.PollInterval):
+		}
+	}
+}
+
+// RelayOnce publishes one batch of due events and returns how many were published
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	published, failed, err := r.repo.ProcessOutbox(ctx, r.opts.BatchSize, claimLease, func(event *models.DomainEvent) error {
+		return r.publisher.Publish(ctx, event)
+	}, retryDelay)
+	if failed > 0 {
+		log.Printf("Failed to publish %d outbox events, they will be retried", failed)
+	}
+
+	return published, err
+}
+
+// retryDelay doubles the delay with every failed attempt
+func retryDelay(attempts int) time.Duration {
+	delay := minRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,153 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+// fakeStore hands its pending events to the relay and remembers the outcome
+type fakeStore struct {
+	pending   []*models.DomainEvent
+	published []string
+	failed    map[string]int
+	lease     time.Duration
+}
+
+func (s *fakeStore) ProcessOutbox(ctx context.Context, limit int, lease time.Duration, publish func(*models.DomainEvent) error, retryAfter func(attempts int) time.Duration) (int, int, error) {
+	s.lease = lease
+	batch := s.pending
+	if len(batch) > limit {
+		batch = batch[:limit]
+	}
+
+	var published, failed int
+	var retry []*models.DomainEvent
+	for _, event := range batch {
+		if err := publish(event); err != nil {
+			if s.failed == nil {
+				s.failed = make(map[string]int)
+			}
+			s.failed[event.ID]++
+			event.Attempts++
+			retry = append(retry, event)
+			failed++
+			continue
+		}
+		s.published = append(s.published, event.ID)
+		published++
+	}
+	s.pending = append(retry, s.pending[len(batch):]...)
+	return published, failed, nil
+}
+
+func (s *fakeStore) DeletePublishedOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// failingPublisher rejects the events listed in reject
+type failingPublisher struct {
+	reject map[string]bool
+}
+
+func (p *failingPublisher) Publish(_ context.Context, event *models.DomainEvent) error {
+	if p.reject[event.ID] {
+		return errors.New("broker unavailable")
+	}
+	return nil
+}
+
+func events(ids ...string) []*models.DomainEvent {
+	list := make([]*models.DomainEvent, len(ids))
+	for i, id := range ids {
+		list[i] = &models.DomainEvent{ID: id, Type: models.EventFeedbackCreated, FeedbackID: "f" + id}
+	}
+	return list
+}
+
+func eventIDs(list []*models.DomainEvent) []string {
+	ids := make([]string, len(list))
+	for i, event := range list {
+		ids[i] = event.ID
+	}
+	return ids
+}
+
+func TestRelayOnceDrainsInBatches(t *testing.T) {
+	store := &fakeStore{pending: events("1", "2", "3")}
+	memory := NewMemoryPublisher()
+	relay := NewRelay(store, memory, RelayOptions{BatchSize: 2})
+
+	published, err := relay.RelayOnce(context.Background())
+	if err != nil || published != 2 {
+		t.Fatalf("got %d, %v, want 2 published", published, err)
+	}
+	if store.lease != claimLease {
+		t.Errorf("got lease %v, want %v", store.lease, claimLease)
+	}
+
+	if published, err = relay.RelayOnce(context.Background()); err != nil || published != 1 {
+		t.Fatalf("got %d, %v, want 1 published", published, err)
+	}
+	if got := eventIDs(memory.Events()); !reflect.DeepEqual(got, []string{"1", "2", "3"}) {
+		t.Errorf("memory publisher got %v", got)
+	}
+}
+
+func TestRelayOnceRetriesFailedEvents(t *testing.T) {
+	store := &fakeStore{pending: events("1", "2")}
+	memory := NewMemoryPublisher()
+	reject := &failingPublisher{reject: map[string]bool{"2": true}}
+	relay := NewRelay(store, MultiPublisher{memory, reject}, RelayOptions{})
+
+	published, err := relay.RelayOnce(context.Background())
+	if err != nil || published != 1 {
+		t.Fatalf("got %d, %v, want 1 published", published, err)
+	}
+	if store.failed["2"] != 1 || len(store.pending) != 1 {
+		t.Errorf("event 2 was not kept for a retry: %+v", store)
+	}
+
+	// The memory publisher saw the failed event too, at-least-once publishers tolerate that
+	delete(reject.reject, "2")
+	if published, _ = relay.RelayOnce(context.Background()); published != 1 {
+		t.Errorf("got %d published on retry, want 1", published)
+	}
+	if got := eventIDs(memory.Events()); !reflect.DeepEqual(got, []string{"1", "2", "2"}) {
+		t.Errorf("memory publisher got %v", got)
+	}
+	if !reflect.DeepEqual(store.published, []string{"1", "2"}) {
+		t.Errorf("store marked %v published", store.published)
+	}
+}
+
+func TestMultiPublisherStopsAtFirstFailure(t *testing.T) {
+	memory := NewMemoryPublisher()
+	publisher := MultiPublisher{&failingPublisher{reject: map[string]bool{"1": true}}, memory}
+
+	if err := publisher.Publish(context.Background(), events("1")[0]); err == nil {
+		t.Fatal("expected the failure to be returned")
+	}
+	if len(memory.Events()) != 0 {
+		t.Error("later publishers received an event that failed earlier")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := map[int]time.Duration{
+		0:  minRetryDelay,
+		1:  time.Second,
+		2:  2 * time.Second,
+		5:  16 * time.Second,
+		30: maxRetryDelay,
+	}
+	for attempts, want := range tests {
+		if got := retryDelay(attempts); got != want {
+			t.Errorf("retryDelay(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	query := QueryFingerprint("feedbacks", int64(7))
+	token := Encode(query, "2024-01-02T03:04:05.123456789Z", "3f0c8a52-4a7e-4d43-9a7b-1f3f6f0a2b11")
+
+	position, err := Decode(token, query, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"2024-01-02T03:04:05.123456789Z", "3f0c8a52-4a7e-4d43-9a7b-1f3f6f0a2b11"}; !reflect.DeepEqual(position, want) {
+		t.Errorf("got %v, want %v", position, want)
+	}
+
+	if position, err := Decode("", query, 2); position != nil || err != nil {
+		t.Errorf("empty token: got %v, %v", position, err)
+	}
+}
+
+func TestDecodeRejectsInvalidTokens(t *testing.T) {
+	query := QueryFingerprint("feedbacks", int64(7))
+
+	tests := map[string]string{
+		"not base64":      "%%%",
+		"not json":        base64.RawURLEncoding.EncodeToString([]byte("{")),
+		"other query":     Encode(QueryFingerprint("feedbacks", int64(8)), "a", "b"),
+		"wrong key parts": Encode(query, "a"),
+	}
+
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Decode(token, query, 2); !errors.Is(err, ErrInvalidPageToken) {
+				t.Errorf("got %v, want %v", err, ErrInvalidPageToken)
+			}
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.FixedZone("", 3600))
+
+	parsed, err := ParseTime(FormatTime(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Equal(now) {
+		t.Errorf("got %v, want %v", parsed, now)
+	}
+
+	if _, err := ParseTime("yesterday"); !errors.Is(err, ErrInvalidPageToken) {
+		t.Errorf("got %v, want %v", err, ErrInvalidPageToken)
+	}
+}
+
+func TestParseID(t *testing.T) {
+	id, err := ParseID("3F0C8A52-4A7E-4D43-9A7B-1F3F6F0A2B11")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "3f0c8a52-4a7e-4d43-9a7b-1f3f6f0a2b11" {
+		t.Errorf("got %q", id)
+	}
+
+	for _, value := range []string{"", "42", "'; DROP TABLE feedbacks; --"} {
+		if _, err := ParseID(value); !errors.Is(err, ErrInvalidPageToken) {
+			t.Errorf("%q: got %v, want %v", value, err, ErrInvalidPageToken)
+		}
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	for requested, want := range map[int]int{-1: DefaultPageSize, 0: DefaultPageSize, 5: 5, 1000: MaxPageSize} {
+		if got := PageSize(requested); got != want {
+			t.Errorf("PageSize(%d) = %d, want %d", requested, got, want)
+		}
+	}
+}
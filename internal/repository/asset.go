@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// UpsertAsset records asset metadata, replacing any existing entry with the same filename
+func (r *FeedbackRepository) UpsertAsset(ctx context.Context, feedbackID string, asset *models.AssetInfo) error {
+	query := `
+		INSERT INTO feedback_assets (id, feedback_id, filename, content_type, size, scan_status, content_hash, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		ON CONFLICT (feedback_id, filename)
+		DO UPDATE SET content_type = EXCLUDED.content_type, size = EXCLUDED.size,
+			scan_status = EXCLUDED.scan_status, scan_signature = NULL,
+			content_hash = EXCLUDED.content_hash, thumbnail_sizes = '{}', created_at = NOW()
+		RETURNING created_at`
+
+	return r.db.QueryRowContext(ctx, query,
+		uuid.New().String(),
+		feedbackID,
+		asset.Filename,
+		asset.ContentType,
+		asset.Size,
+		asset.ScanStatus,
+		asset.ContentHash,
+	).Scan(&asset.UploadedAt)
+}
+
+// LockAssetQuota serializes asset writes to feedback of a user until the transaction ends,
+// so that concurrent uploads cannot all pass the quota check
+func (r *FeedbackRepository) LockAssetQuota(ctx context.Context, userID int64) error {
+	_, err := r.db.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('asset_quota'), hashtext($1::text))", userID)
+	return err
+}
+
+// DeleteAssetsByFeedbackID removes all asset metadata of a feedback
+func (r *FeedbackRepository) DeleteAssetsByFeedbackID(ctx context.Context, feedbackID string) error {
+	query := `DELETE FROM feedback_assets WHERE feedback_id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, feedbackID)
+	return err
+}
+
+// GetFeedbackAssetUsage returns the number and total size of assets of a feedback,
+// ignoring excludeFilename so that an asset being replaced is not counted twice
+func (r *FeedbackRepository) GetFeedbackAssetUsage(ctx context.Context, feedbackID, excludeFilename string) (int64, int64, error) {
+	var count, total int64
+
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(size), 0)
+		FROM feedback_assets
+		WHERE feedback_id = $1 AND filename <> $2`
+
+	err := r.db.QueryRowContext(ctx, query, feedbackID, excludeFilename).Scan(&count, &total)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return count, total, nil
+}
+
+// GetUserAssetUsage returns the storage consumed by assets of all feedback owned by a user
+func (r *FeedbackRepository) GetUserAssetUsage(ctx context.Context, userID int64) (*models.StorageUsage, error) {
+	usage := &models.StorageUsage{UserID: userID}
+
+	query := `
+		SELECT COUNT(a.id), COALESCE(SUM(a.size), 0)
+		FROM feedback_assets a
+		JOIN feedback_files f ON f.id = a.feedback_id
+		WHERE f.user_id = $1`
+
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&usage.AssetCount, &usage.TotalBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+// GetAsset returns the recorded metadata of a single asset
+func (r *FeedbackRepository) GetAsset(ctx context.Context, feedbackID, filename string) (*models.AssetInfo, error) {
+	asset := &models.AssetInfo{}
+
+	query := `
+		SELECT filename, content_type, size, created_at, thumbnail_sizes, scan_status, content_hash
+		FROM feedback_assets
+		WHERE feedback_id = $1 AND filename = $2`
+
+	var thumbnailSizes pq.Int64Array
+	err := r.db.QueryRowContext(ctx, query, feedbackID, filename).Scan(
+		&asset.Filename,
+		&asset.ContentType,
+		&asset.Size,
+		&asset.UploadedAt,
+		&thumbnailSizes,
+		&asset.ScanStatus,
+		&asset.ContentHash,
+	)
+	if err != nil {
+		return nil, err
+	}
+	asset.ThumbnailSizes = toInts(thumbnailSizes)
+
+	return asset, nil
+}
+
+// ListAssets returns the recorded metadata of all assets of a feedback
+func (r *FeedbackRepository) ListAssets(ctx context.Context, feedbackID string) ([]*models.AssetInfo, error) {
+	var assets []*models.AssetInfo
+
+	query := `
+		SELECT filename, content_type, size, created_at, thumbnail_sizes, scan_status, content_hash
+		FROM feedback_assets
+		WHERE feedback_id = $1
+		ORDER BY filename`
+
+	rows, err := r.db.QueryContext(ctx, query, feedbackID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		asset := &models.AssetInfo{}
+		var thumbnailSizes pq.Int64Array
+		err := rows.Scan(
+			&asset.Filename,
+			&asset.ContentType,
+			&asset.Size,
+			&asset.UploadedAt,
+			&thumbnailSizes,
+			&asset.ScanStatus,
+			&asset.ContentHash,
+		)
+		if err != nil {
+			return nil, err
+		}
+		asset.ThumbnailSizes = toInts(thumbnailSizes)
+		assets = append(assets, asset)
+	}
+
+	return assets, rows.Err()
+}
+
+// SetAssetThumbnails records the thumbnail sizes generated for an asset, unless the asset
+// was replaced by different content in the meantime
+func (r *FeedbackRepository) SetAssetThumbnails(ctx context.Context, feedbackID, filename, contentHash string, sizes []int) error {
+	query := `
+		UPDATE feedback_assets
+		SET thumbnail_sizes = $3
+		WHERE feedback_id = $1 AND filename = $2 AND content_hash = $4`
+
+	values := make(pq.Int64Array, len(sizes))
+	for i, size := range sizes {
+		values[i] = int64(size)
+	}
+
+	_, err := r.db.ExecContext(ctx, query, feedbackID, filename, values, contentHash)
+	return err
+}
+
+func toInts(values pq.Int64Array) []int {
+	ints := make([]int, len(values))
+	for i, value := range values {
+		ints[i] = int(value)
+	}
+	return ints
+}
+
+// SetAssetScanStatus records the outcome of a malware scan
+func (r *FeedbackRepository) SetAssetScanStatus(ctx context.Context, feedbackID, filename, status, signature string) error {
+	query := `
+		UPDATE feedback_assets
+		SET scan_status = $3, scan_signature = NULLIF($4, '')
+		WHERE feedback_id = $1 AND filename = $2`
+
+	_, err := r.db.ExecContext(ctx, query, feedbackID, filename, status, signature)
+	return err
+}
+
+// AssetRef identifies the stored content of an asset
+type AssetRef struct {
+	FeedbackID  string
+	Filename    string
+	ContentHash string
+}
+
+// ListUnscannedAssets returns assets that are still waiting for a scan result or whose scan
+// failed, oldest first
+func (r *FeedbackRepository) ListUnscannedAssets(ctx context.Context, limit int) ([]AssetRef, error) {
+	query := `
+		SELECT feedback_id, filename, content_hash
+		FROM feedback_assets
+		WHERE scan_status IN ('pending', 'error')
+		ORDER BY created_at, id
+		LIMIT $1`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []AssetRef
+	for rows.Next() {
+		var ref AssetRef
+		if err := rows.Scan(&ref.FeedbackID, &ref.Filename, &ref.ContentHash); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+
+	return refs, rows.Err()
+}
+
+// RecordAssetRescan stores the result of scanning the stored content of an unscanned asset
+// together with the hash of that content. It reports false when the asset was replaced or
+// scanned by an upload since it was listed.
+func (r *FeedbackRepository) RecordAssetRescan(ctx context.Context, ref AssetRef, status, signature, contentHash string) (bool, error) {
+	query := `
+		UPDATE feedback_assets
+		SET scan_status = $4, scan_signature = NULLIF($5, ''), content_hash = $6
+		WHERE feedback_id = $1 AND filename = $2 AND content_hash = $3
+			AND scan_status IN ('pending', 'error')`
+
+	result, err := r.db.ExecContext(ctx, query, ref.FeedbackID, ref.Filename, ref.ContentHash, status, signature, contentHash)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := result.RowsAffected()
+	return count > 0, err
+}
+
+// RequeueAssetScan marks an asset as unscanned when its stored content may not match its
+// metadata, unless it was replaced by different content in the meantime
+func (r *FeedbackRepository) RequeueAssetScan(ctx context.Context, feedbackID, filename, contentHash string) error {
+	query := `
+		UPDATE feedback_assets
+		SET scan_status = 'error', scan_signature = NULL
+		WHERE feedback_id = $1 AND filename = $2 AND content_hash = $3`
+
+	_, err := r.db.ExecContext(ctx, query, feedbackID, filename, contentHash)
+	return err
+}
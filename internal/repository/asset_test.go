@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestListUnscannedAssets(t *testing.T) {
+	repo, fake := newFakeRepository(t, func(query string, args []driver.Value) fakeResult {
+		return fakeResult{
+			columns: []string{"feedback_id", "filename", "content_hash"},
+			rows:    [][]driver.Value{{"f1", "a.png", "h1"}, {"f2", "b.txt", "h2"}},
+		}
+	})
+
+	refs, err := repo.ListUnscannedAssets(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []AssetRef{{"f1", "a.png", "h1"}, {"f2", "b.txt", "h2"}}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("got %+v, want %+v", refs, want)
+	}
+	if query := fake.queries()[0]; !strings.Contains(query, "WHERE scan_status IN ('pending', 'error') ORDER BY created_at, id LIMIT $1") {
+		t.Errorf("unscanned assets are not listed oldest first: %s", query)
+	}
+}
+
+func TestRecordAssetRescanOnlyUpdatesListedContent(t *testing.T) {
+	matched := true
+	repo, fake := newFakeRepository(t, func(query string, args []driver.Value) fakeResult {
+		if matched {
+			return fakeResult{rows: [][]driver.Value{{}}}
+		}
+		return fakeResult{}
+	})
+
+	ref := AssetRef{FeedbackID: "f1", Filename: "a.png", ContentHash: "old"}
+	recorded, err := repo.RecordAssetRescan(context.Background(), ref, "clean", "", "new")
+	if err != nil || !recorded {
+		t.Fatalf("got %v, %v, want the result recorded", recorded, err)
+	}
+
+	if want := []driver.Value{"f1", "a.png", "old", "clean", "", "new"}; !reflect.DeepEqual(fake.statements[0].args, want) {
+		t.Errorf("got args %v, want %v", fake.statements[0].args, want)
+	}
+	query := fake.queries()[0]
+	if !strings.Contains(query, "content_hash = $3 AND scan_status IN ('pending', 'error')") {
+		t.Errorf("a rescan may overwrite a newer upload: %s", query)
+	}
+
+	// An upload replaced or scanned the asset since it was listed
+	matched = false
+	recorded, err = repo.RecordAssetRescan(context.Background(), ref, "clean", "", "new")
+	if err != nil || recorded {
+		t.Errorf("got %v, %v, want nothing recorded", recorded, err)
+	}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// BatchItemKey identifies an item of a batch, batches are scoped by the caller who runs them
+type BatchItemKey struct {
+	CallerID int64
+	BatchID  string
+	ItemKey  string
+}
+
+// BatchReservation is the outcome of reserving a batch item
+type BatchReservation struct {
+	Reserved    bool   // The caller may create the item now
+	FeedbackID  string // Created by an earlier attempt, empty while that attempt is still running
+	Fingerprint string // Payload the item was first requested with, empty for items recorded before it was kept
+}
+
+// ReserveBatchItem claims a batch item for creation for the length of lease. An item that was
+// never completed can be claimed again once its lease has passed, e.g. after a crash.
+func (r *FeedbackRepository) ReserveBatchItem(ctx context.Context, key BatchItemKey, fingerprint string, lease time.Duration) (*BatchReservation, error) {
+	var inserted int
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO feedback_batch_items (caller_id, batch_id, item_key, fingerprint, reserved_until, created_at)
+		VALUES ($1, $2, $3, $4, NOW() + $5 * INTERVAL '1 millisecond', NOW())
+		ON CONFLICT (caller_id, batch_id, item_key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint, reserved_until = EXCLUDED.reserved_until, created_at = NOW()
+		WHERE feedback_batch_items.feedback_id IS NULL
+			AND feedback_batch_items.fingerprint IN ('', EXCLUDED.fingerprint)
+			AND feedback_batch_items.reserved_until < NOW()
+		RETURNING 1`,
+		key.CallerID, key.BatchID, key.ItemKey, fingerprint, lease.Milliseconds()).Scan(&inserted)
+	if err == nil {
+		return &BatchReservation{Reserved: true, Fingerprint: fingerprint}, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	var feedbackID sql.NullString
+	reservation := &BatchReservation{}
+	err = r.db.QueryRowContext(ctx, `
+		SELECT feedback_id, fingerprint FROM feedback_batch_items
+		WHERE caller_id = $1 AND batch_id = $2 AND item_key = $3`,
+		key.CallerID, key.BatchID, key.ItemKey).Scan(&feedbackID, &reservation.Fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	reservation.FeedbackID = feedbackID.String
+	return reservation, nil
+}
+
+// CompleteBatchItem records the feedback created for a reserved batch item
+func (r *FeedbackRepository) CompleteBatchItem(ctx context.Context, key BatchItemKey, feedbackID string) error {
+	query := `
+		UPDATE feedback_batch_items SET feedback_id = $4, reserved_until = NULL
+		WHERE caller_id = $1 AND batch_id = $2 AND item_key = $3`
+
+	_, err := r.db.ExecContext(ctx, query, key.CallerID, key.BatchID, key.ItemKey, feedbackID)
+	return err
+}
+
+// ReleaseBatchItem removes a reservation so the item can be retried
+func (r *FeedbackRepository) ReleaseBatchItem(ctx context.Context, key BatchItemKey) error {
+	query := `DELETE FROM feedback_batch_items WHERE caller_id = $1 AND batch_id = $2 AND item_key = $3`
+
+	_, err := r.db.ExecContext(ctx, query, key.CallerID, key.BatchID, key.ItemKey)
+	return err
+}
+
+// DeleteBatchItemsByFeedbackID forgets batch items that created a feedback
+func (r *FeedbackRepository) DeleteBatchItemsByFeedbackID(ctx context.Context, feedbackID string) error {
+	query := `DELETE FROM feedback_batch_items WHERE feedback_id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, feedbackID)
+	return err
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReserveBatchItem(t *testing.T) {
+	key := BatchItemKey{CallerID: 7, BatchID: "b1", ItemKey: "42"}
+
+	repo, fake := newFakeRepository(t, func(query string, args []driver.Value) fakeResult {
+		switch {
+		case strings.Contains(query, "INSERT") && args[2] == "42":
+			return fakeResult{columns: []string{"?column?"}, rows: [][]driver.Value{{int64(1)}}}
+		case strings.Contains(query, "INSERT"):
+			return fakeResult{columns: []string{"?column?"}}
+		default:
+			return fakeResult{columns: []string{"feedback_id", "fingerprint"}, rows: [][]driver.Value{{"f1", "abc"}}}
+		}
+	})
+
+	reservation, err := repo.ReserveBatchItem(context.Background(), key, "abc", 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reservation.Reserved {
+		t.Errorf("got %+v, want a reservation", reservation)
+	}
+
+	insert := fake.statements[0]
+	if want := []driver.Value{int64(7), "b1", "42", "abc", int64(300000)}; !reflect.DeepEqual(insert.args, want) {
+		t.Errorf("got args %v, want %v", insert.args, want)
+	}
+	query := fake.queries()[0]
+	for _, want := range []string{
+		"ON CONFLICT (caller_id, batch_id, item_key)",
+		"feedback_batch_items.feedback_id IS NULL",
+		"feedback_batch_items.reserved_until < NOW()",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("reservation query lacks %q", want)
+		}
+	}
+
+	// A taken item reports what it was reserved for
+	key.ItemKey = "43"
+	reservation, err = repo.ReserveBatchItem(context.Background(), key, "abc", 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&BatchReservation{FeedbackID: "f1", Fingerprint: "abc"}); !reflect.DeepEqual(reservation, want) {
+		t.Errorf("got %+v, want %+v", reservation, want)
+	}
+	if lookup := fake.queries()[2]; !strings.Contains(lookup, "WHERE caller_id = $1 AND batch_id = $2 AND item_key = $3") {
+		t.Errorf("lookup is not scoped by caller: %s", lookup)
+	}
+}
+
+func TestBatchItemWritesAreScopedByCaller(t *testing.T) {
+	repo, fake := newFakeRepository(t, nil)
+	key := BatchItemKey{CallerID: 7, BatchID: "b1", ItemKey: "42"}
+
+	if err := repo.CompleteBatchItem(context.Background(), key, "f1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ReleaseBatchItem(context.Background(), key); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, statement := range fake.statements {
+		if !reflect.DeepEqual(statement.args[:3], []driver.Value{int64(7), "b1", "42"}) {
+			t.Errorf("statement %d: got args %v", i, statement.args)
+		}
+		if !strings.Contains(fake.queries()[i], "caller_id = $1") {
+			t.Errorf("statement %d is not scoped by caller", i)
+		}
+	}
+	if !strings.Contains(fake.queries()[0], "reserved_until = NULL") {
+		t.Error("completing an item keeps its reservation")
+	}
+}
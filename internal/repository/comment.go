@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/google/uuid"
+)
+
+const commentColumns = `id, feedback_id, COALESCE(parent_id::text, ''), author_id, content, created_at`
+
+// CreateComment stores a comment, replies keep the id of the comment they answer
+func (r *FeedbackRepository) CreateComment(ctx context.Context, comment *models.FeedbackComment) error {
+	comment.ID = uuid.New().String()
+
+	query := `
+		INSERT INTO feedback_comments (id, feedback_id, parent_id, author_id, content, created_at)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5, NOW())
+		RETURNING created_at`
+
+	return r.db.QueryRowContext(ctx, query,
+		comment.ID,
+		comment.FeedbackID,
+		comment.ParentID,
+		comment.AuthorID,
+		comment.Content,
+	).Scan(&comment.CreatedAt)
+}
+
+func (r *FeedbackRepository) GetComment(ctx context.Context, id string) (*models.FeedbackComment, error) {
+	query := `
+		SELECT ` + commentColumns + `
+		FROM feedback_comments
+		WHERE id = $1`
+
+	return scanComment(r.db.QueryRowContext(ctx, query, id))
+}
+
+// ListComments returns the comments of a feedback oldest first, replies included
+func (r *FeedbackRepository) ListComments(ctx context.Context, feedbackID string) ([]*models.FeedbackComment, error) {
+	query := `
+		SELECT ` + commentColumns + `
+		FROM feedback_comments
+		WHERE feedback_id = $1
+		ORDER BY created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, feedbackID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []*models.FeedbackComment
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// DeleteCommentsByFeedbackID removes all comments of a feedback
+func (r *FeedbackRepository) DeleteCommentsByFeedbackID(ctx context.Context, feedbackID string) error {
+	query := `DELETE FROM feedback_comments WHERE feedback_id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, feedbackID)
+	return err
+}
+
+func scanComment(row rowScanner) (*models.FeedbackComment, error) {
+	comment := &models.FeedbackComment{}
+
+	err := row.Scan(
+		&comment.ID,
+		&comment.FeedbackID,
+		&comment.ParentID,
+		&comment.AuthorID,
+		&comment.Content,
+		&comment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
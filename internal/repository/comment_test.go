@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+func TestCreateCommentKeepsParent(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo, fake := newFakeRepository(t, func(query string, args []driver.Value) fakeResult {
+		return fakeResult{columns: []string{"created_at"}, rows: [][]driver.Value{{created}}}
+	})
+
+	for _, parentID := range []string{"", "7d3c8a52-5a8e-4c55-9a0c-2f3f4b6f1e01"} {
+		comment := &models.FeedbackComment{FeedbackID: "f1", ParentID: parentID, AuthorID: 3, Content: "Why?"}
+		if err := repo.CreateComment(context.Background(), comment); err != nil {
+			t.Fatal(err)
+		}
+		if comment.ID == "" || !comment.CreatedAt.Equal(created) {
+			t.Errorf("got %+v, want an id and the stored created_at", comment)
+		}
+	}
+
+	for i, parentID := range []string{"", "7d3c8a52-5a8e-4c55-9a0c-2f3f4b6f1e01"} {
+		args := fake.statements[i].args
+		if want := []driver.Value{"f1", parentID, int64(3), "Why?"}; !reflect.DeepEqual(args[1:], want) {
+			t.Errorf("got args %v, want %v", args[1:], want)
+		}
+	}
+	if query := fake.queries()[0]; !strings.Contains(query, "NULLIF($3, '')::uuid") {
+		t.Errorf("top-level comments are not stored without a parent: %s", query)
+	}
+}
+
+func TestListCommentsOldestFirst(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo, fake := newFakeRepository(t, func(query string, args []driver.Value) fakeResult {
+		return fakeResult{
+			columns: []string{"id", "feedback_id", "parent_id", "author_id", "content", "created_at"},
+			rows: [][]driver.Value{
+				{"c1", "f1", "", int64(2), "Why?", created},
+				{"c2", "f1", "c1", int64(1), "See line 3.", created.Add(time.Minute)},
+			},
+		}
+	})
+
+	comments, err := repo.ListComments(context.Background(), "f1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*models.FeedbackComment{
+		{ID: "c1", FeedbackID: "f1", AuthorID: 2, Content: "Why?", CreatedAt: created},
+		{ID: "c2", FeedbackID: "f1", ParentID: "c1", AuthorID: 1, Content: "See line 3.", CreatedAt: created.Add(time.Minute)},
+	}
+	if !reflect.DeepEqual(comments, want) {
+		t.Errorf("got %+v, want %+v", comments, want)
+	}
+	if query := fake.queries()[0]; !strings.HasSuffix(query, "WHERE feedback_id = $1 ORDER BY created_at, id") {
+		t.Errorf("comments are not listed oldest first: %s", query)
+	}
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+// errEmptyDigest rolls back digests that would not contain any notification
+var errEmptyDigest = errors.New("empty digest")
+
+// ListDigestDueUsers returns up to limit users on the given digest period that have notifications
+// from before periodEnd waiting for a digest, and no digest ending at or after periodEnd yet.
+// Users who switched away from digests get their remaining notifications with the daily digests.
+func (r *FeedbackRepository) ListDigestDueUsers(ctx context.Context, period string, periodEnd time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT DISTINCT n.user_id
+		FROM notifications n
+		LEFT JOIN notification_preferences p ON p.user_id = n.user_id
+		WHERE n.status = 'digest' AND n.digest_id IS NULL AND n.created_at < $1
+		AND (COALESCE(p.mode, '') = 'weekly_digest') = $2
+		AND NOT EXISTS (
+			SELECT 1 FROM notification_digests d
+			WHERE d.user_id = n.user_id AND d.period_end >= $1
+		)
+		ORDER BY n.user_id
+		LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, periodEnd, period == models.DigestWeekly, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// CreateDigest bundles the waiting notifications of a user created before periodEnd into a new
+// digest, which starts where the previous digest of the user ended. It returns nil when another
+// job created the digest first or nothing was waiting.
+func (r *FeedbackRepository) CreateDigest(ctx context.Context, userID int64, period string, periodEnd time.Time) (*models.Digest, error) {
+	digest := &models.Digest{
+		UserID:    userID,
+		Period:    period,
+		PeriodEnd: periodEnd,
+		Status:    models.NotificationPending,
+	}
+
+	err := r.InTx(ctx, func(tx *FeedbackRepository) error {
+		err := tx.db.QueryRowContext(ctx, `
+			INSERT INTO notification_digests (user_id, period, period_start, period_end, notification_count)
+			SELECT $1, $2, COALESCE(
+				(SELECT MAX(period_end) FROM notification_digests WHERE user_id = $1),
+				(SELECT MIN(created_at) FROM notifications WHERE user_id = $1 AND status = 'digest' AND digest_id IS NULL),
+				$3), $3, 0
+			ON CONFLICT (user_id, period_end) DO NOTHING
+			RETURNING id, period_start, next_attempt_at, created_at`,
+			userID, period, periodEnd,
+		).Scan(&digest.ID, &digest.PeriodStart, &digest.NextAttemptAt, &digest.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.db.ExecContext(ctx, `
+			UPDATE notifications
+			SET digest_id = $1
+			WHERE user_id = $2 AND status = 'digest' AND digest_id IS NULL AND created_at < $3`,
+			digest.ID, userID, periodEnd)
+		if err != nil {
+			return err
+		}
+
+		count, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return errEmptyDigest
+		}
+		digest.NotificationCount = int(count)
+
+		_, err = tx.db.ExecContext(ctx,
+			"UPDATE notification_digests SET notification_count = $2 WHERE id = $1", digest.ID, count)
+		return err
+	})
+	if errors.Is(err, errEmptyDigest) || errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return digest, nil
+}
+
+// ClaimDigests counts an attempt for up to limit due pending digests and hides them from other
+// jobs for the lease, after which an unrecorded attempt is retried
+func (r *FeedbackRepository) ClaimDigests(ctx context.Context, limit int, lease time.Duration) ([]*models.Digest, error) {
+	query := `
+		WITH due AS (
+			SELECT id
+			FROM notification_digests
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE notification_digests d
+		SET attempts = d.attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+		FROM due
+		WHERE d.id = due.id
+		RETURNING d.id, d.user_id, d.period, d.period_start, d.period_end, d.notification_count, d.status,
+			d.attempts, COALESCE(d.last_error, ''), d.next_attempt_at, d.created_at, d.sent_at`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var digests []*models.Digest
+	for rows.Next() {
+		digest, err := scanDigest(rows)
+		if err != nil {
+			return nil, err
+		}
+		digests = append(digests, digest)
+	}
+
+	return digests, rows.Err()
+}
+
+// ListDigestNotifications returns the notifications of a digest in the order they happened. Like
+// immediate notifications, those of feedback in the trash or gone, or of drafts the recipient did
+// not write, are left out.
+func (r *FeedbackRepository) ListDigestNotifications(ctx context.Context, digestID int64) ([]*models.Notification, error) {
+	query := `
+		SELECT ` + notificationColumns + `
+		FROM notifications
+		WHERE digest_id = $1
+		AND EXISTS (
+			SELECT 1 FROM feedback_files f
+			WHERE f.id = notifications.feedback_id AND f.deleted_at IS NULL
+			AND (f.status <> 'draft' OR f.author_id = notifications.user_id)
+		)
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, digestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []*models.Notification
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+// RecordDigestAttempt stores the status, error and next attempt of a digest. Once the digest
+// is sent or given up, its notifications take the same status.
+func (r *FeedbackRepository) RecordDigestAttempt(ctx context.Context, digest *models.Digest) error {
+	return r.InTx(ctx, func(tx *FeedbackRepository) error {
+		_, err := tx.db.ExecContext(ctx, `
+			UPDATE notification_digests
+			SET status = $2, last_error = NULLIF($3, ''), next_attempt_at = $4,
+				sent_at = CASE WHEN $2 = 'sent' THEN NOW() ELSE sent_at END
+			WHERE id = $1`,
+			digest.ID, digest.Status, digest.LastError, digest.NextAttemptAt)
+		if err != nil || digest.Status == models.NotificationPending {
+			return err
+		}
+
+		_, err = tx.db.ExecContext(ctx, `
+			UPDATE notifications
+			SET status = $2, sent_at = CASE WHEN $2 = 'sent' THEN NOW() ELSE sent_at END
+			WHERE digest_id = $1`,
+			digest.ID, digest.Status)
+		return err
+	})
+}
+
+func scanDigest(row rowScanner) (*models.Digest, error) {
+	digest := &models.Digest{}
+
+	err := row.Scan(
+		&digest.ID,
+		&digest.UserID,
+		&digest.Period,
+		&digest.PeriodStart,
+		&digest.PeriodEnd,
+		&digest.NotificationCount,
+		&digest.Status,
+		&digest.Attempts,
+		&digest.LastError,
+		&digest.NextAttemptAt,
+		&digest.CreatedAt,
+		&digest.SentAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return digest, nil
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+func TestListDigestNotificationsHidesInvisibleFeedback(t *testing.T) {
+	repo, fake := newFakeRepository(t, func(query string, args []driver.Value) fakeResult {
+		return fakeResult{columns: []string{"id"}}
+	})
+
+	if _, err := repo.ListDigestNotifications(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	query := fake.queries()[0]
+	for _, want := range []string{"f.deleted_at IS NULL", "f.status <> 'draft' OR f.author_id = notifications.user_id"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query lacks %q: %s", want, query)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// uniqueViolation is the PostgreSQL error code of a violated unique constraint
+const uniqueViolation = "23505"
+
+// IsUniqueViolation reports whether err was caused by a violated unique constraint
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
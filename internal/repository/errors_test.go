@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestIsUniqueViolation(t *testing.T) {
+	violation := &pq.Error{Code: "23505"}
+
+	if !IsUniqueViolation(violation) || !IsUniqueViolation(fmt.Errorf("insert: %w", violation)) {
+		t.Error("unique violation not detected")
+	}
+	for _, err := range []error{nil, errors.New("23505"), &pq.Error{Code: "23503"}} {
+		if IsUniqueViolation(err) {
+			t.Errorf("%v detected as unique violation", err)
+		}
+	}
+}
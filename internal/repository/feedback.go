@@ -3,50 +3,167 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Ravwvil/feedback/internal/models"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type FeedbackRepository struct {
-	db *sql.DB
+	db   querier // The connection pool, or the transaction of a repository passed to InTx
+	conn *sql.DB
 }
 
+// feedbackColumns is the column list read by scanFeedback
+const feedbackColumns = `id, user_id, lab_id, title, content_hash, created_at, updated_at,
+	status, COALESCE(author_id, 0), published_at, acknowledged_at, resolved_at, archived_at,
+	deleted_at, COALESCE(deleted_by, 0)`
+
+// FeedbackFilter selects the feedback returned by list queries, zero fields do not filter
+type FeedbackFilter struct {
+	UserIDs  []int64
+	LabIDs   []int64
+	Statuses []string
+	Tags     []string
+	TagMatch string // TagMatchAny or TagMatchAll
+
+	CreatedAfter  time.Time // Inclusive
+	CreatedBefore time.Time // Exclusive
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+
+	TitleContains string // Case-insensitive substring
+	HasAssets     *bool
+
+	ParticipantID int64 // Only feedback the user received or wrote
+	ViewerID      int64 // Drafts are only listed for their author
+}
+
+// FeedbackSort orders list queries, the id breaks ties
+type FeedbackSort struct {
+	Field     string // SortByCreatedAt, SortByUpdatedAt or SortByTitle
+	Ascending bool
+}
+
+// Sortable feedback fields
+const (
+	SortByCreatedAt = "created_at"
+	SortByUpdatedAt = "updated_at"
+	SortByTitle     = "title"
+)
+
+// sortColumns whitelists the columns a list may be ordered by
+var sortColumns = map[string]string{
+	SortByCreatedAt: "created_at",
+	SortByUpdatedAt: "updated_at",
+	SortByTitle:     "title",
+}
+
+// ValidSortField reports whether feedback can be ordered by field
+func ValidSortField(field string) bool {
+	_, ok := sortColumns[field]
+	return ok
+}
+
+// Tag filter modes
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
 func NewFeedbackRepository(db *sql.DB) *FeedbackRepository {
 	return &FeedbackRepository{
-		db: db,
+		db:   db,
+		conn: db,
 	}
 }
 
+// Create inserts a new feedback, an id is generated unless the caller already chose one
 func (r *FeedbackRepository) Create(ctx context.Context, feedback *models.FeedbackFile) error {
-	feedback.ID = uuid.New().String()
+	if feedback.ID == "" {
+		feedback.ID = uuid.New().String()
+	}
+	
+	if feedback.Status == "" {
+		feedback.Status = models.StatusDraft
+	}
 	
 	query := `
-		INSERT INTO feedback_files (id, user_id, lab_id, title, content_hash, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
-		RETURNING created_at, updated_at`
+		INSERT INTO feedback_files (id, user_id, lab_id, title, content_hash, status, author_id, published_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, 0), CASE WHEN $6 = 'published' THEN NOW() END, NOW(), NOW())
+		RETURNING created_at, updated_at, published_at`
 	
+	var publishedAt sql.NullTime
 	err := r.db.QueryRowContext(ctx, query,
 		feedback.ID,
 		feedback.UserID,
 		feedback.LabID,
 		feedback.Title,
 		feedback.ContentHash,
-	).Scan(&feedback.CreatedAt, &feedback.UpdatedAt)
+		feedback.Status,
+		feedback.AuthorID,
+	).Scan(&feedback.CreatedAt, &feedback.UpdatedAt, &publishedAt)
+	feedback.PublishedAt = timePtr(publishedAt)
 	
 	return err
 }
 
-func (r *FeedbackRepository) GetByID(ctx context.Context, id string) (*models.FeedbackFile, error) {
-	feedback := &models.FeedbackFile{}
-	
+// CreateImported inserts a feedback keeping its original timestamps, zero timestamps default to now
+func (r *FeedbackRepository) CreateImported(ctx context.Context, feedback *models.FeedbackFile) error {
+	feedback.ID = uuid.New().String()
+	if feedback.Status == "" {
+		feedback.Status = models.StatusPublished
+	}
+
+	// Imported feedback that is past the draft stage counts as published at creation
 	query := `
-		SELECT id, user_id, lab_id, title, content_hash, created_at, updated_at
-		FROM feedback_files
-		WHERE id = $1`
-	
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		INSERT INTO feedback_files (id, user_id, lab_id, title, content_hash, status, author_id, published_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, 0),
+			CASE WHEN $6 <> 'draft' THEN COALESCE($8, NOW()) END, COALESCE($8, NOW()), COALESCE($9, $8, NOW()))
+		RETURNING created_at, updated_at, published_at`
+
+	var publishedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query,
+		feedback.ID,
+		feedback.UserID,
+		feedback.LabID,
+		feedback.Title,
+		feedback.ContentHash,
+		feedback.Status,
+		feedback.AuthorID,
+		nullTime(feedback.CreatedAt),
+		nullTime(feedback.UpdatedAt),
+	).Scan(&feedback.CreatedAt, &feedback.UpdatedAt, &publishedAt)
+	feedback.PublishedAt = timePtr(publishedAt)
+
+	return err
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanFeedback reads a row selected with feedbackColumns
+func scanFeedback(row rowScanner) (*models.FeedbackFile, error) {
+	feedback := &models.FeedbackFile{}
+	var publishedAt, acknowledgedAt, resolvedAt, archivedAt, deletedAt sql.NullTime
+
+	err := row.Scan(
 		&feedback.ID,
 		&feedback.UserID,
 		&feedback.LabID,
@@ -54,20 +171,67 @@ func (r *FeedbackRepository) GetByID(ctx context.Context, id string) (*models.Fe
 		&feedback.ContentHash,
 		&feedback.CreatedAt,
 		&feedback.UpdatedAt,
+		&feedback.Status,
+		&feedback.AuthorID,
+		&publishedAt,
+		&acknowledgedAt,
+		&resolvedAt,
+		&archivedAt,
+		&deletedAt,
+		&feedback.DeletedBy,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	feedback.PublishedAt = timePtr(publishedAt)
+	feedback.AcknowledgedAt = timePtr(acknowledgedAt)
+	feedback.ResolvedAt = timePtr(resolvedAt)
+	feedback.ArchivedAt = timePtr(archivedAt)
+	feedback.DeletedAt = timePtr(deletedAt)
+
+	return feedback, nil
+}
+
+func (r *FeedbackRepository) GetByID(ctx context.Context, id string) (*models.FeedbackFile, error) {
+	query := `
+		SELECT ` + feedbackColumns + `
+		FROM feedback_files
+		WHERE id = $1 AND deleted_at IS NULL`
 	
+	return scanFeedback(r.db.QueryRowContext(ctx, query, id))
+}
+
+// GetByIDs loads the feedbacks with the given ids in one query, missing or trashed ids are skipped
+func (r *FeedbackRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.FeedbackFile, error) {
+	query := `
+		SELECT ` + feedbackColumns + `
+		FROM feedback_files
+		WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL`
+	
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	
-	return feedback, nil
+	var feedbacks []*models.FeedbackFile
+	for rows.Next() {
+		feedback, err := scanFeedback(rows)
+		if err != nil {
+			return nil, err
+		}
+		feedbacks = append(feedbacks, feedback)
+	}
+	
+	return feedbacks, rows.Err()
 }
 
 func (r *FeedbackRepository) Update(ctx context.Context, feedback *models.FeedbackFile) error {
 	query := `
 		UPDATE feedback_files
 		SET title = $2, content_hash = $3, updated_at = NOW()
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 		RETURNING updated_at`
 	
 	err := r.db.QueryRowContext(ctx, query,
@@ -79,79 +243,185 @@ func (r *FeedbackRepository) Update(ctx context.Context, feedback *models.Feedba
 	return err
 }
 
-func (r *FeedbackRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM feedback_files WHERE id = $1`
+// Delete removes trashed feedback permanently and returns the deleted row
+func (r *FeedbackRepository) Delete(ctx context.Context, id string) (*models.FeedbackFile, error) {
+	query := `DELETE FROM feedback_files WHERE id = $1 AND deleted_at IS NOT NULL RETURNING ` + feedbackColumns
 	
-	result, err := r.db.ExecContext(ctx, query, id)
-	if err != nil {
-		return err
+	feedback, err := scanFeedback(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("feedback with id %s not found in trash", id)
 	}
 	
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	
-	if rowsAffected == 0 {
-		return fmt.Errorf("feedback with id %s not found", id)
-	}
-	
-	return nil
+	return feedback, err
+}
+
+// FeedbackCursor is the position after which a keyset page starts.
+// Key holds the value of the sort field, a time.Time or the title.
+type FeedbackCursor struct {
+	Key interface{}
+	ID  string
 }
 
-func (r *FeedbackRepository) ListByUserID(ctx context.Context, userID int64, labID int64, offset, limit int) ([]*models.FeedbackFile, int, error) {
+// ListFeedbacks returns up to limit feedbacks in sort order, starting after the cursor when it is set
+func (r *FeedbackRepository) ListFeedbacks(ctx context.Context, filter FeedbackFilter, sort FeedbackSort, after *FeedbackCursor, limit int) ([]*models.FeedbackFile, error) {
 	var feedbacks []*models.FeedbackFile
-	var args []interface{}
 	
-	// Build query with optional lab_id filter
-	whereClause := "WHERE user_id = $1"
-	args = append(args, userID)
-	
-	if labID > 0 {
-		whereClause += " AND lab_id = $2"
-		args = append(args, labID)
+	column, ok := sortColumns[sort.Field]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q", sort.Field)
+	}
+	direction, comparison := "DESC", "<"
+	if sort.Ascending {
+		direction, comparison = "ASC", ">"
 	}
 	
-	// Count total records
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM feedback_files %s", whereClause)
-	var totalCount int
-	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount)
-	if err != nil {
-		return nil, 0, err
+	whereClause, args := feedbackWhere(filter)
+	
+	// Keyset pagination, the id breaks ties between rows with the same sort key
+	if after != nil {
+		args = append(args, after.Key, after.ID)
+		whereClause += fmt.Sprintf(" AND (%s, id) %s ($%d, $%d)", column, comparison, len(args)-1, len(args))
 	}
 	
-	// Get paginated results
 	query := fmt.Sprintf(`
-		SELECT id, user_id, lab_id, title, content_hash, created_at, updated_at
+		SELECT `+feedbackColumns+`
 		FROM feedback_files
 		%s
-		ORDER BY created_at DESC
-		LIMIT $%d OFFSET $%d`, whereClause, len(args)+1, len(args)+2)
+		ORDER BY %s %s, id %s
+		LIMIT $%d`, whereClause, column, direction, direction, len(args)+1)
 	
-	args = append(args, limit, offset)
+	args = append(args, limit)
 	
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 	
 	for rows.Next() {
-		feedback := &models.FeedbackFile{}
-		err := rows.Scan(
-			&feedback.ID,
-			&feedback.UserID,
-			&feedback.LabID,
-			&feedback.Title,
-			&feedback.ContentHash,
-			&feedback.CreatedAt,
-			&feedback.UpdatedAt,
-		)
+		feedback, err := scanFeedback(rows)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 		feedbacks = append(feedbacks, feedback)
 	}
 	
-	return feedbacks, totalCount, rows.Err()
+	return feedbacks, rows.Err()
+}
+
+// CountFeedbacks returns the number of feedbacks matching the filter
+func (r *FeedbackRepository) CountFeedbacks(ctx context.Context, filter FeedbackFilter) (int, error) {
+	whereClause, args := feedbackWhere(filter)
+	
+	var totalCount int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM feedback_files %s", whereClause)
+	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount)
+	
+	return totalCount, err
+}
+
+// feedbackWhere builds the WHERE clause for a filter, every value is passed as a query parameter
+func feedbackWhere(filter FeedbackFilter) (string, []interface{}) {
+	var args []interface{}
+	
+	// Trashed feedback is never listed
+	whereClause := "WHERE deleted_at IS NULL"
+	
+	if len(filter.UserIDs) > 0 {
+		args = append(args, pq.Array(filter.UserIDs))
+		whereClause += fmt.Sprintf(" AND user_id = ANY($%d)", len(args))
+	}
+	
+	if len(filter.LabIDs) > 0 {
+		args = append(args, pq.Array(filter.LabIDs))
+		whereClause += fmt.Sprintf(" AND lab_id = ANY($%d)", len(args))
+	}
+	
+	if len(filter.Statuses) > 0 {
+		args = append(args, pq.Array(filter.Statuses))
+		whereClause += fmt.Sprintf(" AND status = ANY($%d)", len(args))
+	}
+	
+	if len(filter.Tags) > 0 {
+		args = append(args, pq.Array(filter.Tags))
+		tagQuery := fmt.Sprintf(`
+			SELECT ft.feedback_id FROM feedback_tags ft
+			JOIN lab_tags t ON t.id = ft.tag_id
+			WHERE t.name = ANY($%d)`, len(args))
+		if filter.TagMatch == TagMatchAll {
+			args = append(args, len(filter.Tags))
+			tagQuery += fmt.Sprintf(" GROUP BY ft.feedback_id HAVING COUNT(DISTINCT t.name) = $%d", len(args))
+		}
+		whereClause += " AND id IN (" + tagQuery + ")"
+	}
+	
+	timeRanges := []struct {
+		condition string
+		value     time.Time
+	}{
+		{"created_at >= $%d", filter.CreatedAfter},
+		{"created_at < $%d", filter.CreatedBefore},
+		{"updated_at >= $%d", filter.UpdatedAfter},
+		{"updated_at < $%d", filter.UpdatedBefore},
+	}
+	for _, timeRange := range timeRanges {
+		if !timeRange.value.IsZero() {
+			args = append(args, timeRange.value)
+			whereClause += " AND " + fmt.Sprintf(timeRange.condition, len(args))
+		}
+	}
+	
+	if filter.TitleContains != "" {
+		args = append(args, "%"+escapeLike(filter.TitleContains)+"%")
+		whereClause += fmt.Sprintf(` AND title ILIKE $%d ESCAPE '\'`, len(args))
+	}
+	
+	if filter.HasAssets != nil {
+		assetQuery := "EXISTS (SELECT 1 FROM feedback_assets a WHERE a.feedback_id = feedback_files.id)"
+		if !*filter.HasAssets {
+			assetQuery = "NOT " + assetQuery
+		}
+		whereClause += " AND " + assetQuery
+	}
+	
+	if filter.ParticipantID > 0 {
+		args = append(args, filter.ParticipantID)
+		whereClause += fmt.Sprintf(" AND (user_id = $%d OR author_id = $%d)", len(args), len(args))
+	}
+	
+	// Drafts are invisible to anyone but their author
+	args = append(args, filter.ViewerID)
+	whereClause += fmt.Sprintf(" AND (status <> 'draft' OR author_id = $%d)", len(args))
+	
+	return whereClause, args
+}
+
+// escapeLike escapes the LIKE wildcards in a user supplied pattern
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// statusTimestampColumns names the column recording when feedback entered each status
+var statusTimestampColumns = map[string]string{
+	models.StatusPublished:    "published_at",
+	models.StatusAcknowledged: "acknowledged_at",
+	models.StatusResolved:     "resolved_at",
+	models.StatusArchived:     "archived_at",
+}
+
+// UpdateStatus moves feedback from one status to another and stamps the transition time.
+// It returns sql.ErrNoRows when the feedback is no longer in the expected status.
+func (r *FeedbackRepository) UpdateStatus(ctx context.Context, id, from, to string) (*models.FeedbackFile, error) {
+	column, ok := statusTimestampColumns[to]
+	if !ok {
+		return nil, fmt.Errorf("unknown target status %q", to)
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE feedback_files
+		SET status = $3, %s = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = $2 AND deleted_at IS NULL
+		RETURNING `+feedbackColumns, column)
+
+	return scanFeedback(r.db.QueryRowContext(ctx, query, id, from, to))
 }
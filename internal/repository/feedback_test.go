@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// feedbackRow returns a row in the order of feedbackColumns
+func feedbackRow(id, status string, deletedAt interface{}) []driver.Value {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return []driver.Value{id, int64(2), int64(3), "Lab 1", "hash", created, created,
+		status, int64(1), nil, nil, nil, nil, deletedAt, int64(0)}
+}
+
+var feedbackColumnNames = strings.Split("id,user_id,lab_id,title,content_hash,created_at,updated_at,status,author_id,published_at,acknowledged_at,resolved_at,archived_at,deleted_at,deleted_by", ",")
+
+func TestDeleteOnlyRemovesTrashedFeedback(t *testing.T) {
+	trashed := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	repo, fake := newFakeRepository(t, func(query string, args []driver.Value) fakeResult {
+		if args[0] == "trashed" {
+			return fakeResult{columns: feedbackColumnNames, rows: [][]driver.Value{feedbackRow("trashed", "published", trashed)}}
+		}
+		return fakeResult{columns: feedbackColumnNames}
+	})
+
+	feedback, err := repo.Delete(context.Background(), "trashed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feedback.ID != "trashed" || feedback.DeletedAt == nil || !feedback.DeletedAt.Equal(trashed) {
+		t.Errorf("got %+v", feedback)
+	}
+
+	if _, err := repo.Delete(context.Background(), "live"); err == nil || !strings.Contains(err.Error(), "not found in trash") {
+		t.Errorf("got %v, want not found in trash", err)
+	}
+
+	for _, query := range fake.queries() {
+		if !strings.Contains(query, "WHERE id = $1 AND deleted_at IS NOT NULL") {
+			t.Errorf("delete without trash guard: %s", query)
+		}
+	}
+}
+
+func TestGetByIDSkipsTrashedFeedback(t *testing.T) {
+	repo, fake := newFakeRepository(t, nil)
+
+	repo.GetByID(context.Background(), "f1")
+
+	if queries := fake.queries(); len(queries) != 1 || !strings.Contains(queries[0], "deleted_at IS NULL") {
+		t.Errorf("got %v, want a lookup of live feedback", queries)
+	}
+}
+
+func TestFeedbackWhere(t *testing.T) {
+	hasAssets, noAssets := true, false
+	after := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		filter FeedbackFilter
+		where  string
+		args   []interface{}
+	}{
+		{
+			name:   "no filter",
+			filter: FeedbackFilter{ViewerID: 7},
+			where:  "WHERE deleted_at IS NULL AND (status <> 'draft' OR author_id = $1)",
+			args:   []interface{}{int64(7)},
+		},
+		{
+			name:   "users, labs and statuses",
+			filter: FeedbackFilter{UserIDs: []int64{1, 2}, LabIDs: []int64{3}, Statuses: []string{"published"}, ViewerID: 7},
+			where: "WHERE deleted_at IS NULL AND user_id = ANY($1) AND lab_id = ANY($2) AND status = ANY($3)" +
+				" AND (status <> 'draft' OR author_id = $4)",
+			args: []interface{}{pq.Array([]int64{1, 2}), pq.Array([]int64{3}), pq.Array([]string{"published"}), int64(7)},
+		},
+		{
+			name:   "any tag",
+			filter: FeedbackFilter{Tags: []string{"a", "b"}, TagMatch: TagMatchAny},
+			where: "WHERE deleted_at IS NULL AND id IN ( SELECT ft.feedback_id FROM feedback_tags ft" +
+				" JOIN lab_tags t ON t.id = ft.tag_id WHERE t.name = ANY($1)) AND (status <> 'draft' OR author_id = $2)",
+			args: []interface{}{pq.Array([]string{"a", "b"}), int64(0)},
+		},
+		{
+			name:   "all tags",
+			filter: FeedbackFilter{Tags: []string{"a", "b"}, TagMatch: TagMatchAll},
+			where: "WHERE deleted_at IS NULL AND id IN ( SELECT ft.feedback_id FROM feedback_tags ft" +
+				" JOIN lab_tags t ON t.id = ft.tag_id WHERE t.name = ANY($1) GROUP BY ft.feedback_id" +
+				" HAVING COUNT(DISTINCT t.name) = $2) AND (status <> 'draft' OR author_id = $3)",
+			args: []interface{}{pq.Array([]string{"a", "b"}), 2, int64(0)},
+		},
+		{
+			name:   "time ranges",
+			filter: FeedbackFilter{CreatedAfter: after, UpdatedBefore: after},
+			where:  "WHERE deleted_at IS NULL AND created_at >= $1 AND updated_at < $2 AND (status <> 'draft' OR author_id = $3)",
+			args:   []interface{}{after, after, int64(0)},
+		},
+		{
+			name:   "title with wildcards",
+			filter: FeedbackFilter{TitleContains: `50%_off\`},
+			where:  `WHERE deleted_at IS NULL AND title ILIKE $1 ESCAPE '\' AND (status <> 'draft' OR author_id = $2)`,
+			args:   []interface{}{`%50\%\_off\\%`, int64(0)},
+		},
+		{
+			name:   "with assets",
+			filter: FeedbackFilter{HasAssets: &hasAssets},
+			where: "WHERE deleted_at IS NULL AND EXISTS (SELECT 1 FROM feedback_assets a WHERE a.feedback_id = feedback_files.id)" +
+				" AND (status <> 'draft' OR author_id = $1)",
+			args: []interface{}{int64(0)},
+		},
+		{
+			name:   "without assets",
+			filter: FeedbackFilter{HasAssets: &noAssets},
+			where: "WHERE deleted_at IS NULL AND NOT EXISTS (SELECT 1 FROM feedback_assets a WHERE a.feedback_id = feedback_files.id)" +
+				" AND (status <> 'draft' OR author_id = $1)",
+			args: []interface{}{int64(0)},
+		},
+		{
+			name:   "participant",
+			filter: FeedbackFilter{ParticipantID: 5, ViewerID: 5},
+			where:  "WHERE deleted_at IS NULL AND (user_id = $1 OR author_id = $1) AND (status <> 'draft' OR author_id = $2)",
+			args:   []interface{}{int64(5), int64(5)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args := feedbackWhere(tt.filter)
+			if where = strings.Join(strings.Fields(where), " "); where != tt.where {
+				t.Errorf("got where\n%s\nwant\n%s", where, tt.where)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("got args %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestListFeedbacksOrder(t *testing.T) {
+	cursorTime := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		sort  FeedbackSort
+		after *FeedbackCursor
+		want  string
+		args  []driver.Value
+	}{
+		{
+			name: "newest first",
+			sort: FeedbackSort{Field: SortByCreatedAt},
+			want: "AND (status <> 'draft' OR author_id = $1) ORDER BY created_at DESC, id DESC LIMIT $2",
+			args: []driver.Value{int64(7), 11},
+		},
+		{
+			name:  "updated ascending after a cursor",
+			sort:  FeedbackSort{Field: SortByUpdatedAt, Ascending: true},
+			after: &FeedbackCursor{Key: cursorTime, ID: "f1"},
+			want:  "AND (updated_at, id) > ($2, $3) ORDER BY updated_at ASC, id ASC LIMIT $4",
+			args:  []driver.Value{int64(7), cursorTime, "f1", 11},
+		},
+		{
+			name:  "title descending after a cursor",
+			sort:  FeedbackSort{Field: SortByTitle},
+			after: &FeedbackCursor{Key: "Lab 1", ID: "f1"},
+			want:  "AND (title, id) < ($2, $3) ORDER BY title DESC, id DESC LIMIT $4",
+			args:  []driver.Value{int64(7), "Lab 1", "f1", 11},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, fake := newFakeRepository(t, func(query string, args []driver.Value) fakeResult {
+				return fakeResult{columns: feedbackColumnNames, rows: [][]driver.Value{feedbackRow("f2", "published", nil)}}
+			})
+
+			feedbacks, err := repo.ListFeedbacks(context.Background(), FeedbackFilter{ViewerID: 7}, tt.sort, tt.after, 11)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(feedbacks) != 1 || feedbacks[0].ID != "f2" {
+				t.Errorf("got %v, want f2", feedbacks)
+			}
+
+			if query := fake.queries()[0]; !strings.HasSuffix(query, tt.want) {
+				t.Errorf("got query\n%s\nwant suffix\n%s", query, tt.want)
+			}
+			if !reflect.DeepEqual(fake.statements[0].args, tt.args) {
+				t.Errorf("got args %#v, want %#v", fake.statements[0].args, tt.args)
+			}
+		})
+	}
+}
+
+func TestListFeedbacksRejectsUnknownSortField(t *testing.T) {
+	repo, fake := newFakeRepository(t, nil)
+
+	_, err := repo.ListFeedbacks(context.Background(), FeedbackFilter{}, FeedbackSort{Field: "id; DROP TABLE feedback_files"}, nil, 10)
+	if err == nil {
+		t.Fatal("expected an unknown sort field to fail")
+	}
+	if len(fake.statements) != 0 {
+		t.Errorf("query ran with an unknown sort field: %v", fake.queries())
+	}
+}
+
+func TestCountFeedbacksUsesTheListFilter(t *testing.T) {
+	repo, fake := newFakeRepository(t, func(query string, args []driver.Value) fakeResult {
+		return fakeResult{columns: []string{"count"}, rows: [][]driver.Value{{int64(3)}}}
+	})
+
+	count, err := repo.CountFeedbacks(context.Background(), FeedbackFilter{LabIDs: []int64{3}, ViewerID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("got %d, want 3", count)
+	}
+
+	want := "SELECT COUNT(*) FROM feedback_files WHERE deleted_at IS NULL AND lab_id = ANY($1) AND (status <> 'draft' OR author_id = $2)"
+	if query := fake.queries()[0]; query != want {
+		t.Errorf("got %s, want %s", query, want)
+	}
+}
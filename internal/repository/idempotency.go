@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+// ReserveIdempotencyKey records a new request under its key for at most lease. If the key is
+// already taken by an unexpired request, that request is returned instead and nothing is changed.
+func (r *FeedbackRepository) ReserveIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord, lease time.Duration) (*models.IdempotencyRecord, error) {
+	// An expired key is free to be reused with any payload, a request whose lease passed without
+	// a response may be taken over by a retry with the same payload
+	query := `
+		INSERT INTO idempotency_keys (caller_id, method, idempotency_key, fingerprint, created_at, expires_at, reserved_until)
+		VALUES ($1, $2, $3, $4, NOW(), $5, NOW() + $6 * INTERVAL '1 millisecond')
+		ON CONFLICT (caller_id, method, idempotency_key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint, response = NULL, created_at = NOW(),
+			expires_at = EXCLUDED.expires_at, reserved_until = EXCLUDED.reserved_until
+		WHERE idempotency_keys.expires_at < NOW()
+			OR (idempotency_keys.response IS NULL
+				AND idempotency_keys.fingerprint = EXCLUDED.fingerprint
+				AND idempotency_keys.reserved_until < NOW())
+		RETURNING created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		record.CallerID,
+		record.Method,
+		record.Key,
+		record.Fingerprint,
+		record.ExpiresAt,
+		lease.Milliseconds(),
+	).Scan(&record.CreatedAt)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	existing := &models.IdempotencyRecord{}
+	err = r.db.QueryRowContext(ctx, `
+		SELECT caller_id, method, idempotency_key, fingerprint, response, created_at, expires_at
+		FROM idempotency_keys
+		WHERE caller_id = $1 AND method = $2 AND idempotency_key = $3`,
+		record.CallerID, record.Method, record.Key,
+	).Scan(
+		&existing.CallerID,
+		&existing.Method,
+		&existing.Key,
+		&existing.Fingerprint,
+		&existing.Response,
+		&existing.CreatedAt,
+		&existing.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// CompleteIdempotencyKey stores the response of a reserved request
+func (r *FeedbackRepository) CompleteIdempotencyKey(ctx context.Context, callerID int64, method, key string, response []byte) error {
+	query := `
+		UPDATE idempotency_keys SET response = $4, reserved_until = NULL
+		WHERE caller_id = $1 AND method = $2 AND idempotency_key = $3`
+
+	_, err := r.db.ExecContext(ctx, query, callerID, method, key, response)
+	return err
+}
+
+// ReleaseIdempotencyKey frees a reserved key so the request can be retried, completed keys are kept
+func (r *FeedbackRepository) ReleaseIdempotencyKey(ctx context.Context, callerID int64, method, key string) error {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE caller_id = $1 AND method = $2 AND idempotency_key = $3 AND response IS NULL`
+
+	_, err := r.db.ExecContext(ctx, query, callerID, method, key)
+	return err
+}
+
+// DeleteExpiredIdempotencyKeys removes keys past their replay window
+func (r *FeedbackRepository) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
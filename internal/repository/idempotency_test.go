@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+func TestReserveIdempotencyKeyLease(t *testing.T) {
+	repo, fake := newFakeRepository(t, func(query string, args []driver.Value) fakeResult {
+		return fakeResult{columns: []string{"created_at"}, rows: [][]driver.Value{{time.Now()}}}
+	})
+
+	record := &models.IdempotencyRecord{CallerID: 7, Method: "m", Key: "k", Fingerprint: "abc", ExpiresAt: time.Now()}
+	existing, err := repo.ReserveIdempotencyKey(context.Background(), record, 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if existing != nil {
+		t.Errorf("got %+v, want the key reserved", existing)
+	}
+
+	if lease := fake.statements[0].args[5]; lease != int64(300000) {
+		t.Errorf("got lease %v, want 300000ms", lease)
+	}
+	query := fake.queries()[0]
+	for _, want := range []string{
+		"NOW() + $6 * INTERVAL '1 millisecond'",
+		"idempotency_keys.expires_at < NOW()",
+		"idempotency_keys.response IS NULL AND idempotency_keys.fingerprint = EXCLUDED.fingerprint AND idempotency_keys.reserved_until < NOW()",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("reservation query lacks %q", want)
+		}
+	}
+}
+
+func TestReleaseIdempotencyKeyKeepsCompletedKeys(t *testing.T) {
+	repo, fake := newFakeRepository(t, nil)
+
+	if err := repo.ReleaseIdempotencyKey(context.Background(), 7, "m", "k"); err != nil {
+		t.Fatal(err)
+	}
+	if query := fake.queries()[0]; !strings.Contains(query, "AND response IS NULL") {
+		t.Errorf("release may delete a completed key: %s", query)
+	}
+}
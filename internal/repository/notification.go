@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+const notificationColumns = `id, event_id, user_id, kind, feedback_id, payload, status, attempts,
+	COALESCE(last_error, ''), next_attempt_at, created_at, sent_at, COALESCE(digest_id, 0)`
+
+// GetNotificationPreference returns the preference of a user, immediate when none was stored
+func (r *FeedbackRepository) GetNotificationPreference(ctx context.Context, userID int64) (*models.NotificationPreference, error) {
+	pref := &models.NotificationPreference{UserID: userID}
+
+	query := `
+		SELECT COALESCE(MAX(mode), $2), COALESCE(MAX(updated_at), NOW())
+		FROM notification_preferences
+		WHERE user_id = $1`
+
+	err := r.db.QueryRowContext(ctx, query, userID, models.NotifyImmediate).Scan(&pref.Mode, &pref.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return pref, nil
+}
+
+func (r *FeedbackRepository) SetNotificationPreference(ctx context.Context, pref *models.NotificationPreference) error {
+	query := `
+		INSERT INTO notification_preferences (user_id, mode, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET mode = EXCLUDED.mode, updated_at = NOW()
+		RETURNING updated_at`
+
+	return r.db.QueryRowContext(ctx, query, pref.UserID, pref.Mode).Scan(&pref.UpdatedAt)
+}
+
+// EnqueueNotification stores a notification unless the recipient was already notified of the event.
+// It reports whether the notification was stored.
+func (r *FeedbackRepository) EnqueueNotification(ctx context.Context, notification *models.Notification) (bool, error) {
+	query := `
+		INSERT INTO notifications (event_id, user_id, kind, feedback_id, payload, status, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		ON CONFLICT (event_id, user_id) DO NOTHING`
+
+	result, err := r.db.ExecContext(ctx, query,
+		notification.EventID,
+		notification.UserID,
+		notification.Kind,
+		notification.FeedbackID,
+		[]byte(notification.Payload),
+		notification.Status,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// ClaimNotifications counts an attempt for up to limit due pending notifications and hides
+// them from other senders for the lease, after which an unrecorded attempt is retried
+func (r *FeedbackRepository) ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]*models.Notification, error) {
+	query := `
+		WITH due AS (
+			SELECT id
+			FROM notifications
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE notifications n
+		SET attempts = n.attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+		FROM due
+		WHERE n.id = due.id
+		RETURNING n.id, n.event_id, n.user_id, n.kind, n.feedback_id, n.payload, n.status, n.attempts,
+			COALESCE(n.last_error, ''), n.next_attempt_at, n.created_at, n.sent_at, COALESCE(n.digest_id, 0)`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []*models.Notification
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+// RecordNotificationAttempt stores the status, error and next attempt of a notification
+func (r *FeedbackRepository) RecordNotificationAttempt(ctx context.Context, notification *models.Notification) error {
+	query := `
+		UPDATE notifications
+		SET status = $2, last_error = NULLIF($3, ''), next_attempt_at = $4,
+			sent_at = CASE WHEN $2 = 'sent' THEN NOW() ELSE sent_at END
+		WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query,
+		notification.ID,
+		notification.Status,
+		notification.LastError,
+		notification.NextAttemptAt,
+	)
+	return err
+}
+
+func scanNotification(row rowScanner) (*models.Notification, error) {
+	notification := &models.Notification{}
+	var payload []byte
+
+	err := row.Scan(
+		&notification.ID,
+		&notification.EventID,
+		&notification.UserID,
+		&notification.Kind,
+		&notification.FeedbackID,
+		&payload,
+		&notification.Status,
+		&notification.Attempts,
+		&notification.LastError,
+		&notification.NextAttemptAt,
+		&notification.CreatedAt,
+		&notification.SentAt,
+		&notification.DigestID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	notification.Payload = payload
+	return notification, nil
+}
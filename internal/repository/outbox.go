@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/google/uuid"
+)
+
+// AppendOutboxEvent stores a domain event for the relay. Call it inside InTx so the event
+// commits or rolls back together with the change it describes.
+func (r *FeedbackRepository) AppendOutboxEvent(ctx context.Context, event *models.DomainEvent) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	// Writers of the same feedback queue up until commit, so ids follow commit order
+	// and the relay never sees a later event of a feedback before an earlier one
+	_, err = r.db.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", event.FeedbackID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO outbox_events (event_id, event_type, feedback_id, payload, created_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $5)`,
+		event.ID, event.Type, event.FeedbackID, payload, event.OccurredAt)
+	return err
+}
+
+// ProcessOutbox claims up to limit due events, hands them to publish and records the outcome.
+// Only the oldest pending event of each feedback is claimed, so a failing event holds back the
+// later events of its feedback. Claiming hides the events from other relays for lease and
+// commits before anything is published, a relay that dies while publishing leaves its events
+// to be claimed again once the lease passed. Failed events are retried after retryAfter(attempts).
+func (r *FeedbackRepository) ProcessOutbox(ctx context.Context, limit int, lease time.Duration, publish func(*models.DomainEvent) error, retryAfter func(attempts int) time.Duration) (published, failed int, err error) {
+	batch, err := r.claimOutboxEvents(ctx, limit, lease)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, item := range batch {
+		event := &models.DomainEvent{}
+		publishErr := json.Unmarshal(item.payload, event)
+		if publishErr != nil {
+			publishErr = fmt.Errorf("failed to decode outbox event %d: %w", item.id, publishErr)
+		} else {
+			event.Attempts = item.attempts
+			publishErr = publish(event)
+		}
+
+		if publishErr != nil {
+			delay := retryAfter(item.attempts + 1)
+			_, err := r.db.ExecContext(ctx, `
+				UPDATE outbox_events
+				SET attempts = attempts + 1, last_error = $2,
+					next_attempt_at = NOW() + $3 * INTERVAL '1 millisecond'
+				WHERE id = $1`,
+				item.id, publishErr.Error(), delay.Milliseconds())
+			if err != nil {
+				return published, failed, err
+			}
+			failed++
+			continue
+		}
+
+		_, err := r.db.ExecContext(ctx,
+			"UPDATE outbox_events SET published_at = NOW(), last_error = NULL WHERE id = $1", item.id)
+		if err != nil {
+			return published, failed, err
+		}
+		published++
+	}
+
+	return published, failed, nil
+}
+
+// claimedOutboxEvent is a row claimed by claimOutboxEvents, decoded only when it is published
+type claimedOutboxEvent struct {
+	id       int64
+	payload  []byte
+	attempts int
+}
+
+// claimOutboxEvents postpones up to limit due events by lease and returns them in id order.
+// Rows are locked with SKIP LOCKED, several relays can claim side by side.
+func (r *FeedbackRepository) claimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]claimedOutboxEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		WITH due AS (
+			SELECT o.id
+			FROM outbox_events o
+			WHERE o.published_at IS NULL AND o.next_attempt_at <= NOW()
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox_events e
+				WHERE e.feedback_id = o.feedback_id AND e.published_at IS NULL AND e.id < o.id
+			)
+			ORDER BY o.id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE outbox_events
+		SET next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+		FROM due
+		WHERE outbox_events.id = due.id
+		RETURNING outbox_events.id, outbox_events.payload, outbox_events.attempts`,
+		limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batch []claimedOutboxEvent
+	for rows.Next() {
+		var item claimedOutboxEvent
+		if err := rows.Scan(&item.id, &item.payload, &item.attempts); err != nil {
+			return nil, err
+		}
+		batch = append(batch, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the order of the claim
+	sort.Slice(batch, func(i, j int) bool {
+		return batch[i].id < batch[j].id
+	})
+	return batch, nil
+}
+
+// DeletePublishedOutboxEvents removes events published before the cutoff
+func (r *FeedbackRepository) DeletePublishedOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx,
+		"DELETE FROM outbox_events WHERE published_at IS NOT NULL AND published_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
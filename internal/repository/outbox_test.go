@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+func TestProcessOutbox(t *testing.T) {
+	repo, fake := newFakeRepository(t, func(query string, args []driver.Value) fakeResult {
+		if !strings.Contains(query, "RETURNING") {
+			return fakeResult{}
+		}
+		// Claimed rows come back out of order, one of them cannot be decoded
+		return fakeResult{
+			columns: []string{"id", "payload", "attempts"},
+			rows: [][]driver.Value{
+				{int64(3), []byte(`{"id":"e3","type":"feedback.updated","feedback_id":"f3"}`), int64(2)},
+				{int64(1), []byte(`{"id":"e1","type":"feedback.created","feedback_id":"f1"}`), int64(0)},
+				{int64(2), []byte(`{not json`), int64(0)},
+			},
+		}
+	})
+
+	var publishedIDs []string
+	published, failed, err := repo.ProcessOutbox(context.Background(), 10, time.Minute, func(event *models.DomainEvent) error {
+		// Nothing may be held open while a publisher runs
+		for _, query := range fake.queries() {
+			if query == "BEGIN" {
+				t.Error("publish ran inside a transaction")
+			}
+		}
+
+		publishedIDs = append(publishedIDs, event.ID)
+		if event.ID == "e3" {
+			if event.Attempts != 2 {
+				t.Errorf("got %d attempts, want 2", event.Attempts)
+			}
+			return errors.New("broker unavailable")
+		}
+		return nil
+	}, func(attempts int) time.Duration {
+		return time.Duration(attempts) * time.Second
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if published != 1 || failed != 2 {
+		t.Errorf("got %d published and %d failed, want 1 and 2", published, failed)
+	}
+	if want := []string{"e1", "e3"}; !reflect.DeepEqual(publishedIDs, want) {
+		t.Errorf("published %v, want %v", publishedIDs, want)
+	}
+
+	claim := fake.queries()[0]
+	for _, want := range []string{"FOR UPDATE SKIP LOCKED", "SET next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'"} {
+		if !strings.Contains(claim, want) {
+			t.Errorf("claim lacks %q", want)
+		}
+	}
+	if want := []driver.Value{10, int64(60000)}; !reflect.DeepEqual(fake.statements[0].args, want) {
+		t.Errorf("got claim args %v, want %v", fake.statements[0].args, want)
+	}
+
+	// Results are recorded in id order, the undecodable event is marked failed on its own
+	results := fake.statements[1:]
+	if len(results) != 3 {
+		t.Fatalf("got %d result statements, want 3: %v", len(results), fake.queries())
+	}
+	if !strings.Contains(results[0].query, "published_at = NOW()") || results[0].args[0] != int64(1) {
+		t.Errorf("event 1 not marked published: %v", results[0])
+	}
+	if results[1].args[0] != int64(2) || !strings.Contains(results[1].args[1].(string), "failed to decode outbox event 2") ||
+		results[1].args[2] != int64(1000) {
+		t.Errorf("event 2 not marked failed: %v", results[1].args)
+	}
+	if results[2].args[0] != int64(3) || results[2].args[1] != "broker unavailable" || results[2].args[2] != int64(3000) {
+		t.Errorf("event 3 not marked failed: %v", results[2].args)
+	}
+}
+
+func TestProcessOutboxStopsWhenAResultCannotBeRecorded(t *testing.T) {
+	repo, _ := newFakeRepository(t, func(query string, args []driver.Value) fakeResult {
+		if strings.Contains(query, "RETURNING") {
+			return fakeResult{
+				columns: []string{"id", "payload", "attempts"},
+				rows: [][]driver.Value{
+					{int64(1), []byte(`{"id":"e1"}`), int64(0)},
+					{int64(2), []byte(`{"id":"e2"}`), int64(0)},
+				},
+			}
+		}
+		return fakeResult{err: errors.New("connection reset")}
+	})
+
+	calls := 0
+	published, _, err := repo.ProcessOutbox(context.Background(), 10, time.Minute, func(event *models.DomainEvent) error {
+		calls++
+		return nil
+	}, func(int) time.Duration { return time.Second })
+	if err == nil {
+		t.Fatal("expected the failed update to be returned")
+	}
+	if calls != 1 || published != 0 {
+		t.Errorf("got %d publish calls and %d published, want 1 and 0", calls, published)
+	}
+}
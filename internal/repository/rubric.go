@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/google/uuid"
+)
+
+// CreateRubric stores a rubric as the next version of its lab
+func (r *FeedbackRepository) CreateRubric(ctx context.Context, rubric *models.Rubric) error {
+	criteria, err := json.Marshal(rubric.Criteria)
+	if err != nil {
+		return fmt.Errorf("failed to encode rubric criteria: %w", err)
+	}
+
+	rubric.ID = uuid.New().String()
+
+	// Concurrent definitions for the same lab fail on the (lab_id, version) constraint
+	query := `
+		INSERT INTO rubrics (id, lab_id, version, title, criteria, created_by, created_at)
+		SELECT $1, $2, COALESCE(MAX(version), 0) + 1, $3, $4, NULLIF($5, 0), NOW()
+		FROM rubrics WHERE lab_id = $2
+		RETURNING version, created_at`
+
+	return r.db.QueryRowContext(ctx, query,
+		rubric.ID,
+		rubric.LabID,
+		rubric.Title,
+		criteria,
+		rubric.CreatedBy,
+	).Scan(&rubric.Version, &rubric.CreatedAt)
+}
+
+// GetRubric returns a rubric version of a lab, version 0 selects the latest one
+func (r *FeedbackRepository) GetRubric(ctx context.Context, labID int64, version int) (*models.Rubric, error) {
+	query := `
+		SELECT id, lab_id, version, title, criteria, COALESCE(created_by, 0), created_at
+		FROM rubrics
+		WHERE lab_id = $1 AND ($2 = 0 OR version = $2)
+		ORDER BY version DESC
+		LIMIT 1`
+
+	return scanRubric(r.db.QueryRowContext(ctx, query, labID, version))
+}
+
+func (r *FeedbackRepository) GetRubricByID(ctx context.Context, id string) (*models.Rubric, error) {
+	query := `
+		SELECT id, lab_id, version, title, criteria, COALESCE(created_by, 0), created_at
+		FROM rubrics
+		WHERE id = $1`
+
+	return scanRubric(r.db.QueryRowContext(ctx, query, id))
+}
+
+func scanRubric(row rowScanner) (*models.Rubric, error) {
+	rubric := &models.Rubric{}
+	var criteria []byte
+
+	err := row.Scan(
+		&rubric.ID,
+		&rubric.LabID,
+		&rubric.Version,
+		&rubric.Title,
+		&criteria,
+		&rubric.CreatedBy,
+		&rubric.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(criteria, &rubric.Criteria); err != nil {
+		return nil, fmt.Errorf("failed to decode rubric criteria: %w", err)
+	}
+
+	return rubric, nil
+}
+
+// UpsertFeedbackScore stores the rubric score of a feedback, replacing any previous score
+func (r *FeedbackRepository) UpsertFeedbackScore(ctx context.Context, score *models.FeedbackScore) error {
+	criteria, err := json.Marshal(score.Criteria)
+	if err != nil {
+		return fmt.Errorf("failed to encode criterion scores: %w", err)
+	}
+
+	query := `
+		INSERT INTO feedback_scores (feedback_id, rubric_id, criteria, total, max_total, scored_by, scored_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), NOW())
+		ON CONFLICT (feedback_id)
+		DO UPDATE SET rubric_id = EXCLUDED.rubric_id, criteria = EXCLUDED.criteria,
+			total = EXCLUDED.total, max_total = EXCLUDED.max_total,
+			scored_by = EXCLUDED.scored_by, scored_at = NOW()
+		RETURNING scored_at`
+
+	return r.db.QueryRowContext(ctx, query,
+		score.FeedbackID,
+		score.RubricID,
+		criteria,
+		score.Total,
+		score.MaxTotal,
+		score.ScoredBy,
+	).Scan(&score.ScoredAt)
+}
+
+// GetFeedbackScore returns the rubric score of a feedback together with the scored rubric version
+func (r *FeedbackRepository) GetFeedbackScore(ctx context.Context, feedbackID string) (*models.FeedbackScore, error) {
+	score := &models.FeedbackScore{}
+	var criteria []byte
+
+	query := `
+		SELECT s.feedback_id, s.rubric_id, rb.version, s.criteria, s.total, s.max_total,
+			COALESCE(s.scored_by, 0), s.scored_at
+		FROM feedback_scores s
+		JOIN rubrics rb ON rb.id = s.rubric_id
+		WHERE s.feedback_id = $1`
+
+	err := r.db.QueryRowContext(ctx, query, feedbackID).Scan(
+		&score.FeedbackID,
+		&score.RubricID,
+		&score.RubricVersion,
+		&criteria,
+		&score.Total,
+		&score.MaxTotal,
+		&score.ScoredBy,
+		&score.ScoredAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(criteria, &score.Criteria); err != nil {
+		return nil, fmt.Errorf("failed to decode criterion scores: %w", err)
+	}
+
+	return score, nil
+}
+
+// DeleteScoreByFeedbackID removes the rubric score of a feedback
+func (r *FeedbackRepository) DeleteScoreByFeedbackID(ctx context.Context, feedbackID string) error {
+	query := `DELETE FROM feedback_scores WHERE feedback_id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, feedbackID)
+	return err
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/lib/pq"
+)
+
+// AddTags applies tags to a feedback, adding names missing from the lab vocabulary
+func (r *FeedbackRepository) AddTags(ctx context.Context, feedbackID string, labID int64, names []string) error {
+	return r.InTx(ctx, func(tx *FeedbackRepository) error {
+		_, err := tx.db.ExecContext(ctx, `
+			INSERT INTO lab_tags (lab_id, name)
+			SELECT $1, unnest($2::text[])
+			ON CONFLICT (lab_id, name) DO NOTHING`,
+			labID, pq.Array(names))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.db.ExecContext(ctx, `
+			INSERT INTO feedback_tags (feedback_id, tag_id)
+			SELECT $1, id FROM lab_tags WHERE lab_id = $2 AND name = ANY($3)
+			ON CONFLICT (feedback_id, tag_id) DO NOTHING`,
+			feedbackID, labID, pq.Array(names))
+		return err
+	})
+}
+
+// RemoveTags removes tags from a feedback, the lab vocabulary is left untouched
+func (r *FeedbackRepository) RemoveTags(ctx context.Context, feedbackID string, names []string) error {
+	query := `
+		DELETE FROM feedback_tags ft
+		USING lab_tags t
+		WHERE ft.tag_id = t.id AND ft.feedback_id = $1 AND t.name = ANY($2)`
+
+	_, err := r.db.ExecContext(ctx, query, feedbackID, pq.Array(names))
+	return err
+}
+
+// DeleteTagsByFeedbackID removes all tags of a feedback
+func (r *FeedbackRepository) DeleteTagsByFeedbackID(ctx context.Context, feedbackID string) error {
+	query := `DELETE FROM feedback_tags WHERE feedback_id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, feedbackID)
+	return err
+}
+
+// ListTags returns the sorted tag names of each given feedback
+func (r *FeedbackRepository) ListTags(ctx context.Context, feedbackIDs []string) (map[string][]string, error) {
+	tags := make(map[string][]string, len(feedbackIDs))
+	if len(feedbackIDs) == 0 {
+		return tags, nil
+	}
+
+	query := `
+		SELECT ft.feedback_id, t.name
+		FROM feedback_tags ft
+		JOIN lab_tags t ON t.id = ft.tag_id
+		WHERE ft.feedback_id = ANY($1::uuid[])
+		ORDER BY t.name`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(feedbackIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var feedbackID, name string
+		if err := rows.Scan(&feedbackID, &name); err != nil {
+			return nil, err
+		}
+		tags[feedbackID] = append(tags[feedbackID], name)
+	}
+
+	return tags, rows.Err()
+}
+
+// ListLabTagCounts returns the tag vocabulary of a lab with the number of feedbacks
+// carrying each tag. Trashed feedback and drafts of other authors than viewerID are not counted.
+func (r *FeedbackRepository) ListLabTagCounts(ctx context.Context, labID, viewerID int64) ([]*models.TagCount, error) {
+	query := `
+		SELECT t.name, COUNT(f.id)
+		FROM lab_tags t
+		LEFT JOIN feedback_tags ft ON ft.tag_id = t.id
+		LEFT JOIN feedback_files f ON f.id = ft.feedback_id AND f.deleted_at IS NULL
+			AND (f.status <> 'draft' OR f.author_id = $2)
+		WHERE t.lab_id = $1
+		GROUP BY t.name
+		ORDER BY t.name`
+
+	rows, err := r.db.QueryContext(ctx, query, labID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []*models.TagCount
+	for rows.Next() {
+		count := &models.TagCount{}
+		if err := rows.Scan(&count.Name, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
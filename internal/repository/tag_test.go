@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+func TestListLabTagCountsHidesOtherAuthorsDrafts(t *testing.T) {
+	var args []driver.Value
+	repo, fake := newFakeRepository(t, func(query string, queryArgs []driver.Value) fakeResult {
+		args = queryArgs
+		return fakeResult{
+			columns: []string{"name", "count"},
+			rows:    [][]driver.Value{{"late", int64(2)}, {"unused", int64(0)}},
+		}
+	})
+
+	counts, err := repo.ListLabTagCounts(context.Background(), 3, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*models.TagCount{{Name: "late", Count: 2}, {Name: "unused", Count: 0}}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("got %v, want %v", counts, want)
+	}
+	if !reflect.DeepEqual(args, []driver.Value{int64(3), int64(9)}) {
+		t.Errorf("got args %v, want lab 3 and viewer 9", args)
+	}
+	if query := fake.queries()[0]; !strings.Contains(query, "AND (f.status <> 'draft' OR f.author_id = $2)") {
+		t.Errorf("drafts are not filtered: %s", query)
+	}
+}
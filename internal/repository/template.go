@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/google/uuid"
+)
+
+const templateColumns = `id, COALESCE(lab_id, 0), name, description, body, COALESCE(created_by, 0), created_at, updated_at`
+
+func (r *FeedbackRepository) CreateTemplate(ctx context.Context, template *models.FeedbackTemplate) error {
+	template.ID = uuid.New().String()
+
+	query := `
+		INSERT INTO feedback_templates (id, lab_id, name, description, body, created_by, created_at, updated_at)
+		VALUES ($1, NULLIF($2, 0), $3, $4, $5, NULLIF($6, 0), NOW(), NOW())
+		RETURNING created_at, updated_at`
+
+	return r.db.QueryRowContext(ctx, query,
+		template.ID,
+		template.LabID,
+		template.Name,
+		template.Description,
+		template.Body,
+		template.CreatedBy,
+	).Scan(&template.CreatedAt, &template.UpdatedAt)
+}
+
+func (r *FeedbackRepository) GetTemplate(ctx context.Context, id string) (*models.FeedbackTemplate, error) {
+	query := `
+		SELECT ` + templateColumns + `
+		FROM feedback_templates
+		WHERE id = $1`
+
+	return scanTemplate(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r *FeedbackRepository) UpdateTemplate(ctx context.Context, template *models.FeedbackTemplate) error {
+	query := `
+		UPDATE feedback_templates
+		SET name = $2, description = $3, body = $4, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`
+
+	return r.db.QueryRowContext(ctx, query,
+		template.ID,
+		template.Name,
+		template.Description,
+		template.Body,
+	).Scan(&template.UpdatedAt)
+}
+
+func (r *FeedbackRepository) DeleteTemplate(ctx context.Context, id string) error {
+	query := `DELETE FROM feedback_templates WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("template with id %s not found", id)
+	}
+
+	return nil
+}
+
+// ListTemplates returns the templates of a lab followed by global ones when includeGlobal is set,
+// labID 0 lists global templates only
+func (r *FeedbackRepository) ListTemplates(ctx context.Context, labID int64, includeGlobal bool) ([]*models.FeedbackTemplate, error) {
+	query := `
+		SELECT ` + templateColumns + `
+		FROM feedback_templates
+		WHERE COALESCE(lab_id, 0) = $1 OR ($2 AND lab_id IS NULL)
+		ORDER BY lab_id NULLS LAST, name`
+
+	rows, err := r.db.QueryContext(ctx, query, labID, includeGlobal)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []*models.FeedbackTemplate
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+
+	return templates, rows.Err()
+}
+
+func scanTemplate(row rowScanner) (*models.FeedbackTemplate, error) {
+	template := &models.FeedbackTemplate{}
+
+	err := row.Scan(
+		&template.ID,
+		&template.LabID,
+		&template.Name,
+		&template.Description,
+		&template.Body,
+		&template.CreatedBy,
+		&template.CreatedAt,
+		&template.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
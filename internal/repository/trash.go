@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+// TrashFilter selects the trashed feedback returned by ListTrash
+type TrashFilter struct {
+	UserID   int64 // Recipient, zero for all
+	LabID    int64
+	ViewerID int64 // Only feedback the viewer wrote or deleted, zero for all
+}
+
+// SoftDelete moves feedback to the trash, it returns sql.ErrNoRows if it is missing or already trashed
+func (r *FeedbackRepository) SoftDelete(ctx context.Context, id string, deletedBy int64) (*models.FeedbackFile, error) {
+	query := `
+		UPDATE feedback_files
+		SET deleted_at = NOW(), deleted_by = NULLIF($2, 0)
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING ` + feedbackColumns
+
+	return scanFeedback(r.db.QueryRowContext(ctx, query, id, deletedBy))
+}
+
+// GetTrashed returns feedback that is in the trash
+func (r *FeedbackRepository) GetTrashed(ctx context.Context, id string) (*models.FeedbackFile, error) {
+	query := `
+		SELECT ` + feedbackColumns + `
+		FROM feedback_files
+		WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	return scanFeedback(r.db.QueryRowContext(ctx, query, id))
+}
+
+// Restore takes feedback out of the trash, it returns sql.ErrNoRows if it is not trashed
+func (r *FeedbackRepository) Restore(ctx context.Context, id string) (*models.FeedbackFile, error) {
+	query := `
+		UPDATE feedback_files
+		SET deleted_at = NULL, deleted_by = NULL
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING ` + feedbackColumns
+
+	return scanFeedback(r.db.QueryRowContext(ctx, query, id))
+}
+
+// TrashCursor is the position after which a keyset page of the trash starts
+type TrashCursor struct {
+	DeletedAt time.Time
+	ID        string
+}
+
+// ListTrash returns up to limit trashed feedbacks, most recently deleted first
+func (r *FeedbackRepository) ListTrash(ctx context.Context, filter TrashFilter, after *TrashCursor, limit int) ([]*models.FeedbackFile, error) {
+	var feedbacks []*models.FeedbackFile
+	whereClause, args := trashWhere(filter)
+
+	if after != nil {
+		args = append(args, after.DeletedAt, after.ID)
+		whereClause += fmt.Sprintf(" AND (deleted_at, id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT `+feedbackColumns+`
+		FROM feedback_files
+		%s
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT $%d`, whereClause, len(args)+1)
+
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		feedback, err := scanFeedback(rows)
+		if err != nil {
+			return nil, err
+		}
+		feedbacks = append(feedbacks, feedback)
+	}
+
+	return feedbacks, rows.Err()
+}
+
+// CountTrash returns the number of trashed feedbacks matching the filter
+func (r *FeedbackRepository) CountTrash(ctx context.Context, filter TrashFilter) (int, error) {
+	whereClause, args := trashWhere(filter)
+
+	var totalCount int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM feedback_files %s", whereClause)
+	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount)
+
+	return totalCount, err
+}
+
+func trashWhere(filter TrashFilter) (string, []interface{}) {
+	var args []interface{}
+
+	whereClause := "WHERE deleted_at IS NOT NULL"
+
+	if filter.UserID > 0 {
+		args = append(args, filter.UserID)
+		whereClause += fmt.Sprintf(" AND user_id = $%d", len(args))
+	}
+
+	if filter.LabID > 0 {
+		args = append(args, filter.LabID)
+		whereClause += fmt.Sprintf(" AND lab_id = $%d", len(args))
+	}
+
+	if filter.ViewerID > 0 {
+		args = append(args, filter.ViewerID)
+		whereClause += fmt.Sprintf(" AND (author_id = $%d OR deleted_by = $%d)", len(args), len(args))
+	}
+
+	return whereClause, args
+}
+
+// ListExpiredTrash returns IDs of feedback trashed before the cutoff, oldest first
+func (r *FeedbackRepository) ListExpiredTrash(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	query := `
+		SELECT id
+		FROM feedback_files
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1
+		ORDER BY deleted_at
+		LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// InTx runs fn with a repository whose queries share one transaction. The transaction
+// commits when fn returns nil and rolls back otherwise. Calls on a repository that is
+// already inside a transaction join it.
+func (r *FeedbackRepository) InTx(ctx context.Context, fn func(tx *FeedbackRepository) error) error {
+	if _, ok := r.db.(*sql.Tx); ok {
+		return fn(r)
+	}
+
+	tx, err := r.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&FeedbackRepository{db: tx, conn: r.conn}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const webhookColumns = `id, url, secret, event_types, COALESCE(lab_id, 0), enabled, consecutive_failures,
+	disabled_at, COALESCE(created_by, 0), created_at, updated_at`
+
+const deliveryColumns = `id, subscription_id, event_id, event_type, payload, status, attempts,
+	COALESCE(response_code, 0), COALESCE(last_error, ''), next_attempt_at, created_at, completed_at`
+
+// PendingDelivery is a claimed delivery together with the endpoint it goes to
+type PendingDelivery struct {
+	Delivery *models.WebhookDelivery
+	URL      string
+	Secret   string
+}
+
+// WebhookDeliveryCursor is the position after which a keyset page of deliveries starts
+type WebhookDeliveryCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+func (r *FeedbackRepository) CreateWebhook(ctx context.Context, sub *models.WebhookSubscription) error {
+	sub.ID = uuid.New().String()
+
+	query := `
+		INSERT INTO webhook_subscriptions (id, url, secret, event_types, lab_id, enabled, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, 0), $6, NULLIF($7, 0), NOW(), NOW())
+		RETURNING created_at, updated_at`
+
+	return r.db.QueryRowContext(ctx, query,
+		sub.ID,
+		sub.URL,
+		sub.Secret,
+		pq.Array(sub.EventTypes),
+		sub.LabID,
+		sub.Enabled,
+		sub.CreatedBy,
+	).Scan(&sub.CreatedAt, &sub.UpdatedAt)
+}
+
+func (r *FeedbackRepository) GetWebhook(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhook_subscriptions
+		WHERE id = $1`
+
+	return scanWebhook(r.db.QueryRowContext(ctx, query, id))
+}
+
+// UpdateWebhook stores url, secret, event types and the enabled flag. Enabling a
+// subscription clears its failure count, disabling it records when that happened.
+func (r *FeedbackRepository) UpdateWebhook(ctx context.Context, sub *models.WebhookSubscription) error {
+	query := `
+		UPDATE webhook_subscriptions
+		SET url = $2, secret = $3, event_types = $4, enabled = $5,
+			consecutive_failures = CASE WHEN $5 AND NOT enabled THEN 0 ELSE consecutive_failures END,
+			disabled_at = CASE WHEN $5 THEN NULL ELSE COALESCE(disabled_at, NOW()) END,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING consecutive_failures, disabled_at, updated_at`
+
+	return r.db.QueryRowContext(ctx, query,
+		sub.ID,
+		sub.URL,
+		sub.Secret,
+		pq.Array(sub.EventTypes),
+		sub.Enabled,
+	).Scan(&sub.ConsecutiveFailures, &sub.DisabledAt, &sub.UpdatedAt)
+}
+
+func (r *FeedbackRepository) DeleteWebhook(ctx context.Context, id string) error {
+	query := `DELETE FROM webhook_subscriptions WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook with id %s not found", id)
+	}
+
+	return nil
+}
+
+// ListWebhooks returns the subscriptions of a lab, labID 0 lists the ones receiving every lab
+func (r *FeedbackRepository) ListWebhooks(ctx context.Context, labID int64) ([]*models.WebhookSubscription, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhook_subscriptions
+		WHERE COALESCE(lab_id, 0) = $1
+		ORDER BY created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, labID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []*models.WebhookSubscription
+	for rows.Next() {
+		sub, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs, rows.Err()
+}
+
+// EnqueueWebhookDeliveries creates a pending delivery of the event for every enabled subscription
+// matching its lab and type. Events on a draft pass draftAuthorID and only reach subscriptions
+// its author created. Enqueueing the same event again adds nothing.
+func (r *FeedbackRepository) EnqueueWebhookDeliveries(ctx context.Context, event *models.DomainEvent, payload []byte, draftAuthorID int64) (int64, error) {
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, next_attempt_at, created_at)
+		SELECT id, $1, $2, $3, NOW(), NOW()
+		FROM webhook_subscriptions
+		WHERE enabled
+		AND (lab_id IS NULL OR lab_id = $4)
+		AND (cardinality(event_types) = 0 OR $2 = ANY(event_types))
+		AND ($5 = 0 OR created_by = $5)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING`
+
+	result, err := r.db.ExecContext(ctx, query, event.ID, event.Type, payload, event.LabID, draftAuthorID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// ClaimWebhookDeliveries counts an attempt for up to limit due deliveries of enabled subscriptions
+// and hides them from other dispatchers for the lease. A delivery whose outcome is never
+// recorded, because its dispatcher died, is picked up again once the lease expired.
+func (r *FeedbackRepository) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*PendingDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT d.id
+			FROM webhook_deliveries d
+			JOIN webhook_subscriptions s ON s.id = d.subscription_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND s.enabled
+			ORDER BY d.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET attempts = d.attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+		FROM due, webhook_subscriptions s
+		WHERE d.id = due.id AND s.id = d.subscription_id
+		RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+			COALESCE(d.response_code, 0), COALESCE(d.last_error, ''), d.next_attempt_at, d.created_at,
+			d.completed_at, s.url, s.secret`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pending []*PendingDelivery
+	for rows.Next() {
+		delivery := &models.WebhookDelivery{}
+		item := &PendingDelivery{Delivery: delivery}
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.SubscriptionID,
+			&delivery.EventID,
+			&delivery.EventType,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.ResponseCode,
+			&delivery.LastError,
+			&delivery.NextAttemptAt,
+			&delivery.CreatedAt,
+			&delivery.CompletedAt,
+			&item.URL,
+			&item.Secret,
+		)
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, item)
+	}
+
+	return pending, rows.Err()
+}
+
+// RecordWebhookAttempt stores the outcome of a delivery attempt: its status, response code,
+// error and next attempt. A success resets the failure count of the subscription, a failure
+// increments it and disables the subscription once disableAfter consecutive attempts failed
+// (0 never disables). It returns whether the subscription was disabled by this attempt.
+func (r *FeedbackRepository) RecordWebhookAttempt(ctx context.Context, delivery *models.WebhookDelivery, disableAfter int) (bool, error) {
+	disabled := false
+
+	err := r.InTx(ctx, func(tx *FeedbackRepository) error {
+		_, err := tx.db.ExecContext(ctx, `
+			UPDATE webhook_deliveries
+			SET status = $2, response_code = NULLIF($3, 0), last_error = NULLIF($4, ''), next_attempt_at = $5,
+				completed_at = CASE WHEN $2 = 'pending' THEN NULL ELSE NOW() END
+			WHERE id = $1`,
+			delivery.ID, delivery.Status, delivery.ResponseCode, delivery.LastError, delivery.NextAttemptAt)
+		if err != nil {
+			return err
+		}
+
+		if delivery.Status == models.DeliveryStatusSucceeded {
+			_, err := tx.db.ExecContext(ctx,
+				"UPDATE webhook_subscriptions SET consecutive_failures = 0 WHERE id = $1", delivery.SubscriptionID)
+			return err
+		}
+
+		err = tx.db.QueryRowContext(ctx, `
+			UPDATE webhook_subscriptions s
+			SET consecutive_failures = s.consecutive_failures + 1,
+				enabled = s.enabled AND ($2 = 0 OR s.consecutive_failures + 1 < $2),
+				disabled_at = CASE WHEN s.enabled AND $2 > 0 AND s.consecutive_failures + 1 >= $2 THEN NOW() ELSE s.disabled_at END
+			FROM (SELECT id, enabled FROM webhook_subscriptions WHERE id = $1 FOR UPDATE) old
+			WHERE s.id = old.id
+			RETURNING old.enabled AND NOT s.enabled`,
+			delivery.SubscriptionID, disableAfter).Scan(&disabled)
+		if errors.Is(err, sql.ErrNoRows) {
+			// The subscription was deleted while the attempt was running
+			return nil
+		}
+		return err
+	})
+
+	return disabled, err
+}
+
+// ListWebhookDeliveries returns up to limit deliveries of a subscription, newest first
+func (r *FeedbackRepository) ListWebhookDeliveries(ctx context.Context, subscriptionID string, after *WebhookDeliveryCursor, limit int) ([]*models.WebhookDelivery, error) {
+	whereClause := "WHERE subscription_id = $1"
+	args := []interface{}{subscriptionID}
+
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		whereClause += fmt.Sprintf(" AND (created_at, id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d`, whereClause, len(args)+1)
+
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		delivery := &models.WebhookDelivery{}
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.SubscriptionID,
+			&delivery.EventID,
+			&delivery.EventType,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.ResponseCode,
+			&delivery.LastError,
+			&delivery.NextAttemptAt,
+			&delivery.CreatedAt,
+			&delivery.CompletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// CountWebhookDeliveries returns the number of deliveries of a subscription
+func (r *FeedbackRepository) CountWebhookDeliveries(ctx context.Context, subscriptionID string) (int, error) {
+	var totalCount int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM webhook_deliveries WHERE subscription_id = $1", subscriptionID).Scan(&totalCount)
+
+	return totalCount, err
+}
+
+// DeleteCompletedWebhookDeliveries removes deliveries that succeeded or failed before the cutoff
+func (r *FeedbackRepository) DeleteCompletedWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx,
+		"DELETE FROM webhook_deliveries WHERE status <> 'pending' AND completed_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func scanWebhook(row rowScanner) (*models.WebhookSubscription, error) {
+	sub := &models.WebhookSubscription{}
+	var eventTypes pq.StringArray
+
+	err := row.Scan(
+		&sub.ID,
+		&sub.URL,
+		&sub.Secret,
+		&eventTypes,
+		&sub.LabID,
+		&sub.Enabled,
+		&sub.ConsecutiveFailures,
+		&sub.DisabledAt,
+		&sub.CreatedBy,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.EventTypes = eventTypes
+	return sub, nil
+}
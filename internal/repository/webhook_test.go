@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+func TestEnqueueWebhookDeliveriesLimitsDrafts(t *testing.T) {
+	repo, fake := newFakeRepository(t, func(query string, args []driver.Value) fakeResult {
+		return fakeResult{rows: [][]driver.Value{{int64(1)}}}
+	})
+
+	event := &models.DomainEvent{ID: "e1", Type: models.EventFeedbackCreated, LabID: 3}
+	if _, err := repo.EnqueueWebhookDeliveries(context.Background(), event, []byte(`{}`), 7); err != nil {
+		t.Fatal(err)
+	}
+
+	stmt := fake.statements[0]
+	if !strings.Contains(stmt.query, "created_by = $5") {
+		t.Errorf("draft events are not limited to the author's subscriptions: %s", stmt.query)
+	}
+	if got := stmt.args[4]; got != int64(7) {
+		t.Errorf("got draft author %v, want 7", got)
+	}
+}
@@ -0,0 +1,177 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubClamd accepts INSTREAM sessions and answers with reply for the received content
+func stubClamd(t *testing.T, reply func(content []byte) string) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveClamd(t, conn, reply)
+		}
+	}()
+
+	return "tcp://" + listener.Addr().String()
+}
+
+func serveClamd(t *testing.T, conn net.Conn, reply func(content []byte) string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	command, err := r.ReadString(0)
+	if err != nil || command != "zINSTREAM\x00" {
+		t.Errorf("unexpected command %q: %v", command, err)
+		return
+	}
+
+	var content bytes.Buffer
+	for {
+		var size uint32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			t.Errorf("failed to read chunk size: %v", err)
+			return
+		}
+		if size == 0 {
+			break
+		}
+		if _, err := io.CopyN(&content, r, int64(size)); err != nil {
+			t.Errorf("failed to read chunk: %v", err)
+			return
+		}
+	}
+
+	conn.Write([]byte(reply(content.Bytes()) + "\x00"))
+}
+
+func TestClamdScanner(t *testing.T) {
+	var received []byte
+	address := stubClamd(t, func(content []byte) string {
+		received = content
+		if bytes.Contains(content, eicarSignature) {
+			return "stream: Eicar-Test-Signature FOUND"
+		}
+		return "stream: OK"
+	})
+
+	scanner, err := NewClamdScanner(address, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Larger than one chunk to exercise the chunked stream
+	clean := bytes.Repeat([]byte("clean content "), clamdChunkSize/7)
+	result, err := scanner.Scan(context.Background(), bytes.NewReader(clean))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Clean {
+		t.Errorf("clean content reported as %q", result.Signature)
+	}
+	if !bytes.Equal(received, clean) {
+		t.Errorf("daemon received %d bytes, want %d", len(received), len(clean))
+	}
+
+	result, err = scanner.Scan(context.Background(), bytes.NewReader(append([]byte("prefix "), eicarSignature...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Clean || result.Signature != "Eicar-Test-Signature" {
+		t.Errorf("got %+v, want Eicar-Test-Signature", result)
+	}
+}
+
+func TestClamdScannerErrors(t *testing.T) {
+	address := stubClamd(t, func([]byte) string {
+		return "INSTREAM size limit exceeded. ERROR"
+	})
+	scanner, err := NewClamdScanner(address, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scanner.Scan(context.Background(), strings.NewReader("data")); err == nil {
+		t.Error("expected an error for a clamd error reply")
+	}
+
+	// Nothing listens here anymore
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := "tcp://" + listener.Addr().String()
+	listener.Close()
+
+	scanner, err = NewClamdScanner(closed, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scanner.Scan(context.Background(), strings.NewReader("data")); err == nil {
+		t.Error("expected an error for an unreachable daemon")
+	}
+}
+
+func TestNewClamdScannerRejectsInvalidAddresses(t *testing.T) {
+	for _, address := range []string{"localhost:3310", "udp://localhost:3310", "tcp://"} {
+		if _, err := NewClamdScanner(address, time.Second); err == nil {
+			t.Errorf("%q: expected an error", address)
+		}
+	}
+}
+
+func TestParseClamdReply(t *testing.T) {
+	tests := []struct {
+		reply     string
+		clean     bool
+		signature string
+		wantErr   bool
+	}{
+		{"stream: OK", true, "", false},
+		{"stream: Win.Test.EICAR_HDB-1 FOUND", false, "Win.Test.EICAR_HDB-1", false},
+		{"stream: Can't allocate memory ERROR", false, "", true},
+		{"garbage", false, "", true},
+	}
+	for _, tt := range tests {
+		result, err := parseClamdReply(tt.reply)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: got error %v, want error %v", tt.reply, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (result.Clean != tt.clean || result.Signature != tt.signature) {
+			t.Errorf("%q: got %+v", tt.reply, result)
+		}
+	}
+}
+
+func TestSignatureScanner(t *testing.T) {
+	scanner := NewSignatureScanner()
+
+	result, err := scanner.Scan(context.Background(), bytes.NewReader(eicarSignature))
+	if err != nil || result.Clean {
+		t.Errorf("EICAR: got %+v, %v", result, err)
+	}
+
+	result, err = scanner.Scan(context.Background(), strings.NewReader("harmless"))
+	if err != nil || !result.Clean {
+		t.Errorf("harmless content: got %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/events"
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/repository"
+)
+
+// batchItemLease is how long a reserved item may take to be created before a retry may take it over
+const batchItemLease = 5 * time.Minute
+
+var (
+	// ErrInvalidBatch is returned for batches that cannot be executed at all
+	ErrInvalidBatch = errors.New("invalid batch")
+
+	// ErrBatchItemInProgress is returned for items still being created by another attempt of the batch
+	ErrBatchItemInProgress = errors.New("batch item is being created by another request")
+)
+
+// BatchOptions limits batch creation
+type BatchOptions struct {
+	MaxItems    int // Items accepted per batch, 0 for no limit
+	Concurrency int // Items created in parallel
+}
+
+type BatchCreateParams struct {
+	// BatchID identifies the batch of the caller across retries, items already created under it
+	// are not created again
+	BatchID      string
+	TemplateID   string // Renders every item from this template with its Variables
+	AllOrNothing bool   // Roll back every created item if one fails
+	Publish      bool
+	Items        []*BatchItem
+}
+
+type BatchItem struct {
+	Key       string // Unique within the batch, defaults to the recipient
+	UserID    int64
+	LabID     int64
+	Title     string
+	Content   string // Ignored when the batch uses a template
+	Variables map[string]string
+}
+
+type BatchItemResult struct {
+	Key       string
+	Feedback  *models.FeedbackFile
+	Duplicate bool // Created by an earlier attempt of the batch
+	Err       error
+}
+
+type BatchResult struct {
+	Items      []*BatchItemResult
+	Succeeded  int
+	Failed     int
+	RolledBack bool
+}
+
+// preparedItem is a batch item with its final content
+type preparedItem struct {
+	key         string
+	fingerprint string // Identifies the requested item, a retry must request the same
+	params      *CreateFeedbackParams
+}
+
+// BatchCreateFeedback creates feedback for many recipients with bounded concurrency
+func (s *FeedbackService) BatchCreateFeedback(ctx context.Context, params *BatchCreateParams) (*BatchResult, error) {
+	caller := CallerFromContext(ctx)
+	if !caller.IsStaff() {
+		return nil, fmt.Errorf("%w: only instructors can create feedback in batches", ErrPermissionDenied)
+	}
+
+	if params.BatchID == "" {
+		return nil, fmt.Errorf("%w: batch ID is required", ErrInvalidBatch)
+	}
+	if len(params.Items) == 0 {
+		return nil, fmt.Errorf("%w: no items", ErrInvalidBatch)
+	}
+	if s.batch.MaxItems > 0 && len(params.Items) > s.batch.MaxItems {
+		return nil, fmt.Errorf("%w: %d items exceed the limit of %d", ErrInvalidBatch, len(params.Items), s.batch.MaxItems)
+	}
+
+	result := &BatchResult{Items: make([]*BatchItemResult, len(params.Items))}
+	prepared, err := s.prepareBatch(ctx, params, result)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nothing is written when an all-or-nothing batch already fails validation
+	if params.AllOrNothing && countFailed(result) > 0 {
+		for i, item := range result.Items {
+			if item.Err == nil {
+				result.Items[i].Err = fmt.Errorf("not created: another item is invalid")
+			}
+		}
+		result.Failed = len(result.Items)
+		return result, nil
+	}
+
+	// All-or-nothing batches create drafts and publish them once every item exists, so
+	// that a rollback never takes back feedback that students were already told about
+	publishLater := params.AllOrNothing && params.Publish
+	if publishLater {
+		for _, item := range prepared {
+			if item != nil {
+				item.params.Publish = false
+			}
+		}
+	}
+
+	concurrency := s.batch.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	for i, item := range prepared {
+		if item == nil {
+			continue
+		}
+
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, item *preparedItem) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			result.Items[i] = s.createBatchItem(ctx, params.BatchID, item)
+		}(i, item)
+	}
+	wg.Wait()
+
+	switch {
+	case params.AllOrNothing && countFailed(result) > 0:
+		s.rollBackBatch(ctx, params.BatchID, result)
+		result.RolledBack = true
+	case publishLater:
+		s.publishBatch(ctx, result)
+	}
+
+	result.Failed = countFailed(result)
+	result.Succeeded = len(result.Items) - result.Failed
+	return result, nil
+}
+
+// prepareBatch validates items and renders their content before anything is written.
+// Invalid items get a failed result and a nil entry in the returned slice.
+func (s *FeedbackService) prepareBatch(ctx context.Context, params *BatchCreateParams, result *BatchResult) ([]*preparedItem, error) {
+	var tmpl *models.FeedbackTemplate
+	if params.TemplateID != "" {
+		var err error
+		tmpl, err = s.GetTemplate(ctx, params.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	prepared := make([]*preparedItem, len(params.Items))
+	seen := make(map[string]bool, len(params.Items))
+
+	for i, item := range params.Items {
+		// Keying by recipient keeps a retry with reordered items from creating duplicates
+		key := item.Key
+		if key == "" {
+			key = strconv.FormatInt(item.UserID, 10)
+		}
+		result.Items[i] = &BatchItemResult{Key: key}
+
+		if seen[key] {
+			result.Items[i].Err = fmt.Errorf("%w: duplicate item key %q", ErrInvalidBatch, key)
+			continue
+		}
+		seen[key] = true
+
+		content := item.Content
+		title := item.Title
+		if tmpl != nil {
+			if tmpl.LabID != 0 && tmpl.LabID != item.LabID {
+				result.Items[i].Err = fmt.Errorf("%w: %s does not belong to lab %d", ErrTemplateNotFound, tmpl.ID, item.LabID)
+				continue
+			}
+
+			rendered, err := RenderTemplate(tmpl.Body, item.Variables)
+			if err != nil {
+				result.Items[i].Err = err
+				continue
+			}
+			content = rendered
+			if title == "" {
+				title = tmpl.Name
+			}
+		}
+
+		itemParams := &CreateFeedbackParams{
+			UserID:      item.UserID,
+			LabID:       item.LabID,
+			Title:       title,
+			Content:     content,
+			ContentHash: fmt.Sprintf("%x", sha256.Sum256([]byte(content))),
+			Publish:     params.Publish,
+		}
+		fingerprint, err := batchItemFingerprint(itemParams)
+		if err != nil {
+			return nil, err
+		}
+
+		prepared[i] = &preparedItem{
+			key:         key,
+			fingerprint: fingerprint,
+			params:      itemParams,
+		}
+	}
+
+	return prepared, nil
+}
+
+// batchItemFingerprint hashes the feedback an item asks for
+func batchItemFingerprint(params *CreateFeedbackParams) (string, error) {
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode batch item: %w", err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(payload)), nil
+}
+
+// createBatchItem creates one item unless an earlier attempt of the batch already did
+func (s *FeedbackService) createBatchItem(ctx context.Context, batchID string, item *preparedItem) *BatchItemResult {
+	result := &BatchItemResult{Key: item.key}
+	key := repository.BatchItemKey{CallerID: CallerFromContext(ctx).UserID, BatchID: batchID, ItemKey: item.key}
+
+	reservation, err := s.repo.ReserveBatchItem(ctx, key, item.fingerprint, batchItemLease)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to reserve batch item: %w", err)
+		return result
+	}
+	if !reservation.Reserved {
+		if reservation.Fingerprint != "" && reservation.Fingerprint != item.fingerprint {
+			result.Err = fmt.Errorf("%w: batch item %q", ErrIdempotencyKeyReused, item.key)
+			return result
+		}
+		if reservation.FeedbackID == "" {
+			result.Err = ErrBatchItemInProgress
+			return result
+		}
+
+		result.Feedback, err = s.getVisibleFeedback(ctx, reservation.FeedbackID)
+		if errors.Is(err, ErrFeedbackNotFound) {
+			err = fmt.Errorf("%w: %s was created by an earlier attempt and deleted since", ErrFeedbackNotFound, reservation.FeedbackID)
+		}
+		result.Err = err
+		result.Duplicate = err == nil
+		return result
+	}
+
+	feedback, err := s.CreateFeedback(ctx, item.params)
+	if err != nil {
+		if releaseErr := s.repo.ReleaseBatchItem(ctx, key); releaseErr != nil {
+			log.Printf("Failed to release batch item %s/%s: %v", batchID, item.key, releaseErr)
+		}
+		result.Err = err
+		return result
+	}
+
+	if err := s.repo.CompleteBatchItem(ctx, key, feedback.ID); err != nil {
+		result.Err = fmt.Errorf("failed to record batch item: %w", err)
+		if purgeErr := s.discardFeedback(ctx, feedback.ID); purgeErr != nil {
+			log.Printf("Failed to roll back batch item %s/%s: %v", batchID, item.key, purgeErr)
+		}
+		if releaseErr := s.repo.ReleaseBatchItem(ctx, key); releaseErr != nil {
+			log.Printf("Failed to release batch item %s/%s: %v", batchID, item.key, releaseErr)
+		}
+		return result
+	}
+
+	result.Feedback = feedback
+	return result
+}
+
+// publishBatch publishes the drafts of a complete all-or-nothing batch, including drafts left
+// by an earlier attempt that failed before publishing
+func (s *FeedbackService) publishBatch(ctx context.Context, result *BatchResult) {
+	for _, item := range result.Items {
+		if item.Err != nil || item.Feedback.Status != models.StatusDraft {
+			continue
+		}
+
+		published, err := s.PublishFeedback(ctx, item.Feedback.ID)
+		if err != nil {
+			item.Err = fmt.Errorf("created as draft, failed to publish: %w", err)
+			continue
+		}
+		item.Feedback = published
+	}
+}
+
+// rollBackBatch removes feedback created by this attempt, items from earlier attempts are kept.
+// Only drafts are removed, published feedback has already been announced to its recipient.
+func (s *FeedbackService) rollBackBatch(ctx context.Context, batchID string, result *BatchResult) {
+	callerID := CallerFromContext(ctx).UserID
+
+	for _, item := range result.Items {
+		if item.Err != nil || item.Duplicate {
+			continue
+		}
+		if item.Feedback.Status != models.StatusDraft {
+			log.Printf("Keeping published batch item %s/%s", batchID, item.Key)
+			continue
+		}
+
+		if err := s.discardFeedback(ctx, item.Feedback.ID); err != nil {
+			log.Printf("Failed to roll back batch item %s/%s: %v", batchID, item.Key, err)
+		} else {
+			s.publishEvent(ctx, events.TypeDeleted, item.Feedback)
+		}
+		key := repository.BatchItemKey{CallerID: callerID, BatchID: batchID, ItemKey: item.Key}
+		if err := s.repo.ReleaseBatchItem(ctx, key); err != nil {
+			log.Printf("Failed to release batch item %s/%s: %v", batchID, item.Key, err)
+		}
+		item.Feedback = nil
+		item.Err = fmt.Errorf("rolled back: another item failed")
+	}
+}
+
+func countFailed(result *BatchResult) int {
+	failed := 0
+	for _, item := range result.Items {
+		if item.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
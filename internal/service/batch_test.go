@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPrepareBatchKeys(t *testing.T) {
+	s := &FeedbackService{}
+	params := &BatchCreateParams{
+		BatchID: "b1",
+		Items: []*BatchItem{
+			{UserID: 10, LabID: 1, Title: "Lab 1", Content: "a"},
+			{UserID: 11, LabID: 1, Title: "Lab 1", Content: "b"},
+			{UserID: 10, LabID: 1, Title: "Lab 1", Content: "c"},
+			{Key: "extra", UserID: 10, LabID: 1, Title: "Lab 1", Content: "d"},
+		},
+	}
+	result := &BatchResult{Items: make([]*BatchItemResult, len(params.Items))}
+
+	prepared, err := s.prepareBatch(context.Background(), params, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Items are keyed by recipient unless a key is given
+	for i, want := range []string{"10", "11", "10", "extra"} {
+		if result.Items[i].Key != want {
+			t.Errorf("item %d: got key %q, want %q", i, result.Items[i].Key, want)
+		}
+	}
+	if prepared[2] != nil || !errors.Is(result.Items[2].Err, ErrInvalidBatch) {
+		t.Errorf("second item for the same recipient: got %v, want ErrInvalidBatch", result.Items[2].Err)
+	}
+	for _, i := range []int{0, 1, 3} {
+		if prepared[i] == nil || prepared[i].fingerprint == "" {
+			t.Errorf("item %d was not prepared", i)
+		}
+	}
+}
+
+func TestBatchItemFingerprint(t *testing.T) {
+	base := &CreateFeedbackParams{UserID: 10, LabID: 1, Title: "Lab 1", Content: "a", ContentHash: "h", Publish: true}
+
+	fingerprint, err := batchItemFingerprint(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	same := *base
+	if again, _ := batchItemFingerprint(&same); again != fingerprint {
+		t.Error("fingerprint is not stable")
+	}
+
+	for name, edit := range map[string]func(*CreateFeedbackParams){
+		"recipient": func(p *CreateFeedbackParams) { p.UserID = 11 },
+		"title":     func(p *CreateFeedbackParams) { p.Title = "Lab 2" },
+		"content":   func(p *CreateFeedbackParams) { p.Content = "b" },
+		"publish":   func(p *CreateFeedbackParams) { p.Publish = false },
+	} {
+		changed := *base
+		edit(&changed)
+		if other, _ := batchItemFingerprint(&changed); other == fingerprint {
+			t.Errorf("changing the %s keeps the fingerprint", name)
+		}
+	}
+}
+
+func TestBatchCreateFeedbackValidation(t *testing.T) {
+	s := &FeedbackService{batch: BatchOptions{MaxItems: 2}}
+	staff := WithCaller(context.Background(), instructor)
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		params *BatchCreateParams
+		want   error
+	}{
+		{"student", WithCaller(context.Background(), student), &BatchCreateParams{BatchID: "b", Items: []*BatchItem{{}}}, ErrPermissionDenied},
+		{"no batch id", staff, &BatchCreateParams{Items: []*BatchItem{{}}}, ErrInvalidBatch},
+		{"no items", staff, &BatchCreateParams{BatchID: "b"}, ErrInvalidBatch},
+		{"too many items", staff, &BatchCreateParams{BatchID: "b", Items: []*BatchItem{{}, {}, {}}}, ErrInvalidBatch},
+	}
+
+	for _, tt := range tests {
+		if _, err := s.BatchCreateFeedback(tt.ctx, tt.params); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/google/uuid"
+)
+
+// maxBatchGetItems limits the ids accepted by a single BatchGetFeedback call
+const maxBatchGetItems = 100
+
+// BatchGetItem is the outcome for one requested id, Feedback is nil when it was not found
+type BatchGetItem struct {
+	ID       string
+	Feedback *models.FeedbackFile
+	Err      error // Set when the content of a found feedback could not be loaded
+}
+
+// BatchGetFeedback loads several feedbacks with one metadata query and returns them in
+// request order. Ids that do not exist, are trashed or are drafts of another author are
+// reported as not found and content that cannot be downloaded as an item error instead
+// of failing the call.
+func (s *FeedbackService) BatchGetFeedback(ctx context.Context, ids []string, includeContent bool) ([]*BatchGetItem, error) {
+	if len(ids) > maxBatchGetItems {
+		return nil, fmt.Errorf("%w: %d ids exceed the limit of %d", ErrInvalidBatch, len(ids), maxBatchGetItems)
+	}
+
+	// Malformed ids cannot exist and would fail the uuid cast of the whole query,
+	// valid ones are compared in their canonical form as returned by the database
+	var lookup []string
+	canonical := make(map[string]string, len(ids))
+	for _, id := range ids {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			continue
+		}
+		if _, ok := canonical[id]; !ok {
+			canonical[id] = parsed.String()
+			lookup = append(lookup, parsed.String())
+		}
+	}
+
+	var found []*models.FeedbackFile
+	if len(lookup) > 0 {
+		feedbacks, err := s.repo.GetByIDs(ctx, lookup)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get feedback metadata: %w", err)
+		}
+
+		caller := CallerFromContext(ctx)
+		for _, feedback := range feedbacks {
+			if canView(caller, feedback) {
+				found = append(found, feedback)
+			}
+		}
+	}
+
+	if len(found) > 0 {
+		if err := s.attachTags(ctx, found...); err != nil {
+			return nil, err
+		}
+	}
+
+	var contentErrs []error
+	if includeContent && len(found) > 0 {
+		contentErrs = fetchContents(ctx, found, s.loadContent)
+	}
+
+	return batchGetItems(ids, canonical, found, contentErrs), nil
+}
+
+// batchGetItems arranges the found feedbacks in request order, contentErrs[i] is the
+// content error of found[i] and may be nil when no content was loaded
+func batchGetItems(ids []string, canonical map[string]string, found []*models.FeedbackFile, contentErrs []error) []*BatchGetItem {
+	byID := make(map[string]*BatchGetItem, len(found))
+	for i, feedback := range found {
+		item := &BatchGetItem{ID: feedback.ID, Feedback: feedback}
+		if i < len(contentErrs) && contentErrs[i] != nil {
+			log.Printf("Failed to load content of feedback %s: %v", feedback.ID, contentErrs[i])
+			item.Err = contentErrs[i]
+		}
+		byID[feedback.ID] = item
+	}
+
+	items := make([]*BatchGetItem, len(ids))
+	for i, id := range ids {
+		items[i] = &BatchGetItem{ID: id}
+		if found, ok := byID[canonical[id]]; ok {
+			items[i].Feedback = found.Feedback
+			items[i].Err = found.Err
+		}
+	}
+	return items
+}
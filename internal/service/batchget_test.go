@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+func TestBatchGetFeedbackLimits(t *testing.T) {
+	s := &FeedbackService{}
+
+	ids := make([]string, maxBatchGetItems+1)
+	if _, err := s.BatchGetFeedback(context.Background(), ids, false); !errors.Is(err, ErrInvalidBatch) {
+		t.Errorf("got %v, want %v", err, ErrInvalidBatch)
+	}
+
+	// Malformed ids are not found without querying the repository
+	items, err := s.BatchGetFeedback(context.Background(), []string{"42", ""}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range items {
+		if item.Feedback != nil || item.Err != nil {
+			t.Errorf("got %+v, want not found", item)
+		}
+	}
+}
+
+func TestBatchGetItems(t *testing.T) {
+	const (
+		idA = "3f0c8a52-4a7e-4d43-9a7b-1f3f6f0a2b11"
+		idB = "9b1d7c2e-0a43-4f5e-8c1d-2e3f4a5b6c7d"
+		idC = "c5e2f1a0-1b2c-4d3e-9f4a-5b6c7d8e9f00"
+	)
+	upperA := "3F0C8A52-4A7E-4D43-9A7B-1F3F6F0A2B11"
+	canonical := map[string]string{idA: idA, upperA: idA, idB: idB, idC: idC}
+	found := []*models.FeedbackFile{{ID: idB}, {ID: idA}}
+	downloadErr := errors.New("storage unavailable")
+
+	items := batchGetItems([]string{idA, "42", idB, idC, upperA}, canonical, found, []error{downloadErr, nil})
+
+	want := []struct {
+		id    string
+		found bool
+		err   error
+	}{
+		{idA, true, nil},
+		{"42", false, nil},
+		{idB, true, downloadErr},
+		{idC, false, nil},
+		{upperA, true, nil},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(items), len(want))
+	}
+	for i, w := range want {
+		item := items[i]
+		if item.ID != w.id || (item.Feedback != nil) != w.found || item.Err != w.err {
+			t.Errorf("item %d: got %+v, want %+v", i, item, w)
+		}
+	}
+}
+
+func TestFetchContents(t *testing.T) {
+	feedbacks := make([]*models.FeedbackFile, 3*contentFetchConcurrency)
+	for i := range feedbacks {
+		feedbacks[i] = &models.FeedbackFile{ID: fmt.Sprint(i)}
+	}
+
+	var running, peak int32
+	errs := fetchContents(context.Background(), feedbacks, func(ctx context.Context, feedback *models.FeedbackFile) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+
+		if feedback.ID == "1" {
+			return errors.New("storage unavailable")
+		}
+		feedback.Content = "# " + feedback.ID
+		return nil
+	})
+
+	for i, err := range errs {
+		if (err != nil) != (i == 1) {
+			t.Errorf("feedback %d: got %v", i, err)
+		}
+		if i != 1 && feedbacks[i].Content == "" {
+			t.Errorf("feedback %d: content not loaded", i)
+		}
+	}
+	if peak > contentFetchConcurrency {
+		t.Errorf("%d downloads ran at once, limit is %d", peak, contentFetchConcurrency)
+	}
+}
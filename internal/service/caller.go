@@ -0,0 +1,39 @@
+package service
+
+import "context"
+
+// Caller roles recognised by permission checks
+const (
+	RoleStudent    = "student"
+	RoleInstructor = "instructor"
+	RoleAdmin      = "admin"
+)
+
+// Caller identifies the user on whose behalf a request runs
+type Caller struct {
+	UserID int64
+	Role   string
+}
+
+type callerKey struct{}
+
+// WithCaller attaches the calling user to ctx
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext returns the calling user, the zero Caller for anonymous requests
+func CallerFromContext(ctx context.Context) Caller {
+	caller, _ := ctx.Value(callerKey{}).(Caller)
+	return caller
+}
+
+// ValidRole reports whether role is one of the recognised caller roles
+func ValidRole(role string) bool {
+	return role == RoleStudent || role == RoleInstructor || role == RoleAdmin
+}
+
+// IsStaff reports whether the caller may manage feedback written by others
+func (c Caller) IsStaff() bool {
+	return c.Role == RoleInstructor || c.Role == RoleAdmin
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/repository"
+	"github.com/google/uuid"
+)
+
+// maxCommentLength bounds comments in characters
+const maxCommentLength = 10000
+
+var (
+	// ErrInvalidComment is returned for empty or oversized comments
+	ErrInvalidComment = errors.New("invalid comment")
+
+	// ErrCommentNotFound is returned when a reply targets a missing comment or one of another feedback
+	ErrCommentNotFound = errors.New("comment not found")
+)
+
+type AddCommentParams struct {
+	FeedbackID string
+	ParentID   string // Comment answered by a reply, empty for top-level comments
+	Content    string
+}
+
+// AddComment comments on feedback the caller can see. The author of the answered comment
+// is notified of a reply through the comment.created event.
+func (s *FeedbackService) AddComment(ctx context.Context, params *AddCommentParams) (*models.FeedbackComment, error) {
+	caller := CallerFromContext(ctx)
+	if caller.UserID == 0 {
+		return nil, fmt.Errorf("%w: commenting requires an authenticated caller", ErrPermissionDenied)
+	}
+
+	content := strings.TrimSpace(params.Content)
+	if content == "" {
+		return nil, fmt.Errorf("%w: content is required", ErrInvalidComment)
+	}
+	if utf8.RuneCountInString(content) > maxCommentLength {
+		return nil, fmt.Errorf("%w: content exceeds %d characters", ErrInvalidComment, maxCommentLength)
+	}
+
+	feedback, err := s.getVisibleFeedback(ctx, params.FeedbackID)
+	if err != nil {
+		return nil, err
+	}
+
+	var parent *models.FeedbackComment
+	if params.ParentID != "" {
+		parent, err = s.getComment(ctx, feedback.ID, params.ParentID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	comment := &models.FeedbackComment{
+		FeedbackID: feedback.ID,
+		ParentID:   params.ParentID,
+		AuthorID:   caller.UserID,
+		Content:    content,
+	}
+
+	err = s.repo.InTx(ctx, func(tx *repository.FeedbackRepository) error {
+		if err := tx.CreateComment(ctx, comment); err != nil {
+			return fmt.Errorf("failed to create comment: %w", err)
+		}
+
+		return s.recordEvent(ctx, tx, models.EventCommentCreated, feedback, eventData{
+			Feedback: snapshotOf(feedback),
+			Comment:  commentSnapshotOf(comment, parent),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// ListComments returns the comments of feedback the caller can see, oldest first
+func (s *FeedbackService) ListComments(ctx context.Context, feedbackID string) ([]*models.FeedbackComment, error) {
+	feedback, err := s.getVisibleFeedback(ctx, feedbackID)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := s.repo.ListComments(ctx, feedback.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	return comments, nil
+}
+
+// getComment loads a comment of the given feedback, comments of other feedback are not found
+func (s *FeedbackService) getComment(ctx context.Context, feedbackID, id string) (*models.FeedbackComment, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCommentNotFound, id)
+	}
+
+	comment, err := s.repo.GetComment(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrCommentNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comment: %w", err)
+	}
+
+	if comment.FeedbackID != feedbackID {
+		return nil, fmt.Errorf("%w: %s", ErrCommentNotFound, id)
+	}
+	return comment, nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+func TestAddCommentValidation(t *testing.T) {
+	s := &FeedbackService{}
+
+	tests := map[string]struct {
+		caller  Caller
+		content string
+		want    error
+	}{
+		"anonymous":  {anonymous, "Why?", ErrPermissionDenied},
+		"empty":      {student, "", ErrInvalidComment},
+		"whitespace": {student, " \n\t", ErrInvalidComment},
+		"too long":   {student, strings.Repeat("ä", maxCommentLength+1), ErrInvalidComment},
+	}
+	for name, tt := range tests {
+		ctx := WithCaller(context.Background(), tt.caller)
+		_, err := s.AddComment(ctx, &AddCommentParams{FeedbackID: "f1", Content: tt.content})
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", name, err, tt.want)
+		}
+	}
+}
+
+func TestCommentSnapshotNamesParentAuthor(t *testing.T) {
+	comment := &models.FeedbackComment{ID: "c2", ParentID: "c1", AuthorID: 1, Content: "See line 3."}
+
+	snapshot := commentSnapshotOf(comment, &models.FeedbackComment{ID: "c1", AuthorID: 2})
+	if snapshot.ParentID != "c1" || snapshot.ParentAuthorID != 2 || snapshot.AuthorID != 1 {
+		t.Errorf("got %+v, want a reply by 1 to a comment of 2", snapshot)
+	}
+
+	if snapshot := commentSnapshotOf(&models.FeedbackComment{ID: "c1", AuthorID: 2}, nil); snapshot.ParentAuthorID != 0 {
+		t.Errorf("top-level comment has parent author %d", snapshot.ParentAuthorID)
+	}
+}
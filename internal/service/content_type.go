@@ -0,0 +1,26 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Ravwvil/feedback/internal/sniff"
+)
+
+// ErrContentTypeRejected is returned when an asset's content is not acceptable
+var ErrContentTypeRejected = errors.New("content type rejected")
+
+// ResolveAssetType validates the declared content type against the leading bytes of an asset
+// and returns the detected type under which the asset is stored and served
+func (s *FeedbackService) ResolveAssetType(declared string, head []byte) (string, error) {
+	detected := sniff.Detect(head)
+
+	if !sniff.Compatible(declared, head) {
+		return "", fmt.Errorf("%w: declared %s but content is %s", ErrContentTypeRejected, declared, detected)
+	}
+	if !sniff.Allowed(detected, s.allowedTypes) {
+		return "", fmt.Errorf("%w: %s is not allowed", ErrContentTypeRejected, detected)
+	}
+
+	return detected, nil
+}
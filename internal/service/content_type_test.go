@@ -0,0 +1,25 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResolveAssetType(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+	html := []byte("<html><body>hi</body></html>")
+	s := &FeedbackService{allowedTypes: []string{"image/*"}}
+
+	// The detected type is stored, not the declared one
+	got, err := s.ResolveAssetType("application/octet-stream", png)
+	if err != nil || got != "image/png" {
+		t.Fatalf("got %q, %v, want image/png", got, err)
+	}
+
+	if _, err := s.ResolveAssetType("image/png", html); !errors.Is(err, ErrContentTypeRejected) {
+		t.Errorf("mismatching declaration: got %v, want ErrContentTypeRejected", err)
+	}
+	if _, err := s.ResolveAssetType("", html); !errors.Is(err, ErrContentTypeRejected) {
+		t.Errorf("type outside the allow-list: got %v, want ErrContentTypeRejected", err)
+	}
+}
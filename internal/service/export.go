@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/bundle"
+)
+
+// ExportFeedback streams a zip bundle with content.md, clean assets and metadata.json to w.
+// Files are copied from storage one at a time, the archive is never held in memory.
+func (s *FeedbackService) ExportFeedback(ctx context.Context, id string, w io.Writer) error {
+	feedback, err := s.getVisibleFeedback(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.attachTags(ctx, feedback); err != nil {
+		return err
+	}
+
+	assets, err := s.ListAssets(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	metadata := &bundle.Metadata{
+		ID:        feedback.ID,
+		UserID:    feedback.UserID,
+		LabID:     feedback.LabID,
+		Title:     feedback.Title,
+		Status:    feedback.Status,
+		Tags:      feedback.Tags,
+		CreatedAt: feedback.CreatedAt,
+		UpdatedAt: feedback.UpdatedAt,
+		Assets:    []bundle.Asset{},
+	}
+
+	bw := bundle.NewWriter(w)
+
+	_, contentHash, err := s.copyToBundle(ctx, bw, id, bundle.ContentFile, feedback.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	metadata.ContentHash = contentHash
+
+	for _, asset := range assets {
+		// Quarantined assets and files without a scan result are never exported
+		if !scannedClean(asset) {
+			continue
+		}
+
+		assetPath := bundle.AssetsDir + asset.Filename
+		size, hash, err := s.copyToBundle(ctx, bw, id, assetPath, asset.UploadedAt)
+		if err != nil {
+			return err
+		}
+
+		metadata.Assets = append(metadata.Assets, bundle.Asset{
+			Filename:    asset.Filename,
+			ContentType: asset.ContentType,
+			Size:        size,
+			SHA256:      hash,
+		})
+	}
+
+	if err := bw.Close(metadata); err != nil {
+		return fmt.Errorf("failed to finish export: %w", err)
+	}
+
+	return nil
+}
+
+func (s *FeedbackService) copyToBundle(ctx context.Context, bw *bundle.Writer, feedbackID, filePath string, modified time.Time) (int64, string, error) {
+	file, err := s.minioClient.OpenFile(ctx, feedbackID, filePath)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to open %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	size, hash, err := bw.WriteFile(filePath, modified, file)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to export %s: %w", filePath, err)
+	}
+
+	return size, hash, nil
+}
@@ -2,17 +2,105 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"sort"
 	"time"
 
+	"github.com/Ravwvil/feedback/internal/events"
+	"github.com/Ravwvil/feedback/internal/lookup"
+	"github.com/Ravwvil/feedback/internal/markdown"
 	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/pagination"
 	"github.com/Ravwvil/feedback/internal/repository"
+	"github.com/Ravwvil/feedback/internal/scanner"
+	"github.com/Ravwvil/feedback/internal/sniff"
 	"github.com/Ravwvil/feedback/internal/storage"
+	"github.com/Ravwvil/feedback/internal/thumbnail"
+	"github.com/google/uuid"
 )
 
 type FeedbackService struct {
 	repo        *repository.FeedbackRepository
 	minioClient *storage.MinIOClient
+	quotas      QuotaLimits
+	thumbnails  *thumbnail.Generator
+
+	// thumbnailSlots bounds the thumbnails generated at once
+	thumbnailSlots chan struct{}
+
+	allowedTypes []string
+	scanner      scanner.AssetScanner
+
+	renderer    *markdown.Renderer
+	renderCache *markdown.Cache
+	assetURLs   AssetURLOptions
+
+	trashRetention time.Duration
+	batch          BatchOptions
+	idempotencyTTL time.Duration
+
+	eventBus       *events.Bus
+	eventPublisher events.Publisher
+
+	users    lookup.UserService
+	labs     lookup.LabService
+	failOpen bool
+}
+
+// Options configures optional behaviour of FeedbackService
+type Options struct {
+	Quotas QuotaLimits
+
+	// Thumbnails generates image previews after upload, nil disables them
+	Thumbnails *thumbnail.Generator
+
+	// ThumbnailConcurrency is how many images are processed at once, uploads beyond it get no thumbnails
+	ThumbnailConcurrency int
+
+	// AllowedContentTypes restricts detected asset types, entries may use wildcards like "image/*"
+	AllowedContentTypes []string
+
+	// Scanner inspects every uploaded asset for malware, nil marks assets clean immediately
+	Scanner scanner.AssetScanner
+
+	// RenderCacheSize is the number of rendered documents kept in memory
+	RenderCacheSize int
+
+	// AssetURLs controls how asset references are rewritten in rendered HTML
+	AssetURLs AssetURLOptions
+
+	// TrashRetention is how long deleted feedback stays restorable before it is purged
+	TrashRetention time.Duration
+
+	// Batch limits BatchCreateFeedback
+	Batch BatchOptions
+
+	// IdempotencyTTL is how long responses are replayed for a reused idempotency key
+	IdempotencyTTL time.Duration
+
+	// EventBus delivers change events to the watch streams of this instance, nil creates one
+	EventBus *events.Bus
+
+	// EventPublisher distributes change events to all instances, nil publishes to EventBus only
+	EventPublisher events.Publisher
+
+	// Users and Labs validate the ids feedback refers to, nil accepts any id
+	Users lookup.UserService
+	Labs  lookup.LabService
+
+	// FailOpen accepts ids that cannot be validated because a service is down instead of failing
+	FailOpen bool
+}
+
+// AssetURLOptions configures download URLs of assets referenced from markdown
+type AssetURLOptions struct {
+	Mode          string        // AssetURLModeREST or AssetURLModePresigned
+	BaseURL       string        // Prefix of REST download URLs, e.g. "/feedback/files"
+	PresignExpiry time.Duration // Validity of presigned URLs
 }
 
 type CreateFeedbackParams struct {
@@ -21,6 +109,7 @@ type CreateFeedbackParams struct {
 	Title       string
 	Content     string
 	ContentHash string
+	Publish     bool // Skip the draft stage
 }
 
 type UpdateFeedbackParams struct {
@@ -28,141 +117,493 @@ type UpdateFeedbackParams struct {
 	Title       string
 	Content     string
 	ContentHash string
+
+	// UpdateMask names the fields to set, empty values included. Without a mask
+	// only non-empty fields are updated, "*" sets every updatable field.
+	UpdateMask []string
 }
 
 type ListUserFeedbacksParams struct {
-	UserID int64
-	LabID  int64
-	Page   int
-	Limit  int
+	UserID   int64
+	LabID    int64
+	Statuses []string
+	Tags     []string
+	TagMatch string // "any" (default) or "all"
+
+	IncludeContent bool
+
+	PageSize          int
+	PageToken         string
+	IncludeTotalCount bool
 }
 
-func NewFeedbackService(repo *repository.FeedbackRepository, minioClient *storage.MinIOClient) *FeedbackService {
+func NewFeedbackService(repo *repository.FeedbackRepository, minioClient *storage.MinIOClient, opts Options) *FeedbackService {
+	eventBus := opts.EventBus
+	if eventBus == nil {
+		eventBus = events.NewBus(events.DefaultBufferSize)
+	}
+	eventPublisher := opts.EventPublisher
+	if eventPublisher == nil {
+		eventPublisher = eventBus
+	}
+
+	thumbnailConcurrency := opts.ThumbnailConcurrency
+	if thumbnailConcurrency <= 0 {
+		thumbnailConcurrency = 2
+	}
+
 	return &FeedbackService{
 		repo:        repo,
 		minioClient: minioClient,
+		quotas:      opts.Quotas,
+		thumbnails:  opts.Thumbnails,
+
+		thumbnailSlots: make(chan struct{}, thumbnailConcurrency),
+
+		allowedTypes: opts.AllowedContentTypes,
+		scanner:      opts.Scanner,
+
+		renderer:    markdown.NewRenderer(),
+		renderCache: markdown.NewCache(opts.RenderCacheSize),
+		assetURLs:   opts.AssetURLs,
+
+		trashRetention: opts.TrashRetention,
+		batch:          opts.Batch,
+		idempotencyTTL: opts.IdempotencyTTL,
+
+		eventBus:       eventBus,
+		eventPublisher: eventPublisher,
+
+		users:    opts.Users,
+		labs:     opts.Labs,
+		failOpen: opts.FailOpen,
 	}
 }
 
 func (s *FeedbackService) CreateFeedback(ctx context.Context, params *CreateFeedbackParams) (*models.FeedbackFile, error) {
+	// New feedback starts as a draft visible only to its author
+	status := models.StatusDraft
+	if params.Publish {
+		status = models.StatusPublished
+	}
+
+	// A draft without an author could never be seen or published again
+	caller := CallerFromContext(ctx)
+	if status == models.StatusDraft && caller.UserID == 0 {
+		return nil, fmt.Errorf("%w: drafts need an authenticated author", ErrPermissionDenied)
+	}
+
+	if err := s.validateReferences(ctx, params.UserID, params.LabID); err != nil {
+		return nil, err
+	}
+
 	feedback := &models.FeedbackFile{
 		UserID:      params.UserID,
 		LabID:       params.LabID,
 		Title:       params.Title,
 		Content:     params.Content,
 		ContentHash: params.ContentHash,
+		Status:      status,
+		AuthorID:    caller.UserID,
 	}
 
-	// Save metadata to database
-	err := s.repo.Create(ctx, feedback)
+	// Save content to MinIO first, the record only exists once its content does
+	feedback.ID = uuid.New().String()
+	err := s.minioClient.UploadFile(ctx, feedback.ID, "content.md", "text/markdown", []byte(params.Content))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create feedback in database: %w", err)
+		return nil, fmt.Errorf("failed to upload content to storage: %w", err)
 	}
 
-	// Save content to MinIO
-	err = s.minioClient.UploadFile(ctx, feedback.ID, "content.md", "text/markdown", []byte(params.Content))
+	err = s.repo.InTx(ctx, func(tx *repository.FeedbackRepository) error {
+		// Save metadata to database
+		if err := tx.Create(ctx, feedback); err != nil {
+			return fmt.Errorf("failed to create feedback in database: %w", err)
+		}
+
+		return s.recordEvent(ctx, tx, models.EventFeedbackCreated, feedback, eventData{Feedback: snapshotOf(feedback)})
+	})
 	if err != nil {
-		// Rollback database record if MinIO upload fails
-		s.repo.Delete(ctx, feedback.ID)
-		return nil, fmt.Errorf("failed to upload content to storage: %w", err)
+		// Without a record the uploaded content can never be reached
+		if deleteErr := s.minioClient.DeleteFolder(context.WithoutCancel(ctx), feedback.ID); deleteErr != nil {
+			log.Printf("Failed to delete content of uncreated feedback %s: %v", feedback.ID, deleteErr)
+		}
+		return nil, err
+	}
+
+	feedback.Warnings, err = s.assetReferenceWarnings(ctx, feedback.ID, feedback.Content)
+	if err != nil {
+		return nil, err
 	}
 
+	s.publishEvent(ctx, events.TypeCreated, feedback)
 	return feedback, nil
 }
 
-func (s *FeedbackService) GetFeedback(ctx context.Context, id string) (*models.FeedbackFile, error) {
+// GetFeedback returns feedback metadata, the content is only loaded from storage when includeContent is set
+func (s *FeedbackService) GetFeedback(ctx context.Context, id string, includeContent bool) (*models.FeedbackFile, error) {
 	// Get metadata from database
-	feedback, err := s.repo.GetByID(ctx, id)
+	feedback, err := s.getVisibleFeedback(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get feedback metadata: %w", err)
+		return nil, err
 	}
 
 	// Get content from MinIO
-	content, err := s.minioClient.DownloadFile(ctx, id, "content.md")
-	if err != nil {
-		return nil, fmt.Errorf("failed to download content from storage: %w", err)
+	if includeContent {
+		if err := s.loadContent(ctx, feedback); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.attachTags(ctx, feedback); err != nil {
+		return nil, err
 	}
 
-	feedback.Content = string(content)
 	return feedback, nil
 }
 
 func (s *FeedbackService) UpdateFeedback(ctx context.Context, params *UpdateFeedbackParams) (*models.FeedbackFile, error) {
+	setTitle, setContent, err := updatedFields(params)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get existing feedback
-	feedback, err := s.repo.GetByID(ctx, params.ID)
+	feedback, err := s.getEditableFeedback(ctx, params.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get existing feedback: %w", err)
+		return nil, err
 	}
 
 	// Update fields if provided
-	if params.Title != "" {
+	var changed []string
+	if setTitle {
 		feedback.Title = params.Title
+		changed = append(changed, FieldTitle)
 	}
-	if params.Content != "" {
+	if setContent {
 		feedback.Content = params.Content
 		feedback.ContentHash = params.ContentHash
+		changed = append(changed, FieldContent)
 	}
 
-	// Update database
-	err = s.repo.Update(ctx, feedback)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update feedback in database: %w", err)
-	}
+	// Update content in MinIO before the database, the previous content is kept so that it
+	// can be put back when the database update fails
+	var previousContent []byte
+	if setContent {
+		previousContent, err = s.minioClient.DownloadFile(ctx, feedback.ID, "content.md")
+		if err != nil {
+			return nil, fmt.Errorf("failed to download content from storage: %w", err)
+		}
 
-	// Update content in MinIO if provided
-	if params.Content != "" {
 		err = s.minioClient.UploadFile(ctx, feedback.ID, "content.md", "text/markdown", []byte(params.Content))
 		if err != nil {
 			return nil, fmt.Errorf("failed to update content in storage: %w", err)
 		}
 	}
 
+	err = s.repo.InTx(ctx, func(tx *repository.FeedbackRepository) error {
+		// Update database
+		if err := tx.Update(ctx, feedback); err != nil {
+			return fmt.Errorf("failed to update feedback in database: %w", err)
+		}
+
+		return s.recordEvent(ctx, tx, models.EventFeedbackUpdated, feedback, eventData{
+			Feedback:      snapshotOf(feedback),
+			ChangedFields: changed,
+		})
+	})
+	if err != nil {
+		if setContent {
+			restoreErr := s.minioClient.UploadFile(context.WithoutCancel(ctx), feedback.ID, "content.md", "text/markdown", previousContent)
+			if restoreErr != nil {
+				log.Printf("Failed to restore content of feedback %s: %v", feedback.ID, restoreErr)
+			}
+		}
+		return nil, err
+	}
+
+	if setContent {
+		feedback.Warnings, err = s.assetReferenceWarnings(ctx, feedback.ID, params.Content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.publishEvent(ctx, events.TypeUpdated, feedback)
 	return feedback, nil
 }
 
+// DeleteFeedback moves feedback to the trash, it is purged after the retention period
 func (s *FeedbackService) DeleteFeedback(ctx context.Context, id string) error {
-	// Delete from MinIO first (folder and all assets)
-	err := s.minioClient.DeleteFolder(ctx, id)
+	feedback, err := s.getVisibleFeedback(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	caller := CallerFromContext(ctx)
+	if !canEdit(caller, feedback) {
+		return fmt.Errorf("%w: cannot delete feedback %s", ErrPermissionDenied, id)
+	}
+
+	var deleted *models.FeedbackFile
+	err = s.repo.InTx(ctx, func(tx *repository.FeedbackRepository) error {
+		var err error
+		deleted, err = tx.SoftDelete(ctx, id, caller.UserID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", ErrFeedbackNotFound, id)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to move feedback to trash: %w", err)
+		}
+
+		return s.recordEvent(ctx, tx, models.EventFeedbackDeleted, deleted, eventData{Feedback: snapshotOf(deleted)})
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete content from storage: %w", err)
+		return err
 	}
 
-	// Delete from database
-	err = s.repo.Delete(ctx, id)
+	s.publishEvent(ctx, events.TypeDeleted, deleted)
+	return nil
+}
+
+// purgeFeedback permanently removes trashed feedback together with its content and assets
+func (s *FeedbackService) purgeFeedback(ctx context.Context, id string) error {
+	// Delete asset metadata, tags, comments, score, batch items and feedback from database. The row goes
+	// first, a feedback restored in the meantime is no longer in the trash and stays untouched.
+	err := s.repo.InTx(ctx, func(tx *repository.FeedbackRepository) error {
+		err := tx.DeleteAssetsByFeedbackID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete asset metadata: %w", err)
+		}
+
+		err = tx.DeleteTagsByFeedbackID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete feedback tags: %w", err)
+		}
+
+		err = tx.DeleteCommentsByFeedbackID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete feedback comments: %w", err)
+		}
+
+		err = tx.DeleteScoreByFeedbackID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete feedback score: %w", err)
+		}
+
+		err = tx.DeleteBatchItemsByFeedbackID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete batch items: %w", err)
+		}
+
+		feedback, err := tx.Delete(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete feedback from database: %w", err)
+		}
+
+		return s.recordEvent(ctx, tx, models.EventFeedbackPurged, feedback, eventData{})
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete feedback from database: %w", err)
+		return err
 	}
 
+	// Delete from MinIO afterwards (folder and all assets), a leftover folder is unreachable
+	if err := s.minioClient.DeleteFolder(ctx, id); err != nil {
+		log.Printf("Failed to delete storage of purged feedback %s: %v", id, err)
+	}
 	return nil
 }
 
-func (s *FeedbackService) ListUserFeedbacks(ctx context.Context, params *ListUserFeedbacksParams) ([]*models.FeedbackFile, int, error) {
-	// Set default pagination
-	if params.Limit <= 0 {
-		params.Limit = 20
+// discardFeedback purges feedback that never reached the trash, e.g. a failed import
+func (s *FeedbackService) discardFeedback(ctx context.Context, id string) error {
+	_, err := s.repo.SoftDelete(ctx, id, CallerFromContext(ctx).UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to move feedback to trash: %w", err)
+	}
+	return s.purgeFeedback(ctx, id)
+}
+
+func (s *FeedbackService) ListUserFeedbacks(ctx context.Context, params *ListUserFeedbacksParams) (*Page[*models.FeedbackFile], error) {
+	for _, status := range params.Statuses {
+		if !ValidStatus(status) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidStatus, status)
+		}
+	}
+
+	tags, err := normalizeTags(params.Tags)
+	if err != nil {
+		return nil, err
+	}
+	tagMatch, err := normalizeTagMatch(params.TagMatch)
+	if err != nil {
+		return nil, err
 	}
-	if params.Page <= 0 {
-		params.Page = 1
+
+	if err := s.validateUsers(ctx, params.UserID); err != nil {
+		return nil, err
+	}
+	if params.LabID > 0 {
+		if err := s.validateLabs(ctx, params.LabID); err != nil {
+			return nil, err
+		}
 	}
 
-	offset := (params.Page - 1) * params.Limit
+	filter := repository.FeedbackFilter{
+		UserIDs:  []int64{params.UserID},
+		Statuses: params.Statuses,
+		Tags:     tags,
+		TagMatch: tagMatch,
+		ViewerID: CallerFromContext(ctx).UserID,
+	}
+	if params.LabID > 0 {
+		filter.LabIDs = []int64{params.LabID}
+	}
 
-	return s.repo.ListByUserID(ctx, params.UserID, params.LabID, offset, params.Limit)
+	sort := repository.FeedbackSort{Field: repository.SortByCreatedAt}
+	return s.listFeedbacks(ctx, filter, sort, params.IncludeContent, params.PageSize, params.PageToken, params.IncludeTotalCount)
 }
 
-func (s *FeedbackService) UploadAsset(ctx context.Context, feedbackID, filename, contentType string, data []byte) (int64, error) {
+func (s *FeedbackService) UploadAsset(ctx context.Context, feedbackID, filename, contentType string, data []byte) (*models.AssetInfo, error) {
 	assetPath := fmt.Sprintf("assets/%s", filename)
-	
-	err := s.minioClient.UploadFile(ctx, feedbackID, assetPath, contentType, data)
+	size := int64(len(data))
+
+	// Never trust the client-supplied type, store the one detected from content
+	contentType, err := s.ResolveAssetType(contentType, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkAssetSize(size); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.getEditableFeedback(ctx, feedbackID); err != nil {
+		return nil, err
+	}
+
+	// The asset is scanned before anything is written, so that its metadata, scan result
+	// and event commit together
+	status, signature := s.scanAsset(ctx, feedbackID, filename, data)
+	asset := &models.AssetInfo{
+		Filename:    filename,
+		Size:        size,
+		ContentType: contentType,
+		ScanStatus:  status,
+		ContentHash: fmt.Sprintf("%x", sha256.Sum256(data)),
+	}
+
+	// The content goes to a staging path first and only replaces the stored asset once its
+	// metadata committed, so that the bytes behind an existing scan result never change
+	stagingPath := fmt.Sprintf("staging/%s", uuid.New().String())
+	if err := s.minioClient.UploadFile(ctx, feedbackID, stagingPath, contentType, data); err != nil {
+		return nil, fmt.Errorf("failed to upload asset: %w", err)
+	}
+
+	// Quotas are checked and the metadata written under the user's quota lock, so that
+	// concurrent uploads cannot together exceed a limit
+	var ownerID int64
+	err = s.repo.InTx(ctx, func(tx *repository.FeedbackRepository) error {
+		feedback, err := tx.GetByID(ctx, feedbackID)
+		if err != nil {
+			return fmt.Errorf("failed to get feedback metadata: %w", err)
+		}
+		ownerID = feedback.UserID
+		if err := tx.LockAssetQuota(ctx, feedback.UserID); err != nil {
+			return fmt.Errorf("failed to lock asset quota: %w", err)
+		}
+		if err := s.checkAssetQuota(ctx, tx, feedback, filename, size); err != nil {
+			return err
+		}
+
+		if err := tx.UpsertAsset(ctx, feedbackID, asset); err != nil {
+			return fmt.Errorf("failed to record asset metadata: %w", err)
+		}
+		if err := tx.SetAssetScanStatus(ctx, feedbackID, filename, status, signature); err != nil {
+			return fmt.Errorf("failed to record scan status: %w", err)
+		}
+		return s.recordEvent(ctx, tx, models.EventAssetUploaded, feedback, eventData{Asset: asset})
+	})
+	if err != nil {
+		if deleteErr := s.minioClient.DeleteFile(context.WithoutCancel(ctx), feedbackID, stagingPath); deleteErr != nil {
+			log.Printf("Failed to delete staged asset %s/%s: %v", feedbackID, stagingPath, deleteErr)
+		}
+		return nil, err
+	}
+
+	placed, err := s.placeStagedAsset(ctx, ownerID, feedbackID, stagingPath, assetPath, asset)
 	if err != nil {
-		return 0, fmt.Errorf("failed to upload asset: %w", err)
+		return nil, err
+	}
+
+	if placed && status == models.ScanStatusClean && s.thumbnails != nil && s.thumbnails.Supports(contentType) {
+		s.startThumbnails(feedbackID, filename, asset.ContentHash, data)
+	}
+
+	s.publishAssetEvent(ctx, feedbackID, filename)
+	return asset, nil
+}
+
+// placeStagedAsset moves an uploaded asset from its staging path into place once its metadata
+// committed. Moves hold the quota lock like metadata writes, so the stored bytes always belong to
+// the latest committed metadata; an upload replaced meanwhile by a newer one is dropped and
+// reported as not placed.
+func (s *FeedbackService) placeStagedAsset(ctx context.Context, ownerID int64, feedbackID, stagingPath, assetPath string, asset *models.AssetInfo) (bool, error) {
+	placed := false
+	err := s.repo.InTx(ctx, func(tx *repository.FeedbackRepository) error {
+		if err := tx.LockAssetQuota(ctx, ownerID); err != nil {
+			return fmt.Errorf("failed to lock asset quota: %w", err)
+		}
+		current, err := tx.GetAsset(ctx, feedbackID, asset.Filename)
+		if err != nil {
+			return fmt.Errorf("failed to get asset metadata: %w", err)
+		}
+		if current.ContentHash != asset.ContentHash {
+			return nil
+		}
+
+		if err := s.minioClient.MoveFile(ctx, feedbackID, stagingPath, assetPath); err != nil {
+			return fmt.Errorf("failed to store asset: %w", err)
+		}
+		placed = true
+		return nil
+	})
+	if placed {
+		// Nothing was written in the transaction, the move stands even if its commit failed
+		return true, nil
 	}
 
-	return int64(len(data)), nil
+	if deleteErr := s.minioClient.DeleteFile(context.WithoutCancel(ctx), feedbackID, stagingPath); deleteErr != nil {
+		log.Printf("Failed to delete staged asset %s/%s: %v", feedbackID, stagingPath, deleteErr)
+	}
+	if err != nil {
+		// The committed scan result may not describe the stored bytes, keep the asset
+		// quarantined until the rescan job has scanned what is actually stored
+		if requeueErr := s.repo.RequeueAssetScan(context.WithoutCancel(ctx), feedbackID, asset.Filename, asset.ContentHash); requeueErr != nil {
+			log.Printf("Failed to requeue scan of asset %s/%s: %v", feedbackID, asset.Filename, requeueErr)
+		}
+		return false, err
+	}
+	return false, nil
 }
 
 func (s *FeedbackService) DownloadAsset(ctx context.Context, feedbackID, filename string) (*models.AssetInfo, []byte, error) {
 	assetPath := fmt.Sprintf("assets/%s", filename)
+
+	if _, err := s.getVisibleFeedback(ctx, feedbackID); err != nil {
+		return nil, nil, err
+	}
+
+	// Assets without a clean scan result must not reach other users, including
+	// files in storage that have no recorded scan at all
+	record, err := s.repo.GetAsset(ctx, feedbackID, filename)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, fmt.Errorf("%w: no scan result", ErrAssetQuarantined)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get asset metadata: %w", err)
+	}
+	if !scannedClean(record) {
+		return nil, nil, fmt.Errorf("%w: scan status is %s", ErrAssetQuarantined, record.ScanStatus)
+	}
 	
 	data, err := s.minioClient.DownloadFile(ctx, feedbackID, assetPath)
 	if err != nil {
@@ -175,22 +616,86 @@ func (s *FeedbackService) DownloadAsset(ctx context.Context, feedbackID, filenam
 		return nil, nil, fmt.Errorf("failed to get asset info: %w", err)
 	}
 
+	// Serve the detected type, assets stored before sniffing may carry a client-supplied one
 	assetInfo := &models.AssetInfo{
 		Filename:    filename,
 		Size:        info.Size,
-		ContentType: info.ContentType,
+		ContentType: sniff.Detect(data),
 		UploadedAt:  info.LastModified,
 	}
 
 	return assetInfo, data, nil
 }
 
+type ListAssetsParams struct {
+	FeedbackID string
+
+	PageSize          int
+	PageToken         string
+	IncludeTotalCount bool
+}
+
+// ListAssetsPage lists the assets of a feedback ordered by filename
+func (s *FeedbackService) ListAssetsPage(ctx context.Context, params *ListAssetsParams) (*Page[*models.AssetInfo], error) {
+	query := pagination.QueryFingerprint("assets", params.FeedbackID)
+	position, err := pagination.Decode(params.PageToken, query, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	// MinIO lists the whole folder anyway, so the page is cut from the sorted listing
+	assets, err := s.ListAssets(ctx, params.FeedbackID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(assets, func(i, j int) bool {
+		return assets[i].Filename < assets[j].Filename
+	})
+
+	start := 0
+	if position != nil {
+		start = sort.Search(len(assets), func(i int) bool {
+			return assets[i].Filename > position[0]
+		})
+	}
+
+	pageSize := pagination.PageSize(params.PageSize)
+	end := start + pageSize + 1
+	if end > len(assets) {
+		end = len(assets)
+	}
+
+	page := newPage(assets[start:end], pageSize, query, func(asset *models.AssetInfo) []string {
+		return []string{asset.Filename}
+	})
+	if params.IncludeTotalCount {
+		page.TotalCount = len(assets)
+	}
+
+	return page, nil
+}
+
+// ListAssets returns all assets of a feedback
 func (s *FeedbackService) ListAssets(ctx context.Context, feedbackID string) ([]*models.AssetInfo, error) {
+	if _, err := s.getVisibleFeedback(ctx, feedbackID); err != nil {
+		return nil, err
+	}
+
 	files, err := s.minioClient.ListFiles(ctx, feedbackID, "assets/")
 	if err != nil {
 		return nil, fmt.Errorf("failed to list assets: %w", err)
 	}
 
+	// Thumbnail availability and scan state are only tracked in the database
+	records, err := s.repo.ListAssets(ctx, feedbackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list asset metadata: %w", err)
+	}
+	recordsByName := make(map[string]*models.AssetInfo, len(records))
+	for _, record := range records {
+		recordsByName[record.Filename] = record
+	}
+
 	assets := make([]*models.AssetInfo, len(files))
 	for i, file := range files {
 		assets[i] = &models.AssetInfo{
@@ -199,6 +704,10 @@ func (s *FeedbackService) ListAssets(ctx context.Context, feedbackID string) ([]
 			ContentType: file.ContentType,
 			UploadedAt:  file.LastModified,
 		}
+		if record, ok := recordsByName[file.Filename]; ok {
+			assets[i].ThumbnailSizes = record.ThumbnailSizes
+			assets[i].ScanStatus = record.ScanStatus
+		}
 	}
 
 	return assets, nil
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+// contentFetchConcurrency limits parallel content downloads when a list includes content
+const contentFetchConcurrency = 8
+
+// Paths accepted in an update mask
+const (
+	FieldTitle   = "title"
+	FieldContent = "content"
+)
+
+// ErrInvalidFieldMask is returned for masks naming unknown or read-only fields
+var ErrInvalidFieldMask = errors.New("invalid field mask")
+
+// updatedFields resolves which fields an update sets
+func updatedFields(params *UpdateFeedbackParams) (title, content bool, err error) {
+	if len(params.UpdateMask) == 0 {
+		return params.Title != "", params.Content != "", nil
+	}
+
+	for _, path := range params.UpdateMask {
+		switch path {
+		case "*":
+			title, content = true, true
+		case FieldTitle:
+			title = true
+		case FieldContent:
+			content = true
+		default:
+			return false, false, fmt.Errorf("%w: %q cannot be updated", ErrInvalidFieldMask, path)
+		}
+	}
+
+	return title, content, nil
+}
+
+// loadContent downloads the markdown content of a feedback from storage
+func (s *FeedbackService) loadContent(ctx context.Context, feedback *models.FeedbackFile) error {
+	content, err := s.minioClient.DownloadFile(ctx, feedback.ID, "content.md")
+	if err != nil {
+		return fmt.Errorf("failed to download content from storage: %w", err)
+	}
+
+	feedback.Content = string(content)
+	return nil
+}
+
+// loadContents downloads the content of several feedbacks concurrently and fails on the first error
+func (s *FeedbackService) loadContents(ctx context.Context, feedbacks []*models.FeedbackFile) error {
+	for _, err := range fetchContents(ctx, feedbacks, s.loadContent) {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchContents runs load for every feedback with bounded parallelism, errs[i] is the outcome of feedbacks[i]
+func fetchContents(ctx context.Context, feedbacks []*models.FeedbackFile, load func(context.Context, *models.FeedbackFile) error) []error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(feedbacks))
+
+	slots := make(chan struct{}, contentFetchConcurrency)
+	for i, feedback := range feedbacks {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, feedback *models.FeedbackFile) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			errs[i] = load(ctx, feedback)
+		}(i, feedback)
+	}
+	wg.Wait()
+
+	return errs
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUpdatedFields(t *testing.T) {
+	tests := []struct {
+		name           string
+		params         UpdateFeedbackParams
+		title, content bool
+		err            error
+	}{
+		{"no mask sets non-empty fields", UpdateFeedbackParams{Title: "Lab 1"}, true, false, nil},
+		{"no mask ignores empty fields", UpdateFeedbackParams{}, false, false, nil},
+		{"mask clears the title", UpdateFeedbackParams{UpdateMask: []string{FieldTitle}}, true, false, nil},
+		{"mask empties the content", UpdateFeedbackParams{Title: "Lab 1", UpdateMask: []string{FieldContent}}, false, true, nil},
+		{"wildcard", UpdateFeedbackParams{UpdateMask: []string{"*"}}, true, true, nil},
+		{"both paths", UpdateFeedbackParams{UpdateMask: []string{FieldTitle, FieldContent}}, true, true, nil},
+		{"read-only field", UpdateFeedbackParams{UpdateMask: []string{"status"}}, false, false, ErrInvalidFieldMask},
+		{"unknown field", UpdateFeedbackParams{UpdateMask: []string{FieldTitle, "author"}}, false, false, ErrInvalidFieldMask},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, content, err := updatedFields(&tt.params)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if title != tt.title || content != tt.content {
+				t.Errorf("got title %v content %v, want %v %v", title, content, tt.title, tt.content)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+var (
+	// ErrIdempotencyKeyReused is returned when a key is replayed with a different request payload
+	ErrIdempotencyKeyReused = errors.New("idempotency key reused with a different request")
+
+	// ErrIdempotencyInProgress is returned while the first request with a key has not finished
+	ErrIdempotencyInProgress = errors.New("request with this idempotency key is in progress")
+
+	// ErrIdempotencyAnonymous is returned for idempotency keys sent without an authenticated caller,
+	// keys are scoped per caller and all anonymous callers would share them
+	ErrIdempotencyAnonymous = errors.New("idempotency keys require an authenticated caller")
+)
+
+// idempotencyLease is how long a request may hold its key without a response before a retry may
+// take the key over
+const idempotencyLease = 5 * time.Minute
+
+// BeginIdempotentRequest claims an idempotency key for a request with the given fingerprint.
+// It returns the stored response when the request was already completed under the key.
+func (s *FeedbackService) BeginIdempotentRequest(ctx context.Context, method, key, fingerprint string) ([]byte, error) {
+	caller := CallerFromContext(ctx)
+	if caller.UserID == 0 {
+		return nil, ErrIdempotencyAnonymous
+	}
+
+	record := &models.IdempotencyRecord{
+		CallerID:    caller.UserID,
+		Method:      method,
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   time.Now().Add(s.idempotencyTTL),
+	}
+
+	existing, err := s.repo.ReserveIdempotencyKey(ctx, record, idempotencyLease)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if existing == nil {
+		return nil, nil
+	}
+
+	if existing.Fingerprint != fingerprint {
+		return nil, fmt.Errorf("%w: %s", ErrIdempotencyKeyReused, key)
+	}
+	if existing.Response == nil {
+		return nil, fmt.Errorf("%w: %s", ErrIdempotencyInProgress, key)
+	}
+
+	return existing.Response, nil
+}
+
+// CompleteIdempotentRequest stores the response replayed for retries of a claimed key
+func (s *FeedbackService) CompleteIdempotentRequest(ctx context.Context, method, key string, response []byte) error {
+	err := s.repo.CompleteIdempotencyKey(ctx, CallerFromContext(ctx).UserID, method, key, response)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// ReleaseIdempotentRequest frees a claimed key after a failed request so the client can retry it
+func (s *FeedbackService) ReleaseIdempotentRequest(ctx context.Context, method, key string) error {
+	err := s.repo.ReleaseIdempotencyKey(ctx, CallerFromContext(ctx).UserID, method, key)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// RunIdempotencyCleanup deletes expired idempotency keys every interval until ctx is cancelled
+func (s *FeedbackService) RunIdempotencyCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.repo.DeleteExpiredIdempotencyKeys(ctx); err != nil {
+				log.Printf("Failed to delete expired idempotency keys: %v", err)
+			}
+		}
+	}
+}
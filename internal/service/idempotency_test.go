@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestBeginIdempotentRequestRequiresCaller(t *testing.T) {
+	s := &FeedbackService{}
+
+	_, err := s.BeginIdempotentRequest(context.Background(), "m", "k", "abc")
+	if !errors.Is(err, ErrIdempotencyAnonymous) {
+		t.Errorf("got %v, want %v", err, ErrIdempotencyAnonymous)
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/Ravwvil/feedback/internal/bundle"
+	"github.com/Ravwvil/feedback/internal/events"
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/repository"
+)
+
+type importedAsset struct {
+	filename    string
+	contentType string
+	data        []byte
+}
+
+// ImportBundle validates a zip bundle and creates a feedback from it with its original timestamps.
+// With dryRun set the bundle is only validated and nothing is persisted.
+func (s *FeedbackService) ImportBundle(ctx context.Context, r io.ReaderAt, size int64, dryRun bool) (*models.FeedbackFile, error) {
+	// Bundles name any student and carry their own timestamps, the caller becomes the author
+	caller := CallerFromContext(ctx)
+	if !caller.IsStaff() {
+		return nil, fmt.Errorf("%w: only instructors can import feedback", ErrPermissionDenied)
+	}
+
+	reader, err := bundle.Open(r, size, s.quotas.MaxAssetSize)
+	if err != nil {
+		return nil, err
+	}
+	metadata := reader.Metadata
+
+	// Validate every asset before anything is written
+	if err := s.checkBundleQuota(metadata); err != nil {
+		return nil, err
+	}
+
+	assets := make([]importedAsset, len(metadata.Assets))
+	for i, asset := range metadata.Assets {
+		data, err := reader.ReadAsset(asset, s.quotas.MaxAssetSize)
+		if err != nil {
+			return nil, err
+		}
+
+		contentType, err := s.ResolveAssetType(asset.ContentType, data)
+		if err != nil {
+			return nil, fmt.Errorf("asset %q: %w", asset.Filename, err)
+		}
+
+		assets[i] = importedAsset{filename: asset.Filename, contentType: contentType, data: data}
+	}
+
+	feedback := &models.FeedbackFile{
+		UserID:      metadata.UserID,
+		LabID:       metadata.LabID,
+		Title:       metadata.Title,
+		Content:     string(reader.Content),
+		ContentHash: fmt.Sprintf("%x", sha256.Sum256(reader.Content)),
+		Status:      metadata.Status,
+		AuthorID:    caller.UserID,
+		CreatedAt:   metadata.CreatedAt,
+		UpdatedAt:   metadata.UpdatedAt,
+	}
+
+	// Bundles without a status predate the lifecycle and were visible to students
+	if feedback.Status != "" && !ValidStatus(feedback.Status) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidStatus, feedback.Status)
+	}
+
+	feedback.Tags, err = normalizeTags(metadata.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateReferences(ctx, feedback.UserID, feedback.LabID); err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return feedback, nil
+	}
+
+	// A failed import purges the feedback again, which is announced as well
+	err = s.repo.InTx(ctx, func(tx *repository.FeedbackRepository) error {
+		if err := tx.CreateImported(ctx, feedback); err != nil {
+			return fmt.Errorf("failed to create feedback in database: %w", err)
+		}
+		return s.recordEvent(ctx, tx, models.EventFeedbackCreated, feedback, eventData{
+			Feedback: snapshotOf(feedback),
+			Imported: true,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.importFiles(ctx, feedback, assets)
+	if err != nil {
+		// Do not leave partially imported feedback behind
+		if deleteErr := s.discardFeedback(ctx, feedback.ID); deleteErr != nil {
+			log.Printf("Failed to roll back import of %s: %v", feedback.ID, deleteErr)
+		}
+		return nil, err
+	}
+
+	s.publishEvent(ctx, events.TypeCreated, feedback)
+	return feedback, nil
+}
+
+func (s *FeedbackService) importFiles(ctx context.Context, feedback *models.FeedbackFile, assets []importedAsset) error {
+	err := s.minioClient.UploadFile(ctx, feedback.ID, "content.md", "text/markdown", []byte(feedback.Content))
+	if err != nil {
+		return fmt.Errorf("failed to upload content to storage: %w", err)
+	}
+
+	for _, asset := range assets {
+		if _, err := s.UploadAsset(ctx, feedback.ID, asset.filename, asset.contentType, asset.data); err != nil {
+			return fmt.Errorf("asset %q: %w", asset.filename, err)
+		}
+	}
+
+	if len(feedback.Tags) > 0 {
+		if err := s.repo.AddTags(ctx, feedback.ID, feedback.LabID, feedback.Tags); err != nil {
+			return fmt.Errorf("failed to import tags: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// checkBundleQuota applies the per-feedback limits that can be verified before the feedback exists
+func (s *FeedbackService) checkBundleQuota(metadata *bundle.Metadata) error {
+	limits := s.quotas
+
+	if limits.MaxAssetsPerFeedback > 0 && int64(len(metadata.Assets)) > limits.MaxAssetsPerFeedback {
+		return fmt.Errorf("%w: bundle has %d assets", ErrQuotaExceeded, len(metadata.Assets))
+	}
+
+	var total int64
+	for _, asset := range metadata.Assets {
+		total += asset.Size
+	}
+	if limits.MaxBytesPerFeedback > 0 && total > limits.MaxBytesPerFeedback {
+		return fmt.Errorf("%w: bundle assets use %d of %d bytes", ErrQuotaExceeded, total, limits.MaxBytesPerFeedback)
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestImportBundleRequiresStaff(t *testing.T) {
+	s := &FeedbackService{}
+
+	for _, ctx := range []context.Context{
+		context.Background(),
+		WithCaller(context.Background(), Caller{UserID: 7, Role: RoleStudent}),
+	} {
+		_, err := s.ImportBundle(ctx, bytes.NewReader(nil), 0, true)
+		if !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("got %v, want ErrPermissionDenied", err)
+		}
+	}
+}
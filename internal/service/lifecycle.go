@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Ravwvil/feedback/internal/events"
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/repository"
+)
+
+var (
+	// ErrFeedbackNotFound is returned for missing feedback and for drafts of other authors
+	ErrFeedbackNotFound = errors.New("feedback not found")
+
+	// ErrInvalidTransition is returned when feedback cannot move to the requested status
+	ErrInvalidTransition = errors.New("invalid status transition")
+
+	// ErrInvalidStatus is returned for status names outside the lifecycle
+	ErrInvalidStatus = errors.New("invalid status")
+
+	// ErrPermissionDenied is returned when the caller may not perform the operation
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// transition describes an allowed status change and who may perform it
+type transition struct {
+	from    []string
+	allowed func(caller Caller, feedback *models.FeedbackFile) bool
+}
+
+func isAuthor(caller Caller, feedback *models.FeedbackFile) bool {
+	return caller.UserID != 0 && caller.UserID == feedback.AuthorID
+}
+
+func isRecipient(caller Caller, feedback *models.FeedbackFile) bool {
+	return caller.UserID != 0 && caller.UserID == feedback.UserID
+}
+
+// transitions is keyed by target status
+var transitions = map[string]transition{
+	// Drafts are hidden from everyone but their author, so nobody else could publish one
+	models.StatusPublished: {
+		from:    []string{models.StatusDraft},
+		allowed: isAuthor,
+	},
+	models.StatusAcknowledged: {
+		from:    []string{models.StatusPublished},
+		allowed: isRecipient,
+	},
+	models.StatusResolved: {
+		from: []string{models.StatusPublished, models.StatusAcknowledged},
+		allowed: func(caller Caller, feedback *models.FeedbackFile) bool {
+			return isAuthor(caller, feedback) || caller.IsStaff()
+		},
+	},
+	// Archived feedback is visible to the recipient, drafts are moved to the trash instead
+	models.StatusArchived: {
+		from: []string{models.StatusPublished, models.StatusAcknowledged, models.StatusResolved},
+		allowed: func(caller Caller, feedback *models.FeedbackFile) bool {
+			return isAuthor(caller, feedback) || caller.IsStaff()
+		},
+	},
+}
+
+// canEdit reports whether the caller may change the feedback or its assets
+func canEdit(caller Caller, feedback *models.FeedbackFile) bool {
+	return isAuthor(caller, feedback) || caller.IsStaff()
+}
+
+// canView reports whether the caller may see the feedback, drafts are visible to their author only
+func canView(caller Caller, feedback *models.FeedbackFile) bool {
+	return feedback.Status != models.StatusDraft || isAuthor(caller, feedback)
+}
+
+// getVisibleFeedback loads feedback metadata, hiding drafts from everyone but the author
+func (s *FeedbackService) getVisibleFeedback(ctx context.Context, id string) (*models.FeedbackFile, error) {
+	feedback, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrFeedbackNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feedback metadata: %w", err)
+	}
+
+	if !canView(CallerFromContext(ctx), feedback) {
+		return nil, fmt.Errorf("%w: %s", ErrFeedbackNotFound, id)
+	}
+
+	return feedback, nil
+}
+
+// getEditableFeedback loads feedback the caller may see and change
+func (s *FeedbackService) getEditableFeedback(ctx context.Context, id string) (*models.FeedbackFile, error) {
+	feedback, err := s.getVisibleFeedback(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canEdit(CallerFromContext(ctx), feedback) {
+		return nil, fmt.Errorf("%w: cannot change feedback %s", ErrPermissionDenied, id)
+	}
+
+	return feedback, nil
+}
+
+func (s *FeedbackService) PublishFeedback(ctx context.Context, id string) (*models.FeedbackFile, error) {
+	return s.transition(ctx, id, models.StatusPublished)
+}
+
+func (s *FeedbackService) AcknowledgeFeedback(ctx context.Context, id string) (*models.FeedbackFile, error) {
+	return s.transition(ctx, id, models.StatusAcknowledged)
+}
+
+func (s *FeedbackService) ResolveFeedback(ctx context.Context, id string) (*models.FeedbackFile, error) {
+	return s.transition(ctx, id, models.StatusResolved)
+}
+
+func (s *FeedbackService) ArchiveFeedback(ctx context.Context, id string) (*models.FeedbackFile, error) {
+	return s.transition(ctx, id, models.StatusArchived)
+}
+
+// transition moves feedback to the target status if the state machine and the caller's role allow it
+func (s *FeedbackService) transition(ctx context.Context, id, to string) (*models.FeedbackFile, error) {
+	rule, ok := transitions[to]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidTransition, to)
+	}
+
+	feedback, err := s.getVisibleFeedback(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !containsStatus(rule.from, feedback.Status) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, feedback.Status, to)
+	}
+	if !rule.allowed(CallerFromContext(ctx), feedback) {
+		return nil, fmt.Errorf("%w: cannot move feedback to %s", ErrPermissionDenied, to)
+	}
+
+	var updated *models.FeedbackFile
+	err = s.repo.InTx(ctx, func(tx *repository.FeedbackRepository) error {
+		// The update only applies if nobody changed the status since it was read
+		var err error
+		updated, err = tx.UpdateStatus(ctx, id, feedback.Status, to)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: status of %s changed concurrently", ErrInvalidTransition, id)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update feedback status: %w", err)
+		}
+
+		return s.recordEvent(ctx, tx, models.EventFeedbackStatusChanged, updated, eventData{
+			Feedback:       snapshotOf(updated),
+			PreviousStatus: feedback.Status,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishEvent(ctx, events.TypeUpdated, updated)
+	return updated, nil
+}
+
+// ValidStatus reports whether status names a lifecycle state
+func ValidStatus(status string) bool {
+	return status == models.StatusDraft || status == models.StatusPublished ||
+		status == models.StatusAcknowledged || status == models.StatusResolved ||
+		status == models.StatusArchived
+}
+
+func containsStatus(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
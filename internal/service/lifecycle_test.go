@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+var (
+	author     = Caller{UserID: 1, Role: RoleInstructor}
+	recipient  = Caller{UserID: 2, Role: RoleStudent}
+	instructor = Caller{UserID: 3, Role: RoleInstructor}
+	admin      = Caller{UserID: 4, Role: RoleAdmin}
+	student    = Caller{UserID: 5, Role: RoleStudent}
+	anonymous  = Caller{}
+)
+
+func TestCanView(t *testing.T) {
+	draft := &models.FeedbackFile{Status: models.StatusDraft, AuthorID: 1, UserID: 2}
+	published := &models.FeedbackFile{Status: models.StatusPublished, AuthorID: 1, UserID: 2}
+
+	for _, caller := range []Caller{author, recipient, instructor, admin, student, anonymous} {
+		if !canView(caller, published) {
+			t.Errorf("%+v cannot view published feedback", caller)
+		}
+		if want := caller == author; canView(caller, draft) != want {
+			t.Errorf("%+v viewing a draft: got %v, want %v", caller, !want, want)
+		}
+	}
+
+	// Drafts without an author stay hidden from anonymous callers
+	if canView(anonymous, &models.FeedbackFile{Status: models.StatusDraft}) {
+		t.Error("anonymous caller can view an authorless draft")
+	}
+}
+
+func TestCanEdit(t *testing.T) {
+	feedback := &models.FeedbackFile{Status: models.StatusPublished, AuthorID: 1, UserID: 2}
+
+	tests := map[Caller]bool{
+		author:     true,
+		instructor: true,
+		admin:      true,
+		recipient:  false,
+		student:    false,
+		anonymous:  false,
+	}
+	for caller, want := range tests {
+		if got := canEdit(caller, feedback); got != want {
+			t.Errorf("%+v: got %v, want %v", caller, got, want)
+		}
+	}
+}
+
+func TestDraftsCannotBeArchived(t *testing.T) {
+	if containsStatus(transitions[models.StatusArchived].from, models.StatusDraft) {
+		t.Fatal("archiving a draft would expose it to the recipient")
+	}
+}
+
+func TestTransitionPermissions(t *testing.T) {
+	feedback := &models.FeedbackFile{AuthorID: 1, UserID: 2}
+
+	tests := []struct {
+		to      string
+		allowed []Caller
+		denied  []Caller
+	}{
+		{models.StatusPublished, []Caller{author}, []Caller{instructor, admin, recipient, anonymous}},
+		{models.StatusAcknowledged, []Caller{recipient}, []Caller{author, admin, student, anonymous}},
+		{models.StatusResolved, []Caller{author, instructor, admin}, []Caller{recipient, anonymous}},
+		{models.StatusArchived, []Caller{author, instructor, admin}, []Caller{recipient, anonymous}},
+	}
+
+	for _, tt := range tests {
+		rule := transitions[tt.to]
+		for _, caller := range tt.allowed {
+			if !rule.allowed(caller, feedback) {
+				t.Errorf("%s: %+v was denied", tt.to, caller)
+			}
+		}
+		for _, caller := range tt.denied {
+			if rule.allowed(caller, feedback) {
+				t.Errorf("%s: %+v was allowed", tt.to, caller)
+			}
+		}
+	}
+}
+
+func TestCreateFeedbackRejectsAnonymousDrafts(t *testing.T) {
+	s := &FeedbackService{}
+
+	_, err := s.CreateFeedback(context.Background(), &CreateFeedbackParams{UserID: 2, LabID: 1, Title: "Lab 1"})
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("got %v, want ErrPermissionDenied", err)
+	}
+}
+
+func TestValidRole(t *testing.T) {
+	for _, role := range []string{RoleStudent, RoleInstructor, RoleAdmin} {
+		if !ValidRole(role) {
+			t.Errorf("%q is not valid", role)
+		}
+	}
+	for _, role := range []string{"", "root", "Admin"} {
+		if ValidRole(role) {
+			t.Errorf("%q is valid", role)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/pagination"
+	"github.com/Ravwvil/feedback/internal/repository"
+)
+
+// maxTitleFilterLength matches the length of the title column
+const maxTitleFilterLength = 255
+
+// maxFilterIDs bounds the distinct user and lab ids of a filter, each is looked up in the external services
+const maxFilterIDs = 100
+
+// ErrInvalidFilter is returned for list filters or sort orders that cannot be applied
+var ErrInvalidFilter = errors.New("invalid filter")
+
+type ListFeedbacksParams struct {
+	UserIDs  []int64
+	LabIDs   []int64
+	Statuses []string
+	Tags     []string
+	TagMatch string // "any" (default) or "all"
+
+	// Zero times leave the range open, After is inclusive and Before exclusive
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+
+	TitleContains string
+	HasAssets     *bool
+
+	SortBy        string // "created_at" (default), "updated_at" or "title"
+	SortAscending bool
+
+	IncludeContent bool
+
+	PageSize          int
+	PageToken         string
+	IncludeTotalCount bool
+}
+
+// ListFeedbacks lists feedback across users and labs. Instructors and admins may
+// list any feedback, students only feedback they received or wrote.
+func (s *FeedbackService) ListFeedbacks(ctx context.Context, params *ListFeedbacksParams) (*Page[*models.FeedbackFile], error) {
+	caller := CallerFromContext(ctx)
+	if caller.UserID == 0 {
+		return nil, fmt.Errorf("%w: listing feedback requires an authenticated caller", ErrPermissionDenied)
+	}
+
+	for _, status := range params.Statuses {
+		if !ValidStatus(status) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidStatus, status)
+		}
+	}
+
+	tags, err := normalizeTags(params.Tags)
+	if err != nil {
+		return nil, err
+	}
+	tagMatch, err := normalizeTagMatch(params.TagMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateRange("created", params.CreatedAfter, params.CreatedBefore); err != nil {
+		return nil, err
+	}
+	if err := validateRange("updated", params.UpdatedAfter, params.UpdatedBefore); err != nil {
+		return nil, err
+	}
+	if utf8.RuneCountInString(params.TitleContains) > maxTitleFilterLength {
+		return nil, fmt.Errorf("%w: title filter exceeds %d characters", ErrInvalidFilter, maxTitleFilterLength)
+	}
+
+	sort := repository.FeedbackSort{Field: params.SortBy, Ascending: params.SortAscending}
+	if sort.Field == "" {
+		sort.Field = repository.SortByCreatedAt
+	}
+	if !repository.ValidSortField(sort.Field) {
+		return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidFilter, sort.Field)
+	}
+
+	userIDs, labIDs := dedupeIDs(params.UserIDs), dedupeIDs(params.LabIDs)
+	if len(userIDs) > maxFilterIDs || len(labIDs) > maxFilterIDs {
+		return nil, fmt.Errorf("%w: at most %d users and %d labs", ErrInvalidFilter, maxFilterIDs, maxFilterIDs)
+	}
+	if err := s.validateUsers(ctx, userIDs...); err != nil {
+		return nil, err
+	}
+	if err := s.validateLabs(ctx, labIDs...); err != nil {
+		return nil, err
+	}
+
+	filter := repository.FeedbackFilter{
+		UserIDs:       userIDs,
+		LabIDs:        labIDs,
+		Statuses:      params.Statuses,
+		Tags:          tags,
+		TagMatch:      tagMatch,
+		CreatedAfter:  params.CreatedAfter,
+		CreatedBefore: params.CreatedBefore,
+		UpdatedAfter:  params.UpdatedAfter,
+		UpdatedBefore: params.UpdatedBefore,
+		TitleContains: params.TitleContains,
+		HasAssets:     params.HasAssets,
+		ViewerID:      caller.UserID,
+	}
+	if !caller.IsStaff() {
+		filter.ParticipantID = caller.UserID
+	}
+
+	return s.listFeedbacks(ctx, filter, sort, params.IncludeContent, params.PageSize, params.PageToken, params.IncludeTotalCount)
+}
+
+// listFeedbacks loads one page of feedback, page tokens carry the sort key and id of the last feedback
+func (s *FeedbackService) listFeedbacks(ctx context.Context, filter repository.FeedbackFilter, sort repository.FeedbackSort, includeContent bool, pageSize int, pageToken string, includeTotalCount bool) (*Page[*models.FeedbackFile], error) {
+	query := feedbackQueryFingerprint(filter, sort)
+	position, err := pagination.Decode(pageToken, query, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	var after *repository.FeedbackCursor
+	if position != nil {
+		id, err := pagination.ParseID(position[1])
+		if err != nil {
+			return nil, err
+		}
+		after = &repository.FeedbackCursor{Key: position[0], ID: id}
+		if sort.Field != repository.SortByTitle {
+			if after.Key, err = pagination.ParseTime(position[0]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	pageSize = pagination.PageSize(pageSize)
+	feedbacks, err := s.repo.ListFeedbacks(ctx, filter, sort, after, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := newPage(feedbacks, pageSize, query, func(feedback *models.FeedbackFile) []string {
+		return []string{feedbackSortKey(feedback, sort.Field), feedback.ID}
+	})
+
+	if includeTotalCount {
+		if page.TotalCount, err = s.repo.CountFeedbacks(ctx, filter); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.attachTags(ctx, page.Items...); err != nil {
+		return nil, err
+	}
+
+	if includeContent {
+		if err := s.loadContents(ctx, page.Items); err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+// feedbackSortKey encodes the value feedback is ordered by for a page token
+func feedbackSortKey(feedback *models.FeedbackFile, field string) string {
+	switch field {
+	case repository.SortByUpdatedAt:
+		return pagination.FormatTime(feedback.UpdatedAt)
+	case repository.SortByTitle:
+		return feedback.Title
+	default:
+		return pagination.FormatTime(feedback.CreatedAt)
+	}
+}
+
+// feedbackQueryFingerprint ties page tokens to the filter and order they were issued for
+func feedbackQueryFingerprint(filter repository.FeedbackFilter, sort repository.FeedbackSort) string {
+	var hasAssets string
+	if filter.HasAssets != nil {
+		hasAssets = strconv.FormatBool(*filter.HasAssets)
+	}
+
+	return pagination.QueryFingerprint("feedbacks",
+		filter.UserIDs, filter.LabIDs, filter.Statuses, filter.Tags, filter.TagMatch,
+		pagination.FormatTime(filter.CreatedAfter), pagination.FormatTime(filter.CreatedBefore),
+		pagination.FormatTime(filter.UpdatedAfter), pagination.FormatTime(filter.UpdatedBefore),
+		filter.TitleContains, hasAssets, filter.ParticipantID, filter.ViewerID,
+		sort.Field, sort.Ascending,
+	)
+}
+
+// validateRange rejects time ranges that cannot match anything
+func validateRange(field string, after, before time.Time) error {
+	if !after.IsZero() && !before.IsZero() && !after.Before(before) {
+		return fmt.Errorf("%w: %s_after must be before %s_before", ErrInvalidFilter, field, field)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/repository"
+)
+
+// The service has no repository, valid parameters would panic when they reach it
+func TestListFeedbacksValidation(t *testing.T) {
+	s := &FeedbackService{}
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		caller Caller
+		params ListFeedbacksParams
+		err    error
+	}{
+		{"anonymous", anonymous, ListFeedbacksParams{}, ErrPermissionDenied},
+		{"unknown status", student, ListFeedbacksParams{Statuses: []string{"pending"}}, ErrInvalidStatus},
+		{"unknown tag match", student, ListFeedbacksParams{Tags: []string{"a"}, TagMatch: "some"}, ErrInvalidTag},
+		{"inverted created range", student, ListFeedbacksParams{CreatedAfter: now, CreatedBefore: now.Add(-time.Hour)}, ErrInvalidFilter},
+		{"empty updated range", student, ListFeedbacksParams{UpdatedAfter: now, UpdatedBefore: now}, ErrInvalidFilter},
+		{"long title", student, ListFeedbacksParams{TitleContains: strings.Repeat("ё", maxTitleFilterLength+1)}, ErrInvalidFilter},
+		{"unknown sort field", student, ListFeedbacksParams{SortBy: "author_id"}, ErrInvalidFilter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithCaller(context.Background(), tt.caller)
+			if _, err := s.ListFeedbacks(ctx, &tt.params); !errors.Is(err, tt.err) {
+				t.Errorf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestFeedbackQueryFingerprint(t *testing.T) {
+	hasAssets := true
+	base := repository.FeedbackFilter{UserIDs: []int64{1}, ViewerID: 7}
+	sort := repository.FeedbackSort{Field: repository.SortByCreatedAt}
+	fingerprint := feedbackQueryFingerprint(base, sort)
+
+	if feedbackQueryFingerprint(base, sort) != fingerprint {
+		t.Error("fingerprint is not stable")
+	}
+
+	changes := map[string]func(*repository.FeedbackFilter, *repository.FeedbackSort){
+		"users":      func(f *repository.FeedbackFilter, s *repository.FeedbackSort) { f.UserIDs = []int64{2} },
+		"title":      func(f *repository.FeedbackFilter, s *repository.FeedbackSort) { f.TitleContains = "lab" },
+		"has assets": func(f *repository.FeedbackFilter, s *repository.FeedbackSort) { f.HasAssets = &hasAssets },
+		"viewer":     func(f *repository.FeedbackFilter, s *repository.FeedbackSort) { f.ViewerID = 8 },
+		"sort field": func(f *repository.FeedbackFilter, s *repository.FeedbackSort) { s.Field = repository.SortByTitle },
+		"direction":  func(f *repository.FeedbackFilter, s *repository.FeedbackSort) { s.Ascending = true },
+	}
+	for name, change := range changes {
+		filter, sort := base, sort
+		change(&filter, &sort)
+		if feedbackQueryFingerprint(filter, sort) == fingerprint {
+			t.Errorf("changing the %s keeps the fingerprint", name)
+		}
+	}
+}
+
+func TestFeedbackSortKey(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	feedback := &models.FeedbackFile{Title: "Lab 1", CreatedAt: created, UpdatedAt: created.Add(time.Hour)}
+
+	tests := map[string]string{
+		repository.SortByCreatedAt: "2026-03-01T12:00:00Z",
+		repository.SortByUpdatedAt: "2026-03-01T13:00:00Z",
+		repository.SortByTitle:     "Lab 1",
+	}
+	for field, want := range tests {
+		if got := feedbackSortKey(feedback, field); got != want {
+			t.Errorf("%s: got %q, want %q", field, got, want)
+		}
+	}
+}
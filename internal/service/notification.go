@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+// ErrInvalidNotificationMode is returned for notification modes other than immediate, daily_digest,
+// weekly_digest and off
+var ErrInvalidNotificationMode = errors.New("invalid notification mode")
+
+// notificationUser resolves userID 0 to the caller and allows users to manage their own
+// preferences only, admins may manage everyone's
+func notificationUser(caller Caller, userID int64) (int64, error) {
+	if userID == 0 {
+		userID = caller.UserID
+	}
+	if userID == 0 {
+		return 0, fmt.Errorf("%w: user is required", ErrPermissionDenied)
+	}
+	if userID != caller.UserID && caller.Role != RoleAdmin {
+		return 0, fmt.Errorf("%w: cannot manage notifications of user %d", ErrPermissionDenied, userID)
+	}
+	return userID, nil
+}
+
+// GetNotificationPreference returns how a user is notified, userID 0 for the caller
+func (s *FeedbackService) GetNotificationPreference(ctx context.Context, userID int64) (*models.NotificationPreference, error) {
+	userID, err := notificationUser(CallerFromContext(ctx), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pref, err := s.repo.GetNotificationPreference(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preference: %w", err)
+	}
+	return pref, nil
+}
+
+// SetNotificationPreference changes how a user is notified, userID 0 for the caller.
+// Notifications already waiting for a digest stay in it.
+func (s *FeedbackService) SetNotificationPreference(ctx context.Context, userID int64, mode string) (*models.NotificationPreference, error) {
+	userID, err := notificationUser(CallerFromContext(ctx), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case models.NotifyImmediate, models.NotifyDailyDigest, models.NotifyWeeklyDigest, models.NotifyOff:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidNotificationMode, mode)
+	}
+
+	pref := &models.NotificationPreference{
+		UserID: userID,
+		Mode:   mode,
+	}
+	if err := s.repo.SetNotificationPreference(ctx, pref); err != nil {
+		return nil, fmt.Errorf("failed to save notification preference: %w", err)
+	}
+	return pref, nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/repository"
+)
+
+// eventData is the payload of a domain event, only the parts relevant to its type are set
+type eventData struct {
+	Feedback       *feedbackSnapshot     `json:"feedback,omitempty"`
+	ChangedFields  []string              `json:"changed_fields,omitempty"`
+	PreviousStatus string                `json:"previous_status,omitempty"`
+	Score          *models.FeedbackScore `json:"score,omitempty"`
+	Asset          *models.AssetInfo     `json:"asset,omitempty"`
+	Comment        *commentSnapshot      `json:"comment,omitempty"`
+	Imported       bool                  `json:"imported,omitempty"` // Created from a bundle, not written now
+}
+
+// feedbackSnapshot is the feedback metadata carried by events, content stays in storage
+type feedbackSnapshot struct {
+	ID          string    `json:"id"`
+	UserID      int64     `json:"user_id"`
+	LabID       int64     `json:"lab_id"`
+	AuthorID    int64     `json:"author_id,omitempty"`
+	Title       string    `json:"title"`
+	ContentHash string    `json:"content_hash"`
+	Status      string    `json:"status"`
+	Tags        []string  `json:"tags,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// commentSnapshot is a comment carried by comment events, replies name the author they answer
+type commentSnapshot struct {
+	ID             string    `json:"id"`
+	ParentID       string    `json:"parent_id,omitempty"`
+	ParentAuthorID int64     `json:"parent_author_id,omitempty"`
+	AuthorID       int64     `json:"author_id"`
+	Content        string    `json:"content"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func commentSnapshotOf(comment *models.FeedbackComment, parent *models.FeedbackComment) *commentSnapshot {
+	snapshot := &commentSnapshot{
+		ID:        comment.ID,
+		ParentID:  comment.ParentID,
+		AuthorID:  comment.AuthorID,
+		Content:   comment.Content,
+		CreatedAt: comment.CreatedAt,
+	}
+	if parent != nil {
+		snapshot.ParentAuthorID = parent.AuthorID
+	}
+	return snapshot
+}
+
+func snapshotOf(feedback *models.FeedbackFile) *feedbackSnapshot {
+	return &feedbackSnapshot{
+		ID:          feedback.ID,
+		UserID:      feedback.UserID,
+		LabID:       feedback.LabID,
+		AuthorID:    feedback.AuthorID,
+		Title:       feedback.Title,
+		ContentHash: feedback.ContentHash,
+		Status:      feedback.Status,
+		Tags:        feedback.Tags,
+		CreatedAt:   feedback.CreatedAt,
+		UpdatedAt:   feedback.UpdatedAt,
+	}
+}
+
+// recordEvent appends a domain event to the outbox. tx must be the transaction of the
+// change the event describes, so the event exists exactly when the change was committed.
+func (s *FeedbackService) recordEvent(ctx context.Context, tx *repository.FeedbackRepository, eventType string, feedback *models.FeedbackFile, data eventData) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	err = tx.AppendOutboxEvent(ctx, &models.DomainEvent{
+		Type:       eventType,
+		FeedbackID: feedback.ID,
+		LabID:      feedback.LabID,
+		ActorID:    CallerFromContext(ctx).UserID,
+		Data:       payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record %s event: %w", eventType, err)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"github.com/Ravwvil/feedback/internal/pagination"
+)
+
+// ErrInvalidPageToken is returned for page tokens that are malformed or were issued for another filter
+var ErrInvalidPageToken = pagination.ErrInvalidPageToken
+
+// Page is one page of a list call
+type Page[T any] struct {
+	Items         []T
+	NextPageToken string // Empty on the last page
+	TotalCount    int    // Only set when the count was requested
+}
+
+// newPage trims items fetched with one extra row to pageSize and issues the
+// token continuing after the last returned item when more rows exist
+func newPage[T any](items []T, pageSize int, query string, position func(T) []string) *Page[T] {
+	page := &Page[T]{Items: items}
+	if len(items) > pageSize {
+		page.Items = items[:pageSize]
+		page.NextPageToken = pagination.Encode(query, position(page.Items[pageSize-1])...)
+	}
+	return page
+}
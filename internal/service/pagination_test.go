@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/pagination"
+	"github.com/Ravwvil/feedback/internal/repository"
+)
+
+func TestNewPage(t *testing.T) {
+	page := newPage([]int{1, 2, 3}, 2, "q", func(i int) []string { return []string{"k", "id"} })
+	if len(page.Items) != 2 || page.NextPageToken == "" {
+		t.Errorf("got %+v, want two items and a next page", page)
+	}
+
+	page = newPage([]int{1, 2}, 2, "q", func(i int) []string { return []string{"k", "id"} })
+	if len(page.Items) != 2 || page.NextPageToken != "" {
+		t.Errorf("got %+v, want the last page", page)
+	}
+}
+
+// The services below have no repository, a token reaching the database would panic
+func TestForgedPageTokenIDsAreRejected(t *testing.T) {
+	s := &FeedbackService{}
+	now := pagination.FormatTime(time.Now())
+
+	t.Run("feedbacks", func(t *testing.T) {
+		filter := repository.FeedbackFilter{}
+		sort := repository.FeedbackSort{Field: repository.SortByCreatedAt}
+		token := pagination.Encode(feedbackQueryFingerprint(filter, sort), now, "not-a-uuid")
+
+		_, err := s.listFeedbacks(context.Background(), filter, sort, false, 10, token, false)
+		if !errors.Is(err, ErrInvalidPageToken) || !strings.Contains(err.Error(), "UUID") {
+			t.Errorf("got %v, want %v", err, ErrInvalidPageToken)
+		}
+	})
+
+	t.Run("trash", func(t *testing.T) {
+		ctx := WithCaller(context.Background(), admin)
+		token := pagination.Encode(pagination.QueryFingerprint("trash", int64(0), int64(0), int64(0)), now, "42")
+
+		_, err := s.ListTrash(ctx, &ListTrashParams{PageToken: token})
+		if !errors.Is(err, ErrInvalidPageToken) || !strings.Contains(err.Error(), "UUID") {
+			t.Errorf("got %v, want %v", err, ErrInvalidPageToken)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/repository"
+)
+
+// ErrQuotaExceeded is returned when an upload would exceed a storage quota
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// QuotaLimits holds the configured storage limits, a zero value disables a limit
+type QuotaLimits struct {
+	MaxAssetsPerFeedback int64
+	MaxBytesPerFeedback  int64
+	MaxBytesPerUser      int64
+	MaxAssetSize         int64
+}
+
+// Usage combines the current consumption of a user with the configured limits
+type Usage struct {
+	*models.StorageUsage
+	Limits QuotaLimits
+}
+
+// MaxAssetSize returns the configured single-asset size limit
+func (s *FeedbackService) MaxAssetSize() int64 {
+	return s.quotas.MaxAssetSize
+}
+
+// CheckAssetQuota verifies that storing an asset of the given size would not exceed any quota.
+// It rejects uploads early, UploadAsset checks again while holding the quota lock.
+func (s *FeedbackService) CheckAssetQuota(ctx context.Context, feedbackID, filename string, size int64) error {
+	if err := s.checkAssetSize(size); err != nil {
+		return err
+	}
+
+	feedback, err := s.getEditableFeedback(ctx, feedbackID)
+	if err != nil {
+		return err
+	}
+
+	return s.checkAssetQuota(ctx, s.repo, feedback, filename, size)
+}
+
+// checkAssetSize enforces the single-asset limit
+func (s *FeedbackService) checkAssetSize(size int64) error {
+	if size < 0 {
+		return fmt.Errorf("%w: negative asset size %d", ErrQuotaExceeded, size)
+	}
+	if s.quotas.MaxAssetSize > 0 && size > s.quotas.MaxAssetSize {
+		return fmt.Errorf("%w: asset size %d exceeds limit of %d bytes", ErrQuotaExceeded, size, s.quotas.MaxAssetSize)
+	}
+	return nil
+}
+
+// checkAssetQuota enforces the per-feedback and per-user limits using the given repository,
+// which is a transaction holding LockAssetQuota when the result must stay valid until the write
+func (s *FeedbackService) checkAssetQuota(ctx context.Context, repo *repository.FeedbackRepository, feedback *models.FeedbackFile, filename string, size int64) error {
+	limits := s.quotas
+	feedbackID := feedback.ID
+
+	// An asset uploaded under an existing filename replaces the old one
+	var replacedSize int64
+	existing, err := repo.GetAsset(ctx, feedbackID, filename)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get asset metadata: %w", err)
+	}
+	if existing != nil {
+		replacedSize = existing.Size
+	}
+
+	count, total, err := repo.GetFeedbackAssetUsage(ctx, feedbackID, filename)
+	if err != nil {
+		return fmt.Errorf("failed to get feedback usage: %w", err)
+	}
+
+	if limits.MaxAssetsPerFeedback > 0 && count+1 > limits.MaxAssetsPerFeedback {
+		return fmt.Errorf("%w: feedback already has %d assets", ErrQuotaExceeded, count)
+	}
+	if limits.MaxBytesPerFeedback > 0 && total+size > limits.MaxBytesPerFeedback {
+		return fmt.Errorf("%w: feedback would use %d of %d bytes", ErrQuotaExceeded, total+size, limits.MaxBytesPerFeedback)
+	}
+
+	if limits.MaxBytesPerUser > 0 {
+		usage, err := repo.GetUserAssetUsage(ctx, feedback.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get user usage: %w", err)
+		}
+
+		userTotal := usage.TotalBytes - replacedSize + size
+		if userTotal > limits.MaxBytesPerUser {
+			return fmt.Errorf("%w: user would use %d of %d bytes", ErrQuotaExceeded, userTotal, limits.MaxBytesPerUser)
+		}
+	}
+
+	return nil
+}
+
+// GetUsage returns the storage consumed by a user together with the configured limits,
+// users may only read their own usage unless they are staff
+func (s *FeedbackService) GetUsage(ctx context.Context, userID int64) (*Usage, error) {
+	caller := CallerFromContext(ctx)
+	if !caller.IsStaff() && (caller.UserID == 0 || caller.UserID != userID) {
+		return nil, fmt.Errorf("%w: cannot read the usage of user %d", ErrPermissionDenied, userID)
+	}
+
+	usage, err := s.repo.GetUserAssetUsage(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user usage: %w", err)
+	}
+
+	return &Usage{
+		StorageUsage: usage,
+		Limits:       s.quotas,
+	}, nil
+}
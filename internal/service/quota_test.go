@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCheckAssetSize(t *testing.T) {
+	limited := &FeedbackService{quotas: QuotaLimits{MaxAssetSize: 100}}
+	unlimited := &FeedbackService{}
+
+	tests := []struct {
+		name    string
+		service *FeedbackService
+		size    int64
+		wantErr bool
+	}{
+		{"within limit", limited, 100, false},
+		{"over limit", limited, 101, true},
+		{"negative", limited, -1, true},
+		{"no limit", unlimited, 1 << 40, false},
+		{"negative without limit", unlimited, -1, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.service.checkAssetSize(tt.size)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrQuotaExceeded) {
+			t.Errorf("%s: got %v, want ErrQuotaExceeded", tt.name, err)
+		}
+	}
+}
+
+func TestGetUsageRequiresOwnerOrStaff(t *testing.T) {
+	// Only denied callers are tested, allowed ones would reach the repository
+	s := &FeedbackService{}
+
+	tests := map[string]struct {
+		caller Caller
+		userID int64
+	}{
+		"other student":        {student, recipient.UserID},
+		"anonymous":            {anonymous, recipient.UserID},
+		"anonymous for user 0": {anonymous, 0},
+	}
+	for name, tt := range tests {
+		_, err := s.GetUsage(WithCaller(context.Background(), tt.caller), tt.userID)
+		if !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("%s: got %v, want ErrPermissionDenied", name, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/markdown"
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+// Modes for turning relative asset references into URLs when rendering
+const (
+	AssetURLModeREST      = "rest"
+	AssetURLModePresigned = "presigned"
+)
+
+// assetReferenceWarnings reports references in content to assets the feedback does not have
+func (s *FeedbackService) assetReferenceWarnings(ctx context.Context, feedbackID, content string) ([]string, error) {
+	filenames := s.renderer.AssetReferences([]byte(content))
+	if len(filenames) == 0 {
+		return nil, nil
+	}
+
+	assets, err := s.repo.ListAssets(ctx, feedbackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list asset metadata: %w", err)
+	}
+
+	existing := make(map[string]*models.AssetInfo, len(assets))
+	for _, asset := range assets {
+		existing[asset.Filename] = asset
+	}
+
+	var warnings []string
+	for _, filename := range filenames {
+		asset, ok := existing[filename]
+		switch {
+		case !ok:
+			warnings = append(warnings, fmt.Sprintf("broken asset reference %q: asset does not exist", markdown.AssetsPrefix+filename))
+		case asset.ScanStatus != models.ScanStatusClean:
+			warnings = append(warnings, fmt.Sprintf("asset reference %q points to a quarantined asset", markdown.AssetsPrefix+filename))
+		}
+	}
+
+	return warnings, nil
+}
+
+// assetURLResolver returns a resolver producing download URLs for assets of a feedback
+// together with how long the produced URLs stay valid, 0 meaning indefinitely
+func (s *FeedbackService) assetURLResolver(ctx context.Context, feedbackID string) (markdown.URLResolver, time.Duration) {
+	restURL := func(filename string) string {
+		return fmt.Sprintf("%s/%s/assets/%s", strings.TrimSuffix(s.assetURLs.BaseURL, "/"), url.PathEscape(feedbackID), url.PathEscape(filename))
+	}
+
+	// REST downloads refuse quarantined assets themselves
+	if s.assetURLs.Mode != AssetURLModePresigned {
+		return restURL, 0
+	}
+
+	presign := func(filename string) string {
+		presigned, err := s.minioClient.PresignFile(ctx, feedbackID, markdown.AssetsPrefix+filename, s.assetURLs.PresignExpiry)
+		if err != nil {
+			log.Printf("Failed to presign asset %s/%s: %v", feedbackID, filename, err)
+			return restURL(filename)
+		}
+		return presigned
+	}
+
+	// Presigned URLs bypass the scan check, assets are listed once the first reference is found
+	var resolve markdown.URLResolver
+	lazy := func(filename string) string {
+		if resolve == nil {
+			assets, err := s.repo.ListAssets(ctx, feedbackID)
+			if err != nil {
+				log.Printf("Failed to list assets of %s: %v", feedbackID, err)
+			}
+			resolve = onlyClean(assets, presign)
+		}
+		return resolve(filename)
+	}
+
+	// Cached documents must be refreshed well before the embedded URLs expire
+	return lazy, s.assetURLs.PresignExpiry / 2
+}
+
+// onlyClean resolves references to assets that were scanned clean and leaves the others unresolved
+func onlyClean(assets []*models.AssetInfo, resolve markdown.URLResolver) markdown.URLResolver {
+	clean := make(map[string]bool, len(assets))
+	for _, asset := range assets {
+		if scannedClean(asset) {
+			clean[asset.Filename] = true
+		}
+	}
+
+	return func(filename string) string {
+		if !clean[filename] {
+			return ""
+		}
+		return resolve(filename)
+	}
+}
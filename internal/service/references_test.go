@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+func TestOnlyCleanResolvesCleanAssets(t *testing.T) {
+	assets := []*models.AssetInfo{
+		{Filename: "clean.png", ScanStatus: models.ScanStatusClean},
+		{Filename: "infected.png", ScanStatus: models.ScanStatusInfected},
+		{Filename: "pending.png"},
+	}
+	resolve := onlyClean(assets, func(filename string) string {
+		return "https://minio/" + filename
+	})
+
+	tests := map[string]string{
+		"clean.png":    "https://minio/clean.png",
+		"infected.png": "",
+		"pending.png":  "",
+		"missing.png":  "",
+	}
+	for filename, want := range tests {
+		if got := resolve(filename); got != want {
+			t.Errorf("%s: got %q, want %q", filename, got, want)
+		}
+	}
+}
+
+func TestOnlyCleanWithoutAssets(t *testing.T) {
+	// A failed listing leaves every reference unresolved
+	resolve := onlyClean(nil, func(filename string) string { return filename })
+	if got := resolve("clean.png"); got != "" {
+		t.Errorf("got %q, want no URL", got)
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+// renderCacheKey includes the feedback ID because rendered asset URLs point into its folder
+func renderCacheKey(feedbackID, contentHash string) string {
+	return feedbackID + ":" + contentHash
+}
+
+// RenderFeedback returns the feedback with its content rendered to sanitized HTML.
+// Rendered documents are cached by content hash, a cache hit skips reading content from storage.
+func (s *FeedbackService) RenderFeedback(ctx context.Context, id string) (*models.FeedbackFile, error) {
+	feedback, err := s.getVisibleFeedback(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if feedback.ContentHash != "" {
+		if html, ok := s.renderCache.Get(renderCacheKey(feedback.ID, feedback.ContentHash)); ok {
+			feedback.RenderedHTML = html
+			return feedback, nil
+		}
+	}
+
+	content, err := s.minioClient.DownloadFile(ctx, id, "content.md")
+	if err != nil {
+		return nil, fmt.Errorf("failed to download content from storage: %w", err)
+	}
+	feedback.Content = string(content)
+
+	if err := s.RenderContent(ctx, feedback); err != nil {
+		return nil, err
+	}
+
+	return feedback, nil
+}
+
+// RenderContent renders the already loaded content of a feedback into RenderedHTML,
+// relative asset references are rewritten to download URLs
+func (s *FeedbackService) RenderContent(ctx context.Context, feedback *models.FeedbackFile) error {
+	// The stored hash may be missing for older feedback, derive it from the content
+	hash := feedback.ContentHash
+	if hash == "" {
+		hash = fmt.Sprintf("%x", sha256.Sum256([]byte(feedback.Content)))
+	}
+	key := renderCacheKey(feedback.ID, hash)
+
+	if html, ok := s.renderCache.Get(key); ok {
+		feedback.RenderedHTML = html
+		return nil
+	}
+
+	resolve, ttl := s.assetURLResolver(ctx, feedback.ID)
+	html, err := s.renderer.Render([]byte(feedback.Content), resolve)
+	if err != nil {
+		return err
+	}
+	s.renderCache.Put(key, html, ttl)
+
+	feedback.RenderedHTML = html
+	return nil
+}
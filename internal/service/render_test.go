@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Ravwvil/feedback/internal/markdown"
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+func TestRenderContentCachesByContentHash(t *testing.T) {
+	s := &FeedbackService{
+		renderer:    markdown.NewRenderer(),
+		renderCache: markdown.NewCache(10),
+		assetURLs:   AssetURLOptions{Mode: AssetURLModeREST, BaseURL: "/api/feedback/"},
+	}
+
+	feedback := &models.FeedbackFile{ID: "f1", ContentHash: "h1", Content: "![plot](assets/plot.png)"}
+	if err := s.RenderContent(context.Background(), feedback); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(feedback.RenderedHTML, `src="/api/feedback/f1/assets/plot.png"`) {
+		t.Errorf("asset reference not rewritten: %q", feedback.RenderedHTML)
+	}
+
+	// The same hash is served from the cache without rendering again
+	cached := &models.FeedbackFile{ID: "f1", ContentHash: "h1", Content: "changed"}
+	if err := s.RenderContent(context.Background(), cached); err != nil {
+		t.Fatal(err)
+	}
+	if cached.RenderedHTML != feedback.RenderedHTML {
+		t.Errorf("got %q, want the cached document", cached.RenderedHTML)
+	}
+
+	// Another feedback with the same content links into its own folder
+	other := &models.FeedbackFile{ID: "f2", ContentHash: "h1", Content: "![plot](assets/plot.png)"}
+	if err := s.RenderContent(context.Background(), other); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(other.RenderedHTML, "/f2/assets/plot.png") {
+		t.Errorf("got %q, want links into f2", other.RenderedHTML)
+	}
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/repository"
+)
+
+var (
+	// ErrInvalidRubric is returned for rubric definitions that cannot be graded against
+	ErrInvalidRubric = errors.New("invalid rubric")
+
+	// ErrRubricNotFound is returned when a lab has no rubric of the requested version
+	ErrRubricNotFound = errors.New("rubric not found")
+
+	// ErrInvalidScore is returned when scores do not match the criteria and levels of the rubric
+	ErrInvalidScore = errors.New("invalid rubric score")
+
+	// ErrRubricVersionMismatch is returned when a score targets a rubric version that is not current
+	ErrRubricVersionMismatch = errors.New("rubric version mismatch")
+
+	// ErrScoreNotFound is returned for feedback that has not been scored
+	ErrScoreNotFound = errors.New("score not found")
+
+	// ErrRubricConflict is returned when another rubric version of the lab was defined concurrently
+	ErrRubricConflict = errors.New("rubric defined concurrently")
+)
+
+// criterionIDPattern keeps criterion IDs usable as stable keys, e.g. "code-style"
+var criterionIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+type DefineRubricParams struct {
+	LabID    int64
+	Title    string
+	Criteria []models.RubricCriterion
+}
+
+type ScoreFeedbackParams struct {
+	FeedbackID    string
+	RubricVersion int // Version the grader scored against, must be the latest rubric of the lab
+	Criteria      []models.CriterionScore
+}
+
+// ScoreBreakdown is a feedback score joined with the rubric it was scored against
+type ScoreBreakdown struct {
+	Score  *models.FeedbackScore
+	Rubric *models.Rubric
+}
+
+// DefineRubric stores a new rubric version for a lab, existing scores keep the version they were scored against
+func (s *FeedbackService) DefineRubric(ctx context.Context, params *DefineRubricParams) (*models.Rubric, error) {
+	caller := CallerFromContext(ctx)
+	if !caller.IsStaff() {
+		return nil, fmt.Errorf("%w: only instructors can define rubrics", ErrPermissionDenied)
+	}
+
+	rubric := &models.Rubric{
+		LabID:     params.LabID,
+		Title:     params.Title,
+		Criteria:  params.Criteria,
+		CreatedBy: caller.UserID,
+	}
+	if err := validateRubric(rubric); err != nil {
+		return nil, err
+	}
+
+	// Both definitions read the same latest version, the loser may retry
+	err := s.repo.CreateRubric(ctx, rubric)
+	if repository.IsUniqueViolation(err) {
+		return nil, fmt.Errorf("%w: lab %d", ErrRubricConflict, rubric.LabID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rubric: %w", err)
+	}
+
+	return rubric, nil
+}
+
+// GetRubric returns a rubric version of a lab, version 0 selects the latest one
+func (s *FeedbackService) GetRubric(ctx context.Context, labID int64, version int) (*models.Rubric, error) {
+	rubric, err := s.repo.GetRubric(ctx, labID, version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: lab %d version %d", ErrRubricNotFound, labID, version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rubric: %w", err)
+	}
+	return rubric, nil
+}
+
+// ScoreFeedback grades feedback against the latest rubric of its lab and computes the total
+func (s *FeedbackService) ScoreFeedback(ctx context.Context, params *ScoreFeedbackParams) (*ScoreBreakdown, error) {
+	feedback, err := s.getVisibleFeedback(ctx, params.FeedbackID)
+	if err != nil {
+		return nil, err
+	}
+
+	caller := CallerFromContext(ctx)
+	if !canEdit(caller, feedback) {
+		return nil, fmt.Errorf("%w: cannot score feedback %s", ErrPermissionDenied, feedback.ID)
+	}
+
+	rubric, err := s.GetRubric(ctx, feedback.LabID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// A grader working from an outdated rubric must reload it before scoring
+	if params.RubricVersion != rubric.Version {
+		return nil, fmt.Errorf("%w: scored against version %d, current version is %d",
+			ErrRubricVersionMismatch, params.RubricVersion, rubric.Version)
+	}
+
+	total, err := validateScores(rubric, params.Criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	score := &models.FeedbackScore{
+		FeedbackID:    feedback.ID,
+		RubricID:      rubric.ID,
+		RubricVersion: rubric.Version,
+		Criteria:      params.Criteria,
+		Total:         total,
+		MaxTotal:      maxRubricPoints(rubric),
+		ScoredBy:      caller.UserID,
+	}
+	err = s.repo.InTx(ctx, func(tx *repository.FeedbackRepository) error {
+		if err := tx.UpsertFeedbackScore(ctx, score); err != nil {
+			return fmt.Errorf("failed to save score: %w", err)
+		}
+		return s.recordEvent(ctx, tx, models.EventFeedbackScored, feedback, eventData{Score: score})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScoreBreakdown{Score: score, Rubric: rubric}, nil
+}
+
+// GetFeedbackScore returns the score of a feedback with the rubric version it was scored against
+func (s *FeedbackService) GetFeedbackScore(ctx context.Context, feedbackID string) (*ScoreBreakdown, error) {
+	feedback, err := s.getVisibleFeedback(ctx, feedbackID)
+	if err != nil {
+		return nil, err
+	}
+
+	score, err := s.repo.GetFeedbackScore(ctx, feedback.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrScoreNotFound, feedback.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get score: %w", err)
+	}
+
+	rubric, err := s.repo.GetRubricByID(ctx, score.RubricID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rubric: %w", err)
+	}
+
+	return &ScoreBreakdown{Score: score, Rubric: rubric}, nil
+}
+
+func validateRubric(rubric *models.Rubric) error {
+	if rubric.LabID <= 0 {
+		return fmt.Errorf("%w: lab ID is required", ErrInvalidRubric)
+	}
+	if rubric.Title == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidRubric)
+	}
+	if len(rubric.Criteria) == 0 {
+		return fmt.Errorf("%w: at least one criterion is required", ErrInvalidRubric)
+	}
+
+	criteria := make(map[string]bool, len(rubric.Criteria))
+	for _, criterion := range rubric.Criteria {
+		if !criterionIDPattern.MatchString(criterion.ID) {
+			return fmt.Errorf("%w: invalid criterion ID %q", ErrInvalidRubric, criterion.ID)
+		}
+		if criteria[criterion.ID] {
+			return fmt.Errorf("%w: duplicate criterion %q", ErrInvalidRubric, criterion.ID)
+		}
+		criteria[criterion.ID] = true
+
+		if criterion.Title == "" {
+			return fmt.Errorf("%w: criterion %q has no title", ErrInvalidRubric, criterion.ID)
+		}
+		if len(criterion.Levels) == 0 {
+			return fmt.Errorf("%w: criterion %q has no levels", ErrInvalidRubric, criterion.ID)
+		}
+
+		points := make(map[float64]bool, len(criterion.Levels))
+		for _, level := range criterion.Levels {
+			if math.IsNaN(level.Points) || math.IsInf(level.Points, 0) {
+				return fmt.Errorf("%w: criterion %q has invalid points", ErrInvalidRubric, criterion.ID)
+			}
+			if level.Points < 0 {
+				return fmt.Errorf("%w: criterion %q has negative points", ErrInvalidRubric, criterion.ID)
+			}
+			if !hasCents(level.Points) {
+				return fmt.Errorf("%w: criterion %q has points with more than 2 decimals", ErrInvalidRubric, criterion.ID)
+			}
+			if points[level.Points] {
+				return fmt.Errorf("%w: criterion %q has two levels worth %g points", ErrInvalidRubric, criterion.ID, level.Points)
+			}
+			points[level.Points] = true
+		}
+	}
+
+	return nil
+}
+
+// hasCents reports whether points have at most 2 decimal places, within float precision
+func hasCents(points float64) bool {
+	cents := points * 100
+	return math.Abs(cents-math.Round(cents)) < 1e-6
+}
+
+// validateScores checks that every criterion is scored exactly once with the points of one
+// of its levels and returns the total
+func validateScores(rubric *models.Rubric, scores []models.CriterionScore) (float64, error) {
+	criteria := make(map[string]models.RubricCriterion, len(rubric.Criteria))
+	for _, criterion := range rubric.Criteria {
+		criteria[criterion.ID] = criterion
+	}
+
+	var total float64
+	scored := make(map[string]bool, len(scores))
+	for _, score := range scores {
+		criterion, ok := criteria[score.CriterionID]
+		if !ok {
+			return 0, fmt.Errorf("%w: unknown criterion %q", ErrInvalidScore, score.CriterionID)
+		}
+		if scored[score.CriterionID] {
+			return 0, fmt.Errorf("%w: criterion %q scored twice", ErrInvalidScore, score.CriterionID)
+		}
+		scored[score.CriterionID] = true
+
+		if findLevel(criterion, score.Points) == nil {
+			return 0, fmt.Errorf("%w: %g points is not a level of criterion %q", ErrInvalidScore, score.Points, score.CriterionID)
+		}
+		total += score.Points
+	}
+
+	for _, criterion := range rubric.Criteria {
+		if !scored[criterion.ID] {
+			return 0, fmt.Errorf("%w: criterion %q is not scored", ErrInvalidScore, criterion.ID)
+		}
+	}
+
+	return total, nil
+}
+
+// findLevel returns the level of a criterion worth the given points
+func findLevel(criterion models.RubricCriterion, points float64) *models.RubricLevel {
+	for i := range criterion.Levels {
+		if criterion.Levels[i].Points == points {
+			return &criterion.Levels[i]
+		}
+	}
+	return nil
+}
+
+// maxRubricPoints sums the highest level of every criterion
+func maxRubricPoints(rubric *models.Rubric) float64 {
+	var total float64
+	for _, criterion := range rubric.Criteria {
+		var best float64
+		for _, level := range criterion.Levels {
+			if level.Points > best {
+				best = level.Points
+			}
+		}
+		total += best
+	}
+	return total
+}
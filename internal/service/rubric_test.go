@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+func testRubric(points ...float64) *models.Rubric {
+	levels := make([]models.RubricLevel, len(points))
+	for i, p := range points {
+		levels[i] = models.RubricLevel{Points: p}
+	}
+	return &models.Rubric{
+		LabID: 1,
+		Title: "Lab 1",
+		Criteria: []models.RubricCriterion{
+			{ID: "correctness", Title: "Correctness", Levels: levels},
+			{ID: "style", Title: "Style", Levels: []models.RubricLevel{{Points: 0}, {Points: 2.5}}},
+		},
+	}
+}
+
+func TestValidateRubric(t *testing.T) {
+	valid := [][]float64{{0, 5, 10}, {0.5, 1.25}, {0.1, 0.2, 0.3}, {99.99}}
+	for _, points := range valid {
+		if err := validateRubric(testRubric(points...)); err != nil {
+			t.Errorf("%v: %v", points, err)
+		}
+	}
+
+	invalid := [][]float64{
+		{},
+		{-1},
+		{1, 1},
+		{math.NaN()},
+		{math.Inf(1)},
+		{math.Inf(-1)},
+		{0.125},
+		{1.001},
+	}
+	for _, points := range invalid {
+		if err := validateRubric(testRubric(points...)); !errors.Is(err, ErrInvalidRubric) {
+			t.Errorf("%v: got %v, want ErrInvalidRubric", points, err)
+		}
+	}
+}
+
+func TestValidateRubricStructure(t *testing.T) {
+	tests := map[string]func(*models.Rubric){
+		"missing lab":        func(r *models.Rubric) { r.LabID = 0 },
+		"missing title":      func(r *models.Rubric) { r.Title = "" },
+		"no criteria":        func(r *models.Rubric) { r.Criteria = nil },
+		"invalid id":         func(r *models.Rubric) { r.Criteria[0].ID = "Code Style" },
+		"duplicate id":       func(r *models.Rubric) { r.Criteria[1].ID = r.Criteria[0].ID },
+		"untitled criterion": func(r *models.Rubric) { r.Criteria[0].Title = "" },
+	}
+
+	for name, edit := range tests {
+		rubric := testRubric(0, 5)
+		edit(rubric)
+		if err := validateRubric(rubric); !errors.Is(err, ErrInvalidRubric) {
+			t.Errorf("%s: got %v, want ErrInvalidRubric", name, err)
+		}
+	}
+}
+
+func TestValidateScores(t *testing.T) {
+	rubric := testRubric(0, 5, 10)
+
+	total, err := validateScores(rubric, []models.CriterionScore{
+		{CriterionID: "correctness", Points: 5},
+		{CriterionID: "style", Points: 2.5},
+	})
+	if err != nil || total != 7.5 {
+		t.Errorf("got %g, %v, want 7.5", total, err)
+	}
+	if maximum := maxRubricPoints(rubric); maximum != 12.5 {
+		t.Errorf("got maximum %g, want 12.5", maximum)
+	}
+
+	invalid := map[string][]models.CriterionScore{
+		"unknown criterion": {{CriterionID: "correctness", Points: 5}, {CriterionID: "style", Points: 0}, {CriterionID: "other"}},
+		"scored twice":      {{CriterionID: "correctness", Points: 5}, {CriterionID: "correctness", Points: 5}, {CriterionID: "style"}},
+		"not a level":       {{CriterionID: "correctness", Points: 4}, {CriterionID: "style", Points: 0}},
+		"nan points":        {{CriterionID: "correctness", Points: math.NaN()}, {CriterionID: "style", Points: 0}},
+		"missing criterion": {{CriterionID: "correctness", Points: 5}},
+	}
+	for name, scores := range invalid {
+		if _, err := validateScores(rubric, scores); !errors.Is(err, ErrInvalidScore) {
+			t.Errorf("%s: got %v, want ErrInvalidScore", name, err)
+		}
+	}
+}
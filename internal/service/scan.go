@@ -0,0 +1,114 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/sniff"
+)
+
+// ErrAssetQuarantined is returned when downloading an asset that has not been scanned clean
+var ErrAssetQuarantined = errors.New("asset is quarantined")
+
+// rescanBatchSize bounds the assets rescanned in one run of the rescan job
+const rescanBatchSize = 100
+
+// scannedClean reports whether an asset may be served, anything but a clean scan result keeps it quarantined
+func scannedClean(asset *models.AssetInfo) bool {
+	return asset != nil && asset.ScanStatus == models.ScanStatusClean
+}
+
+// scanAsset runs the configured scanner over an uploaded asset and returns its scan status.
+// Scanner failures leave the asset quarantined rather than failing the upload.
+func (s *FeedbackService) scanAsset(ctx context.Context, feedbackID, filename string, data []byte) (string, string) {
+	if s.scanner == nil {
+		return models.ScanStatusClean, ""
+	}
+
+	result, err := s.scanner.Scan(ctx, bytes.NewReader(data))
+	if err != nil {
+		log.Printf("Failed to scan asset %s/%s: %v", feedbackID, filename, err)
+		return models.ScanStatusError, ""
+	}
+
+	if !result.Clean {
+		log.Printf("Quarantined asset %s/%s: %s", feedbackID, filename, result.Signature)
+		return models.ScanStatusInfected, result.Signature
+	}
+
+	return models.ScanStatusClean, ""
+}
+
+// RescanAssets scans the stored content of assets whose scan failed or never ran and returns
+// how many got a result. A run stops at the first scanner failure, the scanner is most likely
+// still unavailable.
+func (s *FeedbackService) RescanAssets(ctx context.Context) (int, error) {
+	if s.scanner == nil {
+		return 0, nil
+	}
+
+	refs, err := s.repo.ListUnscannedAssets(ctx, rescanBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list unscanned assets: %w", err)
+	}
+
+	rescanned := 0
+	for _, ref := range refs {
+		data, err := s.minioClient.DownloadFile(ctx, ref.FeedbackID, fmt.Sprintf("assets/%s", ref.Filename))
+		if err != nil {
+			log.Printf("Failed to download asset %s/%s for rescanning: %v", ref.FeedbackID, ref.Filename, err)
+			continue
+		}
+
+		status, signature := s.scanAsset(ctx, ref.FeedbackID, ref.Filename, data)
+		if status == models.ScanStatusError {
+			return rescanned, nil
+		}
+
+		// The result belongs to the bytes that were scanned, which are recorded with it
+		contentHash := fmt.Sprintf("%x", sha256.Sum256(data))
+		recorded, err := s.repo.RecordAssetRescan(ctx, ref, status, signature, contentHash)
+		if err != nil {
+			log.Printf("Failed to record rescan of asset %s/%s: %v", ref.FeedbackID, ref.Filename, err)
+			continue
+		}
+		if !recorded {
+			continue
+		}
+		rescanned++
+
+		// Thumbnails are only generated for clean images, so they are still missing
+		if status == models.ScanStatusClean && s.thumbnails != nil && s.thumbnails.Supports(sniff.Detect(data)) {
+			s.startThumbnails(ref.FeedbackID, ref.Filename, contentHash, data)
+		}
+	}
+
+	return rescanned, nil
+}
+
+// RunRescanJob rescans unscanned assets every interval until ctx is cancelled
+func (s *FeedbackService) RunRescanJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rescanned, err := s.RescanAssets(ctx)
+			if err != nil {
+				log.Printf("Failed to rescan assets: %v", err)
+			}
+			if rescanned > 0 {
+				log.Printf("Rescanned %d assets", rescanned)
+			}
+		}
+	}
+}
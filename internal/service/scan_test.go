@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/scanner"
+)
+
+type stubScanner struct {
+	result *scanner.Result
+	err    error
+}
+
+func (s *stubScanner) Scan(ctx context.Context, r io.Reader) (*scanner.Result, error) {
+	return s.result, s.err
+}
+
+func TestScanAsset(t *testing.T) {
+	tests := []struct {
+		name          string
+		scanner       scanner.AssetScanner
+		wantStatus    string
+		wantSignature string
+	}{
+		{"no scanner", nil, models.ScanStatusClean, ""},
+		{"clean", &stubScanner{result: &scanner.Result{Clean: true}}, models.ScanStatusClean, ""},
+		{"infected", &stubScanner{result: &scanner.Result{Signature: "Eicar"}}, models.ScanStatusInfected, "Eicar"},
+		{"scanner down", &stubScanner{err: errors.New("connection refused")}, models.ScanStatusError, ""},
+	}
+
+	for _, tt := range tests {
+		s := &FeedbackService{scanner: tt.scanner}
+		status, signature := s.scanAsset(context.Background(), "f", "a.txt", []byte("data"))
+		if status != tt.wantStatus || signature != tt.wantSignature {
+			t.Errorf("%s: got %s/%q, want %s/%q", tt.name, status, signature, tt.wantStatus, tt.wantSignature)
+		}
+	}
+}
+
+func TestScannedClean(t *testing.T) {
+	tests := []struct {
+		asset *models.AssetInfo
+		want  bool
+	}{
+		{nil, false},
+		{&models.AssetInfo{}, false},
+		{&models.AssetInfo{ScanStatus: models.ScanStatusPending}, false},
+		{&models.AssetInfo{ScanStatus: models.ScanStatusInfected}, false},
+		{&models.AssetInfo{ScanStatus: models.ScanStatusError}, false},
+		{&models.AssetInfo{ScanStatus: models.ScanStatusClean}, true},
+	}
+	for _, tt := range tests {
+		if got := scannedClean(tt.asset); got != tt.want {
+			t.Errorf("scannedClean(%+v) = %v, want %v", tt.asset, got, tt.want)
+		}
+	}
+}
+
+func TestRescanAssetsWithoutScanner(t *testing.T) {
+	// Without a scanner uploads are never left unscanned, so nothing is looked up
+	s := &FeedbackService{}
+	rescanned, err := s.RescanAssets(context.Background())
+	if rescanned != 0 || err != nil {
+		t.Errorf("got %d, %v", rescanned, err)
+	}
+}
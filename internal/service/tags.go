@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Ravwvil/feedback/internal/events"
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/repository"
+)
+
+// ErrInvalidTag is returned for tag names outside the allowed format
+var ErrInvalidTag = errors.New("invalid tag")
+
+// tagPattern allows short lowercase names such as "style" or "plagiarism-check"
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// normalizeTags lowercases, validates and de-duplicates tag names
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	var names []string
+
+	for _, tag := range tags {
+		name := strings.ToLower(strings.TrimSpace(tag))
+		if !tagPattern.MatchString(name) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// normalizeTagMatch defaults an empty tag filter mode to matching any tag
+func normalizeTagMatch(match string) (string, error) {
+	switch match {
+	case "", repository.TagMatchAny:
+		return repository.TagMatchAny, nil
+	case repository.TagMatchAll:
+		return repository.TagMatchAll, nil
+	default:
+		return "", fmt.Errorf("%w: unknown tag match %q", ErrInvalidTag, match)
+	}
+}
+
+// AddTags tags feedback, names missing from the lab vocabulary are added to it
+func (s *FeedbackService) AddTags(ctx context.Context, id string, tags []string) (*models.FeedbackFile, error) {
+	return s.modifyTags(ctx, id, tags, func(tx *repository.FeedbackRepository, feedback *models.FeedbackFile, names []string) error {
+		return tx.AddTags(ctx, feedback.ID, feedback.LabID, names)
+	})
+}
+
+// RemoveTags removes tags from feedback
+func (s *FeedbackService) RemoveTags(ctx context.Context, id string, tags []string) (*models.FeedbackFile, error) {
+	return s.modifyTags(ctx, id, tags, func(tx *repository.FeedbackRepository, feedback *models.FeedbackFile, names []string) error {
+		return tx.RemoveTags(ctx, feedback.ID, names)
+	})
+}
+
+func (s *FeedbackService) modifyTags(ctx context.Context, id string, tags []string, apply func(*repository.FeedbackRepository, *models.FeedbackFile, []string) error) (*models.FeedbackFile, error) {
+	names, err := normalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	feedback, err := s.getVisibleFeedback(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	caller := CallerFromContext(ctx)
+	if !canEdit(caller, feedback) {
+		return nil, fmt.Errorf("%w: cannot tag feedback %s", ErrPermissionDenied, id)
+	}
+
+	if len(names) == 0 {
+		if err := s.attachTags(ctx, feedback); err != nil {
+			return nil, err
+		}
+		return feedback, nil
+	}
+
+	err = s.repo.InTx(ctx, func(tx *repository.FeedbackRepository) error {
+		if err := apply(tx, feedback, names); err != nil {
+			return fmt.Errorf("failed to update tags: %w", err)
+		}
+
+		tags, err := tx.ListTags(ctx, []string{feedback.ID})
+		if err != nil {
+			return fmt.Errorf("failed to get feedback tags: %w", err)
+		}
+		feedback.Tags = tags[feedback.ID]
+
+		return s.recordEvent(ctx, tx, models.EventFeedbackTagsChanged, feedback, eventData{Feedback: snapshotOf(feedback)})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishEvent(ctx, events.TypeUpdated, feedback)
+
+	return feedback, nil
+}
+
+// GetLabTags returns the tag vocabulary of a lab with per-tag feedback counts, counts cover
+// feedback of every student so they are limited to instructors
+func (s *FeedbackService) GetLabTags(ctx context.Context, labID int64) ([]*models.TagCount, error) {
+	caller := CallerFromContext(ctx)
+	if !caller.IsStaff() {
+		return nil, fmt.Errorf("%w: only instructors can list lab tags", ErrPermissionDenied)
+	}
+
+	counts, err := s.repo.ListLabTagCounts(ctx, labID, caller.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lab tags: %w", err)
+	}
+	return counts, nil
+}
+
+// attachTags loads the tags of the given feedbacks with a single query
+func (s *FeedbackService) attachTags(ctx context.Context, feedbacks ...*models.FeedbackFile) error {
+	ids := make([]string, len(feedbacks))
+	for i, feedback := range feedbacks {
+		ids[i] = feedback.ID
+	}
+
+	tags, err := s.repo.ListTags(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get feedback tags: %w", err)
+	}
+
+	for _, feedback := range feedbacks {
+		feedback.Tags = tags[feedback.ID]
+	}
+	return nil
+}
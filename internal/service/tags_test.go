@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Ravwvil/feedback/internal/repository"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	got, err := normalizeTags([]string{" Style ", "late", "style", "plagiarism-check", "a_b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a_b", "late", "plagiarism-check", "style"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, tag := range []string{"", "-late", "two words", "ünicode", strings.Repeat("a", 65)} {
+		if _, err := normalizeTags([]string{tag}); !errors.Is(err, ErrInvalidTag) {
+			t.Errorf("%q: got %v, want ErrInvalidTag", tag, err)
+		}
+	}
+}
+
+func TestNormalizeTagMatch(t *testing.T) {
+	tests := map[string]string{
+		"":                     repository.TagMatchAny,
+		repository.TagMatchAny: repository.TagMatchAny,
+		repository.TagMatchAll: repository.TagMatchAll,
+	}
+	for match, want := range tests {
+		if got, err := normalizeTagMatch(match); err != nil || got != want {
+			t.Errorf("%q: got %q, %v, want %q", match, got, err, want)
+		}
+	}
+
+	if _, err := normalizeTagMatch("some"); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("got %v, want ErrInvalidTag", err)
+	}
+}
+
+func TestGetLabTagsRequiresStaff(t *testing.T) {
+	s := &FeedbackService{}
+
+	for _, caller := range []Caller{anonymous, student} {
+		_, err := s.GetLabTags(WithCaller(context.Background(), caller), 1)
+		if !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("%+v: got %v, want ErrPermissionDenied", caller, err)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"text/template"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+var (
+	// ErrTemplateNotFound is returned for missing templates and templates of other labs
+	ErrTemplateNotFound = errors.New("template not found")
+
+	// ErrInvalidTemplate is returned for templates that do not parse
+	ErrInvalidTemplate = errors.New("invalid template")
+
+	// ErrTemplateRender is returned when supplied variables do not satisfy a template
+	ErrTemplateRender = errors.New("failed to render template")
+)
+
+// placeholderPattern matches bare placeholders such as {{student_name}} or {{- score -}}
+var placeholderPattern = regexp.MustCompile(`\{\{(-?\s*)([A-Za-z_][A-Za-z0-9_]*)(\s*-?)\}\}`)
+
+// templateKeywords are actions and builtin functions that must not be treated as variables
+var templateKeywords = map[string]bool{
+	"if": true, "else": true, "end": true, "range": true, "with": true, "define": true,
+	"template": true, "block": true, "break": true, "continue": true, "nil": true,
+	"true": true, "false": true, "and": true, "or": true, "not": true, "len": true,
+	"index": true, "slice": true, "print": true, "printf": true, "println": true,
+	"html": true, "js": true, "urlquery": true, "call": true,
+	"eq": true, "ne": true, "lt": true, "le": true, "gt": true, "ge": true,
+}
+
+type TemplateParams struct {
+	ID          string // Empty when creating
+	LabID       int64  // 0 creates a global template
+	Name        string
+	Description string
+	Body        string
+}
+
+type CreateFeedbackFromTemplateParams struct {
+	TemplateID string
+	UserID     int64
+	LabID      int64
+	Title      string
+	Variables  map[string]string
+	Publish    bool
+}
+
+// parseTemplate parses a template body with text/template semantics. Bare placeholders like
+// {{student_name}} are shorthand for {{.student_name}}, referencing a variable that is not
+// supplied is an error.
+func parseTemplate(name, body string) (*template.Template, error) {
+	source := placeholderPattern.ReplaceAllStringFunc(body, func(placeholder string) string {
+		parts := placeholderPattern.FindStringSubmatch(placeholder)
+		if templateKeywords[parts[2]] {
+			return placeholder
+		}
+		return "{{" + parts[1] + "." + parts[2] + parts[3] + "}}"
+	})
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return tmpl, nil
+}
+
+// RenderTemplate renders a template body with the given variables
+func RenderTemplate(body string, variables map[string]string) (string, error) {
+	tmpl, err := parseTemplate("feedback", body)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, variables); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrTemplateRender, err)
+	}
+	return buf.String(), nil
+}
+
+// checkTemplateWrite allows instructors to manage lab templates and admins to manage global ones
+func checkTemplateWrite(caller Caller, labID int64) error {
+	if labID == 0 && caller.Role != RoleAdmin {
+		return fmt.Errorf("%w: only admins can manage global templates", ErrPermissionDenied)
+	}
+	if !caller.IsStaff() {
+		return fmt.Errorf("%w: only instructors can manage templates", ErrPermissionDenied)
+	}
+	return nil
+}
+
+func validateTemplate(params *TemplateParams) error {
+	if params.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidTemplate)
+	}
+	if params.Body == "" {
+		return fmt.Errorf("%w: body is required", ErrInvalidTemplate)
+	}
+	_, err := parseTemplate(params.Name, params.Body)
+	return err
+}
+
+func (s *FeedbackService) CreateTemplate(ctx context.Context, params *TemplateParams) (*models.FeedbackTemplate, error) {
+	caller := CallerFromContext(ctx)
+	if err := checkTemplateWrite(caller, params.LabID); err != nil {
+		return nil, err
+	}
+	if err := validateTemplate(params); err != nil {
+		return nil, err
+	}
+
+	tmpl := &models.FeedbackTemplate{
+		LabID:       params.LabID,
+		Name:        params.Name,
+		Description: params.Description,
+		Body:        params.Body,
+		CreatedBy:   caller.UserID,
+	}
+	if err := s.repo.CreateTemplate(ctx, tmpl); err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+func (s *FeedbackService) GetTemplate(ctx context.Context, id string) (*models.FeedbackTemplate, error) {
+	tmpl, err := s.repo.GetTemplate(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// UpdateTemplate replaces name, description and body, the lab of a template never changes
+func (s *FeedbackService) UpdateTemplate(ctx context.Context, params *TemplateParams) (*models.FeedbackTemplate, error) {
+	tmpl, err := s.GetTemplate(ctx, params.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkTemplateWrite(CallerFromContext(ctx), tmpl.LabID); err != nil {
+		return nil, err
+	}
+	if err := validateTemplate(params); err != nil {
+		return nil, err
+	}
+
+	tmpl.Name = params.Name
+	tmpl.Description = params.Description
+	tmpl.Body = params.Body
+	if err := s.repo.UpdateTemplate(ctx, tmpl); err != nil {
+		return nil, fmt.Errorf("failed to update template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+func (s *FeedbackService) DeleteTemplate(ctx context.Context, id string) error {
+	tmpl, err := s.GetTemplate(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := checkTemplateWrite(CallerFromContext(ctx), tmpl.LabID); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteTemplate(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	return nil
+}
+
+// ListTemplates returns the templates usable for a lab, lab templates first
+func (s *FeedbackService) ListTemplates(ctx context.Context, labID int64, includeGlobal bool) ([]*models.FeedbackTemplate, error) {
+	templates, err := s.repo.ListTemplates(ctx, labID, includeGlobal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	return templates, nil
+}
+
+// CreateFeedbackFromTemplate renders a template with the supplied variables and creates feedback from it
+func (s *FeedbackService) CreateFeedbackFromTemplate(ctx context.Context, params *CreateFeedbackFromTemplateParams) (*models.FeedbackFile, error) {
+	tmpl, err := s.GetTemplate(ctx, params.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lab templates may only be used for feedback on their own lab
+	if tmpl.LabID != 0 && tmpl.LabID != params.LabID {
+		return nil, fmt.Errorf("%w: %s does not belong to lab %d", ErrTemplateNotFound, tmpl.ID, params.LabID)
+	}
+
+	content, err := RenderTemplate(tmpl.Body, params.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	title := params.Title
+	if title == "" {
+		title = tmpl.Name
+	}
+
+	return s.CreateFeedback(ctx, &CreateFeedbackParams{
+		UserID:      params.UserID,
+		LabID:       params.LabID,
+		Title:       title,
+		Content:     content,
+		ContentHash: fmt.Sprintf("%x", sha256.Sum256([]byte(content))),
+		Publish:     params.Publish,
+	})
+}
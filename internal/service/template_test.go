@@ -0,0 +1,88 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	variables := map[string]string{"student_name": "Ada", "lab_title": "Lab 1", "score": "9"}
+
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"Hi {{student_name}}, {{lab_title}}: {{score}}/10", "Hi Ada, Lab 1: 9/10"},
+		{"Hi {{.student_name}}", "Hi Ada"},
+		{"a {{- score -}} b", "a9b"},
+		{"{{ score }}", "9"},
+		{"{{if .score}}scored{{else}}pending{{end}}", "scored"},
+		{"{{range $k, $v := .}}{{end}}done", "done"},
+		{"no placeholders", "no placeholders"},
+	}
+
+	for _, tt := range tests {
+		got, err := RenderTemplate(tt.body, variables)
+		if err != nil {
+			t.Errorf("%q: %v", tt.body, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestRenderTemplateErrors(t *testing.T) {
+	variables := map[string]string{"student_name": "Ada"}
+
+	if _, err := RenderTemplate("{{score}}", variables); !errors.Is(err, ErrTemplateRender) {
+		t.Errorf("missing variable: got %v, want ErrTemplateRender", err)
+	}
+	for _, body := range []string{"{{student_name", "{{if .student_name}}", "{{score | nope}}"} {
+		if _, err := RenderTemplate(body, variables); !errors.Is(err, ErrInvalidTemplate) {
+			t.Errorf("%q: got %v, want ErrInvalidTemplate", body, err)
+		}
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	if err := validateTemplate(&TemplateParams{Name: "intro", Body: "Hi {{student_name}}"}); err != nil {
+		t.Error(err)
+	}
+
+	for _, params := range []*TemplateParams{
+		{Body: "Hi"},
+		{Name: "intro"},
+		{Name: "intro", Body: "{{end}}"},
+	} {
+		if err := validateTemplate(params); !errors.Is(err, ErrInvalidTemplate) {
+			t.Errorf("%+v: got %v, want ErrInvalidTemplate", params, err)
+		}
+	}
+}
+
+func TestCheckTemplateWrite(t *testing.T) {
+	tests := []struct {
+		caller Caller
+		labID  int64
+		ok     bool
+	}{
+		{instructor, 1, true},
+		{admin, 1, true},
+		{admin, 0, true},
+		{instructor, 0, false},
+		{student, 1, false},
+		{anonymous, 1, false},
+	}
+
+	for _, tt := range tests {
+		err := checkTemplateWrite(tt.caller, tt.labID)
+		if tt.ok && err != nil {
+			t.Errorf("%+v lab %d: %v", tt.caller, tt.labID, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("%+v lab %d: got %v, want ErrPermissionDenied", tt.caller, tt.labID, err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/thumbnail"
+)
+
+// ErrThumbnailNotFound is returned when no thumbnail is available for an asset
+var ErrThumbnailNotFound = errors.New("thumbnail not found")
+
+const thumbnailTimeout = 2 * time.Minute
+
+func thumbnailPath(filename string, size int) string {
+	return fmt.Sprintf("thumbnails/%d/%s.jpg", size, filename)
+}
+
+// startThumbnails generates thumbnails in the background when a slot is free. Uploads arriving
+// while all slots are busy get no thumbnails rather than piling up goroutines holding their data.
+func (s *FeedbackService) startThumbnails(feedbackID, filename, contentHash string, data []byte) {
+	select {
+	case s.thumbnailSlots <- struct{}{}:
+	default:
+		log.Printf("Skipping thumbnails for %s/%s: too many images in progress", feedbackID, filename)
+		return
+	}
+
+	go func() {
+		defer func() { <-s.thumbnailSlots }()
+		s.generateThumbnails(feedbackID, filename, contentHash, data)
+	}()
+}
+
+// generateThumbnails renders and stores previews of an uploaded image
+func (s *FeedbackService) generateThumbnails(feedbackID, filename, contentHash string, data []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), thumbnailTimeout)
+	defer cancel()
+
+	thumbnails, err := s.thumbnails.Generate(data)
+	if err != nil {
+		log.Printf("Failed to generate thumbnails for %s/%s: %v", feedbackID, filename, err)
+		return
+	}
+
+	// Do not overwrite the thumbnails of content uploaded after this one
+	if !s.assetUnchanged(ctx, feedbackID, filename, contentHash) {
+		return
+	}
+
+	sizes := make([]int, 0, len(thumbnails))
+	for size, thumb := range thumbnails {
+		err := s.minioClient.UploadFile(ctx, feedbackID, thumbnailPath(filename, size), thumbnail.ContentType, thumb)
+		if err != nil {
+			log.Printf("Failed to store %dpx thumbnail for %s/%s: %v", size, feedbackID, filename, err)
+			continue
+		}
+		sizes = append(sizes, size)
+	}
+	sort.Ints(sizes)
+
+	if err := s.repo.SetAssetThumbnails(ctx, feedbackID, filename, contentHash, sizes); err != nil {
+		log.Printf("Failed to record thumbnails for %s/%s: %v", feedbackID, filename, err)
+	}
+}
+
+// assetUnchanged reports whether the stored asset still has the given content
+func (s *FeedbackService) assetUnchanged(ctx context.Context, feedbackID, filename, contentHash string) bool {
+	asset, err := s.repo.GetAsset(ctx, feedbackID, filename)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to get asset %s/%s: %v", feedbackID, filename, err)
+		}
+		return false
+	}
+	return asset.ContentHash == contentHash
+}
+
+// GetAssetThumbnail returns the thumbnail closest to the requested size, 0 selects the smallest one
+func (s *FeedbackService) GetAssetThumbnail(ctx context.Context, feedbackID, filename string, size int) ([]byte, int, error) {
+	if _, err := s.getVisibleFeedback(ctx, feedbackID); err != nil {
+		return nil, 0, err
+	}
+
+	asset, err := s.repo.GetAsset(ctx, feedbackID, filename)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, ErrThumbnailNotFound
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get asset metadata: %w", err)
+	}
+
+	selected, ok := selectThumbnailSize(asset.ThumbnailSizes, size)
+	if !ok {
+		return nil, 0, ErrThumbnailNotFound
+	}
+
+	data, err := s.minioClient.DownloadFile(ctx, feedbackID, thumbnailPath(filename, selected))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to download thumbnail: %w", err)
+	}
+
+	return data, selected, nil
+}
+
+// selectThumbnailSize picks the smallest available size not below the requested one,
+// falling back to the largest available size. Sizes are expected in ascending order.
+func selectThumbnailSize(available []int, requested int) (int, bool) {
+	if len(available) == 0 {
+		return 0, false
+	}
+
+	for _, size := range available {
+		if size >= requested {
+			return size, true
+		}
+	}
+
+	return available[len(available)-1], true
+}
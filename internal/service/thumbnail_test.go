@@ -0,0 +1,40 @@
+package service
+
+import "testing"
+
+func TestSelectThumbnailSize(t *testing.T) {
+	available := []int{128, 512}
+
+	tests := []struct {
+		requested int
+		want      int
+	}{
+		{0, 128},
+		{100, 128},
+		{128, 128},
+		{300, 512},
+		{1024, 512},
+	}
+	for _, tt := range tests {
+		got, ok := selectThumbnailSize(available, tt.requested)
+		if !ok || got != tt.want {
+			t.Errorf("requested %d: got %d, %v, want %d", tt.requested, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := selectThumbnailSize(nil, 128); ok {
+		t.Error("expected no thumbnail without available sizes")
+	}
+}
+
+func TestStartThumbnailsSkipsWhenBusy(t *testing.T) {
+	// The generator is nil, so a started goroutine would panic
+	s := &FeedbackService{thumbnailSlots: make(chan struct{}, 1)}
+	s.thumbnailSlots <- struct{}{}
+
+	s.startThumbnails("f", "a.png", "hash", []byte("data"))
+
+	if len(s.thumbnailSlots) != 1 {
+		t.Fatalf("got %d busy slots, want 1", len(s.thumbnailSlots))
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/events"
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/pagination"
+	"github.com/Ravwvil/feedback/internal/repository"
+)
+
+// purgeBatchSize limits how many trashed feedbacks are loaded per purge query
+const purgeBatchSize = 100
+
+type ListTrashParams struct {
+	UserID int64
+	LabID  int64
+
+	PageSize          int
+	PageToken         string
+	IncludeTotalCount bool
+}
+
+// ListTrash lists deleted feedback awaiting purge. Admins see the whole trash,
+// other callers only feedback they wrote or deleted.
+func (s *FeedbackService) ListTrash(ctx context.Context, params *ListTrashParams) (*Page[*models.FeedbackFile], error) {
+	caller := CallerFromContext(ctx)
+	if caller.UserID == 0 {
+		return nil, fmt.Errorf("%w: trash requires an authenticated caller", ErrPermissionDenied)
+	}
+
+	filter := repository.TrashFilter{
+		UserID: params.UserID,
+		LabID:  params.LabID,
+	}
+	if caller.Role != RoleAdmin {
+		filter.ViewerID = caller.UserID
+	}
+
+	query := pagination.QueryFingerprint("trash", filter.UserID, filter.LabID, filter.ViewerID)
+	position, err := pagination.Decode(params.PageToken, query, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	var after *repository.TrashCursor
+	if position != nil {
+		deletedAt, err := pagination.ParseTime(position[0])
+		if err != nil {
+			return nil, err
+		}
+		id, err := pagination.ParseID(position[1])
+		if err != nil {
+			return nil, err
+		}
+		after = &repository.TrashCursor{DeletedAt: deletedAt, ID: id}
+	}
+
+	pageSize := pagination.PageSize(params.PageSize)
+	feedbacks, err := s.repo.ListTrash(ctx, filter, after, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := newPage(feedbacks, pageSize, query, func(feedback *models.FeedbackFile) []string {
+		return []string{pagination.FormatTime(*feedback.DeletedAt), feedback.ID}
+	})
+
+	if params.IncludeTotalCount {
+		if page.TotalCount, err = s.repo.CountTrash(ctx, filter); err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+// RestoreFeedback takes feedback out of the trash in the status it was deleted in
+func (s *FeedbackService) RestoreFeedback(ctx context.Context, id string) (*models.FeedbackFile, error) {
+	feedback, err := s.repo.GetTrashed(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s is not in the trash", ErrFeedbackNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feedback metadata: %w", err)
+	}
+
+	caller := CallerFromContext(ctx)
+	deletedByCaller := caller.UserID != 0 && caller.UserID == feedback.DeletedBy
+	if !isAuthor(caller, feedback) && !deletedByCaller && !caller.IsStaff() {
+		return nil, fmt.Errorf("%w: cannot restore feedback %s", ErrPermissionDenied, id)
+	}
+
+	var restored *models.FeedbackFile
+	err = s.repo.InTx(ctx, func(tx *repository.FeedbackRepository) error {
+		var err error
+		restored, err = tx.Restore(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s is not in the trash", ErrFeedbackNotFound, id)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to restore feedback: %w", err)
+		}
+
+		return s.recordEvent(ctx, tx, models.EventFeedbackRestored, restored, eventData{Feedback: snapshotOf(restored)})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Watchers saw the deletion, to them the restored feedback is new again
+	s.publishEvent(ctx, events.TypeCreated, restored)
+	return restored, nil
+}
+
+// PurgeExpiredTrash permanently removes feedback that has been in the trash longer than
+// the retention period and returns how many were purged
+func (s *FeedbackService) PurgeExpiredTrash(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-s.trashRetention)
+	purged := 0
+
+	for {
+		ids, err := s.repo.ListExpiredTrash(ctx, cutoff, purgeBatchSize)
+		if err != nil {
+			return purged, fmt.Errorf("failed to list expired trash: %w", err)
+		}
+
+		batchPurged := 0
+		for _, id := range ids {
+			if err := s.purgeFeedback(ctx, id); err != nil {
+				// Leave it for the next run, the remaining feedback can still be purged
+				log.Printf("Failed to purge feedback %s: %v", id, err)
+				continue
+			}
+			batchPurged++
+		}
+		purged += batchPurged
+
+		// Stop once the backlog is drained or a whole batch keeps failing
+		if len(ids) < purgeBatchSize || batchPurged == 0 {
+			return purged, nil
+		}
+	}
+}
+
+// RunPurgeJob purges expired trash every interval until ctx is cancelled
+func (s *FeedbackService) RunPurgeJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeExpiredTrash(ctx)
+			if err != nil {
+				log.Printf("Failed to purge trash: %v", err)
+			}
+			if purged > 0 {
+				log.Printf("Purged %d feedbacks from trash", purged)
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+var (
+	// ErrUnknownUser is returned for user ids the User Service does not know
+	ErrUnknownUser = errors.New("unknown user")
+
+	// ErrUnknownLab is returned for lab ids the Lab Service does not know
+	ErrUnknownLab = errors.New("unknown lab")
+
+	// ErrDependencyUnavailable is returned when ids cannot be validated and FailOpen is not set
+	ErrDependencyUnavailable = errors.New("dependency unavailable")
+)
+
+// lookupConcurrency bounds the existence lookups of one request that run at the same time
+const lookupConcurrency = 10
+
+// validateUsers checks user ids against the User Service, without one every id is accepted
+func (s *FeedbackService) validateUsers(ctx context.Context, userIDs ...int64) error {
+	if s.users == nil {
+		return nil
+	}
+	return s.validateIDs(ctx, "user", userIDs, s.users.UserExists, ErrUnknownUser)
+}
+
+// validateLabs checks lab ids against the Lab Service, without one every id is accepted
+func (s *FeedbackService) validateLabs(ctx context.Context, labIDs ...int64) error {
+	if s.labs == nil {
+		return nil
+	}
+	return s.validateIDs(ctx, "lab", labIDs, s.labs.LabExists, ErrUnknownLab)
+}
+
+// validateIDs looks up every distinct id concurrently and reports the first problem in the
+// order of ids, so the answer does not depend on which lookup finished first
+func (s *FeedbackService) validateIDs(ctx context.Context, kind string, ids []int64, exists func(context.Context, int64) (bool, error), unknown error) error {
+	ids = dedupeIDs(ids)
+
+	var wg sync.WaitGroup
+	found := make([]bool, len(ids))
+	errs := make([]error, len(ids))
+
+	slots := make(chan struct{}, lookupConcurrency)
+	for i, id := range ids {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, id int64) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			found[i], errs[i] = exists(ctx, id)
+		}(i, id)
+	}
+	wg.Wait()
+
+	for i, id := range ids {
+		if err := errs[i]; err != nil {
+			if s.failOpen {
+				log.Printf("Accepting %s %d unvalidated: %v", kind, id, err)
+				continue
+			}
+			return fmt.Errorf("%w: cannot validate %s %d: %v", ErrDependencyUnavailable, kind, id, err)
+		}
+		if !found[i] {
+			return fmt.Errorf("%w: %d", unknown, id)
+		}
+	}
+	return nil
+}
+
+// dedupeIDs returns ids without repetitions, in the order they first appear
+func dedupeIDs(ids []int64) []int64 {
+	if len(ids) < 2 {
+		return ids
+	}
+
+	seen := make(map[int64]bool, len(ids))
+	unique := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// validateReferences checks the user and lab a feedback is about
+func (s *FeedbackService) validateReferences(ctx context.Context, userID, labID int64) error {
+	if err := s.validateUsers(ctx, userID); err != nil {
+		return err
+	}
+	return s.validateLabs(ctx, labID)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/lookup"
+)
+
+// countingUsers wraps a UserService and records the lookups and how many ran at once
+type countingUsers struct {
+	users lookup.UserService
+
+	mu      sync.Mutex
+	calls   map[int64]int
+	running int
+	peak    int
+}
+
+func newCountingUsers(users lookup.UserService) *countingUsers {
+	return &countingUsers{users: users, calls: make(map[int64]int)}
+}
+
+func (c *countingUsers) UserExists(ctx context.Context, userID int64) (bool, error) {
+	c.mu.Lock()
+	c.calls[userID]++
+	c.running++
+	if c.running > c.peak {
+		c.peak = c.running
+	}
+	c.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	c.mu.Lock()
+	c.running--
+	c.mu.Unlock()
+	return c.users.UserExists(ctx, userID)
+}
+
+func TestValidateUsersFailOpen(t *testing.T) {
+	users := lookup.NewMemoryUsers(1)
+	users.SetErr(errors.New("connection refused"))
+
+	closed := &FeedbackService{users: users}
+	if err := closed.validateUsers(context.Background(), 1); !errors.Is(err, ErrDependencyUnavailable) {
+		t.Errorf("fail closed: got %v, want ErrDependencyUnavailable", err)
+	}
+
+	open := &FeedbackService{users: users, failOpen: true}
+	if err := open.validateUsers(context.Background(), 1, 2); err != nil {
+		t.Errorf("fail open: got %v, want the ids accepted", err)
+	}
+
+	// Answers the service did give are still enforced when failing open
+	users.SetErr(nil)
+	if err := open.validateUsers(context.Background(), 1, 2); !errors.Is(err, ErrUnknownUser) {
+		t.Errorf("fail open: got %v, want ErrUnknownUser", err)
+	}
+}
+
+func TestValidateLabsFailOpen(t *testing.T) {
+	labs := lookup.NewMemoryLabs(10)
+	labs.SetErr(errors.New("deadline exceeded"))
+
+	if err := (&FeedbackService{labs: labs}).validateLabs(context.Background(), 10); !errors.Is(err, ErrDependencyUnavailable) {
+		t.Errorf("fail closed: got %v, want ErrDependencyUnavailable", err)
+	}
+	if err := (&FeedbackService{labs: labs, failOpen: true}).validateLabs(context.Background(), 10); err != nil {
+		t.Errorf("fail open: got %v, want the id accepted", err)
+	}
+
+	labs.SetErr(nil)
+	if err := (&FeedbackService{labs: labs}).validateLabs(context.Background(), 10, 11); !errors.Is(err, ErrUnknownLab) {
+		t.Errorf("got %v, want ErrUnknownLab", err)
+	}
+}
+
+func TestValidateUsersWithoutService(t *testing.T) {
+	if err := (&FeedbackService{}).validateUsers(context.Background(), 1, 2); err != nil {
+		t.Errorf("got %v, want every id accepted", err)
+	}
+	if err := (&FeedbackService{}).validateLabs(context.Background(), 10); err != nil {
+		t.Errorf("got %v, want every id accepted", err)
+	}
+}
+
+func TestValidateUsersLooksUpDistinctIDsConcurrently(t *testing.T) {
+	var ids []int64
+	for id := int64(1); id <= 50; id++ {
+		ids = append(ids, id, id)
+	}
+	users := newCountingUsers(lookup.NewMemoryUsers(ids...))
+
+	if err := (&FeedbackService{users: users}).validateUsers(context.Background(), ids...); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(users.calls) != 50 {
+		t.Errorf("got %d ids looked up, want 50", len(users.calls))
+	}
+	for id, calls := range users.calls {
+		if calls != 1 {
+			t.Errorf("user %d looked up %d times", id, calls)
+		}
+	}
+	if users.peak < 2 || users.peak > lookupConcurrency {
+		t.Errorf("%d lookups ran at once, want between 2 and %d", users.peak, lookupConcurrency)
+	}
+}
+
+func TestValidateUsersReportsFirstUnknownID(t *testing.T) {
+	s := &FeedbackService{users: lookup.NewMemoryUsers(1, 2, 3)}
+
+	for i := 0; i < 10; i++ {
+		err := s.validateUsers(context.Background(), 1, 7, 2, 9)
+		if !errors.Is(err, ErrUnknownUser) || err.Error() != "unknown user: 7" {
+			t.Fatalf("got %v, want user 7 reported", err)
+		}
+	}
+}
+
+func TestDedupeIDs(t *testing.T) {
+	tests := []struct {
+		ids, want []int64
+	}{
+		{nil, nil},
+		{[]int64{3}, []int64{3}},
+		{[]int64{3, 1, 3, 2, 1}, []int64{3, 1, 2}},
+	}
+	for _, tt := range tests {
+		if got := dedupeIDs(tt.ids); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: got %v, want %v", tt.ids, got, tt.want)
+		}
+	}
+}
+
+func TestListFeedbacksLimitsFilterIDs(t *testing.T) {
+	ctx := WithCaller(context.Background(), instructor)
+
+	var tooMany []int64
+	for id := int64(1); id <= maxFilterIDs+1; id++ {
+		tooMany = append(tooMany, id)
+	}
+	s := &FeedbackService{users: lookup.NewMemoryUsers(), labs: lookup.NewMemoryLabs()}
+	for name, params := range map[string]*ListFeedbacksParams{
+		"users": {UserIDs: tooMany},
+		"labs":  {LabIDs: tooMany},
+	} {
+		if _, err := s.ListFeedbacks(ctx, params); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("%s: got %v, want ErrInvalidFilter", name, err)
+		}
+	}
+
+	// Repeated ids count once, the filter gets as far as validating them
+	repeated := append(tooMany[:maxFilterIDs:maxFilterIDs], tooMany[:maxFilterIDs]...)
+	if _, err := s.ListFeedbacks(ctx, &ListFeedbacksParams{UserIDs: repeated}); !errors.Is(err, ErrUnknownUser) {
+		t.Errorf("got %v, want the ids validated", err)
+	}
+}
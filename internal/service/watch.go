@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/events"
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+// WatchFeedback subscribes to the changes of one feedback, the caller closes the subscription
+func (s *FeedbackService) WatchFeedback(ctx context.Context, id string) (*events.Subscription, error) {
+	feedback, err := s.getVisibleFeedback(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	caller := CallerFromContext(ctx)
+	return s.eventBus.Subscribe(func(event events.Event) bool {
+		return event.FeedbackID == feedback.ID && canSeeEvent(caller, event)
+	}), nil
+}
+
+// WatchLab subscribes to the changes of all feedback of a lab. Instructors and admins
+// see every feedback, students only feedback they received or wrote.
+func (s *FeedbackService) WatchLab(ctx context.Context, labID int64) (*events.Subscription, error) {
+	caller := CallerFromContext(ctx)
+	if caller.UserID == 0 {
+		return nil, fmt.Errorf("%w: watching a lab requires an authenticated caller", ErrPermissionDenied)
+	}
+
+	return s.eventBus.Subscribe(func(event events.Event) bool {
+		if event.LabID != labID || !canSeeEvent(caller, event) {
+			return false
+		}
+		return caller.IsStaff() || event.UserID == caller.UserID || event.AuthorID == caller.UserID
+	}), nil
+}
+
+// canSeeEvent hides changes of drafts from everyone but their author, deletions included
+func canSeeEvent(caller Caller, event events.Event) bool {
+	if event.Status != models.StatusDraft {
+		return true
+	}
+	return caller.UserID != 0 && event.AuthorID == caller.UserID
+}
+
+// publishEvent announces a change of feedback to watchers. Events are best effort,
+// a failure is logged and does not fail the change that was already made.
+func (s *FeedbackService) publishEvent(ctx context.Context, eventType string, feedback *models.FeedbackFile) {
+	s.publish(ctx, events.Event{
+		Type:       eventType,
+		FeedbackID: feedback.ID,
+		LabID:      feedback.LabID,
+		UserID:     feedback.UserID,
+		AuthorID:   feedback.AuthorID,
+		Status:     feedback.Status,
+		OccurredAt: time.Now().UTC(),
+	})
+}
+
+// publishAssetEvent announces an uploaded asset, the feedback is loaded for the lab and recipient
+func (s *FeedbackService) publishAssetEvent(ctx context.Context, feedbackID, filename string) {
+	feedback, err := s.repo.GetByID(ctx, feedbackID)
+	if err != nil {
+		log.Printf("Failed to publish %s event for %s: %v", events.TypeAssetAdded, feedbackID, err)
+		return
+	}
+
+	s.publish(ctx, events.Event{
+		Type:       events.TypeAssetAdded,
+		FeedbackID: feedback.ID,
+		LabID:      feedback.LabID,
+		UserID:     feedback.UserID,
+		AuthorID:   feedback.AuthorID,
+		Status:     feedback.Status,
+		Filename:   filename,
+		OccurredAt: time.Now().UTC(),
+	})
+}
+
+func (s *FeedbackService) publish(ctx context.Context, event events.Event) {
+	if err := s.eventPublisher.Publish(ctx, event); err != nil {
+		log.Printf("Failed to publish %s event for %s: %v", event.Type, event.FeedbackID, err)
+	}
+}
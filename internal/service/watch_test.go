@@ -0,0 +1,33 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Ravwvil/feedback/internal/events"
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+func TestCanSeeEvent(t *testing.T) {
+	tests := []struct {
+		name   string
+		caller Caller
+		event  events.Event
+		want   bool
+	}{
+		{"published", student, events.Event{Type: events.TypeUpdated, Status: models.StatusPublished, AuthorID: author.UserID}, true},
+		{"published deletion", anonymous, events.Event{Type: events.TypeDeleted, Status: models.StatusPublished}, true},
+		{"own draft", author, events.Event{Type: events.TypeUpdated, Status: models.StatusDraft, AuthorID: author.UserID}, true},
+		{"own draft deletion", author, events.Event{Type: events.TypeDeleted, Status: models.StatusDraft, AuthorID: author.UserID}, true},
+		{"other author's draft", recipient, events.Event{Type: events.TypeUpdated, Status: models.StatusDraft, AuthorID: author.UserID}, false},
+		{"other author's draft deletion", instructor, events.Event{Type: events.TypeDeleted, Status: models.StatusDraft, AuthorID: author.UserID}, false},
+		{"anonymous draft deletion", anonymous, events.Event{Type: events.TypeDeleted, Status: models.StatusDraft}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canSeeEvent(tt.caller, tt.event); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/pagination"
+	"github.com/Ravwvil/feedback/internal/repository"
+	"github.com/Ravwvil/feedback/internal/webhook"
+)
+
+var (
+	// ErrWebhookNotFound is returned for missing webhook subscriptions
+	ErrWebhookNotFound = errors.New("webhook not found")
+
+	// ErrInvalidWebhook is returned for subscriptions with a bad url, secret or event type
+	ErrInvalidWebhook = errors.New("invalid webhook")
+)
+
+// minSecretLength keeps client supplied secrets from being guessable
+const minSecretLength = 16
+
+// webhookEventTypes are the domain event types a subscription can select
+var webhookEventTypes = map[string]bool{
+	models.EventFeedbackCreated:       true,
+	models.EventFeedbackUpdated:       true,
+	models.EventFeedbackStatusChanged: true,
+	models.EventFeedbackTagsChanged:   true,
+	models.EventFeedbackScored:        true,
+	models.EventFeedbackDeleted:       true,
+	models.EventFeedbackRestored:      true,
+	models.EventFeedbackPurged:        true,
+	models.EventAssetUploaded:         true,
+	models.EventCommentCreated:        true,
+}
+
+type WebhookParams struct {
+	ID         string // Empty when creating
+	URL        string
+	Secret     string   // Generated when empty on create, kept when empty on update
+	EventTypes []string // Empty for every event type
+	LabID      int64    // 0 receives every lab, fixed after creation
+	Enabled    *bool    // Update only, nil keeps the current state
+}
+
+type ListWebhookDeliveriesParams struct {
+	WebhookID         string
+	PageSize          int
+	PageToken         string
+	IncludeTotalCount bool
+}
+
+// checkWebhookWrite allows admins only to manage webhooks. Instructors are not tied to their labs
+// here, so letting them in would hand every instructor the events of every lab.
+func checkWebhookWrite(caller Caller) error {
+	if caller.Role != RoleAdmin {
+		return fmt.Errorf("%w: only admins can manage webhooks", ErrPermissionDenied)
+	}
+	return nil
+}
+
+func validateWebhook(params *WebhookParams) error {
+	endpoint, err := url.Parse(params.URL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https url", ErrInvalidWebhook)
+	}
+	// Names are resolved and checked again on every delivery
+	if err := webhook.CheckHost(endpoint.Hostname()); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+	}
+	if params.Secret != "" && len(params.Secret) < minSecretLength {
+		return fmt.Errorf("%w: secret must have at least %d characters", ErrInvalidWebhook, minSecretLength)
+	}
+	for _, eventType := range params.EventTypes {
+		if !webhookEventTypes[eventType] {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, eventType)
+		}
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// CreateWebhook subscribes an endpoint to domain events. The returned subscription carries
+// the signing secret, it is not returned by any other call.
+func (s *FeedbackService) CreateWebhook(ctx context.Context, params *WebhookParams) (*models.WebhookSubscription, error) {
+	caller := CallerFromContext(ctx)
+	if err := checkWebhookWrite(caller); err != nil {
+		return nil, err
+	}
+	if err := validateWebhook(params); err != nil {
+		return nil, err
+	}
+
+	secret := params.Secret
+	if secret == "" {
+		var err error
+		if secret, err = generateSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	sub := &models.WebhookSubscription{
+		URL:        params.URL,
+		Secret:     secret,
+		EventTypes: params.EventTypes,
+		LabID:      params.LabID,
+		Enabled:    true,
+		CreatedBy:  caller.UserID,
+	}
+	if err := s.repo.CreateWebhook(ctx, sub); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return sub, nil
+}
+
+func (s *FeedbackService) GetWebhook(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	sub, err := s.repo.GetWebhook(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrWebhookNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	if err := checkWebhookWrite(CallerFromContext(ctx)); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// UpdateWebhook replaces url and event types, rotates the secret when one is given and
+// enables or disables the subscription. Enabling it resumes its pending deliveries.
+func (s *FeedbackService) UpdateWebhook(ctx context.Context, params *WebhookParams) (*models.WebhookSubscription, error) {
+	sub, err := s.GetWebhook(ctx, params.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateWebhook(params); err != nil {
+		return nil, err
+	}
+
+	sub.URL = params.URL
+	sub.EventTypes = params.EventTypes
+	if params.Secret != "" {
+		sub.Secret = params.Secret
+	}
+	if params.Enabled != nil {
+		sub.Enabled = *params.Enabled
+	}
+	if err := s.repo.UpdateWebhook(ctx, sub); err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	return sub, nil
+}
+
+// DeleteWebhook removes a subscription together with its delivery log
+func (s *FeedbackService) DeleteWebhook(ctx context.Context, id string) error {
+	if _, err := s.GetWebhook(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteWebhook(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// ListWebhooks returns the subscriptions of a lab, labID 0 lists the ones receiving every lab
+func (s *FeedbackService) ListWebhooks(ctx context.Context, labID int64) ([]*models.WebhookSubscription, error) {
+	if err := checkWebhookWrite(CallerFromContext(ctx)); err != nil {
+		return nil, err
+	}
+
+	subs, err := s.repo.ListWebhooks(ctx, labID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	return subs, nil
+}
+
+// ListWebhookDeliveries returns the delivery log of a subscription, newest first
+func (s *FeedbackService) ListWebhookDeliveries(ctx context.Context, params *ListWebhookDeliveriesParams) (*Page[*models.WebhookDelivery], error) {
+	if _, err := s.GetWebhook(ctx, params.WebhookID); err != nil {
+		return nil, err
+	}
+
+	query := pagination.QueryFingerprint("webhook_deliveries", params.WebhookID)
+	position, err := pagination.Decode(params.PageToken, query, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	var after *repository.WebhookDeliveryCursor
+	if position != nil {
+		createdAt, err := pagination.ParseTime(position[0])
+		if err != nil {
+			return nil, err
+		}
+		id, err := strconv.ParseInt(position[1], 10, 64)
+		if err != nil {
+			return nil, ErrInvalidPageToken
+		}
+		after = &repository.WebhookDeliveryCursor{CreatedAt: createdAt, ID: id}
+	}
+
+	pageSize := pagination.PageSize(params.PageSize)
+	deliveries, err := s.repo.ListWebhookDeliveries(ctx, params.WebhookID, after, pageSize+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	page := newPage(deliveries, pageSize, query, func(delivery *models.WebhookDelivery) []string {
+		return []string{pagination.FormatTime(delivery.CreatedAt), strconv.FormatInt(delivery.ID, 10)}
+	})
+
+	if params.IncludeTotalCount {
+		if page.TotalCount, err = s.repo.CountWebhookDeliveries(ctx, params.WebhookID); err != nil {
+			return nil, fmt.Errorf("failed to count webhook deliveries: %w", err)
+		}
+	}
+
+	return page, nil
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+func TestValidateWebhook(t *testing.T) {
+	tests := []struct {
+		name   string
+		params WebhookParams
+		valid  bool
+	}{
+		{"https", WebhookParams{URL: "https://hooks.example.com/feedback"}, true},
+		{"event types", WebhookParams{URL: "https://hooks.example.com", EventTypes: []string{models.EventFeedbackCreated}}, true},
+		{"relative", WebhookParams{URL: "/feedback"}, false},
+		{"ftp", WebhookParams{URL: "ftp://hooks.example.com"}, false},
+		{"localhost", WebhookParams{URL: "http://localhost:8080/hook"}, false},
+		{"loopback", WebhookParams{URL: "http://127.0.0.1/hook"}, false},
+		{"ipv6 loopback", WebhookParams{URL: "http://[::1]:9000/hook"}, false},
+		{"metadata service", WebhookParams{URL: "http://169.254.169.254/latest/meta-data"}, false},
+		{"private network", WebhookParams{URL: "https://10.0.0.5/hook"}, false},
+		{"short secret", WebhookParams{URL: "https://hooks.example.com", Secret: "short"}, false},
+		{"unknown event type", WebhookParams{URL: "https://hooks.example.com", EventTypes: []string{"feedback.read"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWebhook(&tt.params)
+			if tt.valid && err != nil {
+				t.Errorf("got %v, want valid", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidWebhook) {
+				t.Errorf("got %v, want %v", err, ErrInvalidWebhook)
+			}
+		})
+	}
+}
+
+func TestCheckWebhookWrite(t *testing.T) {
+	if err := checkWebhookWrite(admin); err != nil {
+		t.Errorf("admin: %v", err)
+	}
+	for _, caller := range []Caller{instructor, student, anonymous} {
+		if err := checkWebhookWrite(caller); !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("%+v: got %v, want %v", caller, err, ErrPermissionDenied)
+		}
+	}
+}
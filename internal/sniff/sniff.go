@@ -0,0 +1,90 @@
+package sniff
+
+import (
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// DefaultContentType is used when neither client nor content identify the type
+const DefaultContentType = "application/octet-stream"
+
+// activeTypes can execute script or markup when rendered by a browser
+var activeTypes = map[string]bool{
+	"text/html":                     true,
+	"application/xhtml+xml":         true,
+	"image/svg+xml":                 true,
+	"text/xml":                      true,
+	"application/xml":               true,
+	"text/javascript":               true,
+	"application/javascript":        true,
+	"application/x-javascript":      true,
+	"application/pdf":               true,
+	"application/x-shockwave-flash": true,
+}
+
+// aliases maps non-standard types commonly sent by clients to their canonical form
+var aliases = map[string]string{
+	"image/jpg":   "image/jpeg",
+	"image/pjpeg": "image/jpeg",
+	"image/x-png": "image/png",
+}
+
+// Detect returns the MIME type of data based on its leading magic bytes, without parameters
+func Detect(data []byte) string {
+	return baseType(mimetype.Detect(data).String())
+}
+
+// Compatible reports whether a client-declared type is consistent with the detected content.
+// Untyped declarations are always accepted, as are textual subtypes that cannot be sniffed.
+func Compatible(declared string, data []byte) bool {
+	declared = baseType(declared)
+	if alias, ok := aliases[declared]; ok {
+		declared = alias
+	}
+	if declared == "" || declared == DefaultContentType {
+		return true
+	}
+
+	detected := mimetype.Detect(data)
+	for m := detected; m != nil; m = m.Parent() {
+		if m.Is(declared) {
+			return true
+		}
+	}
+
+	// Formats such as text/markdown or text/csv are indistinguishable from plain text
+	return strings.HasPrefix(declared, "text/") && detected.Is("text/plain") && !activeTypes[declared]
+}
+
+// Allowed reports whether contentType matches an allow-list entry such as "image/png" or "image/*",
+// an empty allow-list permits every type
+func Allowed(contentType string, allowList []string) bool {
+	if len(allowList) == 0 {
+		return true
+	}
+
+	contentType = baseType(contentType)
+	for _, allowed := range allowList {
+		if allowed == "*/*" || allowed == contentType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsActive reports whether content of this type must not be rendered inline by browsers
+func IsActive(contentType string) bool {
+	return activeTypes[baseType(contentType)]
+}
+
+func baseType(contentType string) string {
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
@@ -0,0 +1,79 @@
+package sniff
+
+import "testing"
+
+var (
+	pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+	htmlDoc   = []byte("<!DOCTYPE html><html><body><script>alert(1)</script></body></html>")
+	plainText = []byte("# Heading\n\nSome notes.\n")
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{pngHeader, "image/png"},
+		{htmlDoc, "text/html"},
+		{plainText, "text/plain"},
+	}
+	for _, tt := range tests {
+		if got := Detect(tt.data); got != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.data[:8], got, tt.want)
+		}
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	tests := []struct {
+		declared string
+		data     []byte
+		want     bool
+	}{
+		{"image/png", pngHeader, true},
+		{"image/x-png", pngHeader, true},
+		{"IMAGE/PNG; charset=binary", pngHeader, true},
+		{"", htmlDoc, true},
+		{"application/octet-stream", htmlDoc, true},
+		{"image/png", htmlDoc, false},
+		{"image/jpeg", pngHeader, false},
+		{"text/markdown", plainText, true},
+		{"text/html", plainText, false},
+	}
+	for _, tt := range tests {
+		if got := Compatible(tt.declared, tt.data); got != tt.want {
+			t.Errorf("Compatible(%q, %q) = %v, want %v", tt.declared, tt.data[:8], got, tt.want)
+		}
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	allowList := []string{"image/*", "application/pdf"}
+
+	tests := []struct {
+		contentType string
+		allowList   []string
+		want        bool
+	}{
+		{"image/png", allowList, true},
+		{"application/pdf", allowList, true},
+		{"text/html", allowList, false},
+		{"imagex/png", allowList, false},
+		{"text/html", nil, true},
+		{"text/html", []string{"*/*"}, true},
+	}
+	for _, tt := range tests {
+		if got := Allowed(tt.contentType, tt.allowList); got != tt.want {
+			t.Errorf("Allowed(%q, %v) = %v, want %v", tt.contentType, tt.allowList, got, tt.want)
+		}
+	}
+}
+
+func TestIsActive(t *testing.T) {
+	if !IsActive("image/svg+xml; charset=utf-8") {
+		t.Error("svg must be active")
+	}
+	if IsActive("image/png") {
+		t.Error("png must not be active")
+	}
+}
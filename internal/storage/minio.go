@@ -5,6 +5,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/minio/minio-go/v7"
@@ -16,6 +18,14 @@ type MinIOClient struct {
 	bucketName string
 }
 
+// FileInfo describes a file inside a feedback folder
+type FileInfo struct {
+	Filename     string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
 type ObjectInfo struct {
 	Key          string
 	Size         int64
@@ -147,3 +157,101 @@ func (c *MinIOClient) ListObjects(ctx context.Context, prefix string) ([]ObjectI
 
 	return objects, nil
 }
+
+// UploadFile stores a file in the folder of a feedback
+func (c *MinIOClient) UploadFile(ctx context.Context, feedbackID, filePath, contentType string, data []byte) error {
+	return c.PutObject(ctx, feedbackObjectKey(feedbackID, filePath), data, contentType)
+}
+
+// DownloadFile reads a file of a feedback folder
+func (c *MinIOClient) DownloadFile(ctx context.Context, feedbackID, filePath string) ([]byte, error) {
+	return c.GetObject(ctx, feedbackObjectKey(feedbackID, filePath))
+}
+
+// GetFileInfo returns the size, type and modification time of a file of a feedback folder
+func (c *MinIOClient) GetFileInfo(ctx context.Context, feedbackID, filePath string) (*FileInfo, error) {
+	stat, err := c.client.StatObject(ctx, c.bucketName, feedbackObjectKey(feedbackID, filePath), minio.StatObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat object: %w", err)
+	}
+
+	return &FileInfo{
+		Filename:     path.Base(filePath),
+		Size:         stat.Size,
+		ContentType:  stat.ContentType,
+		LastModified: stat.LastModified,
+	}, nil
+}
+
+// ListFiles lists the files of a feedback folder below prefix, named relative to it
+func (c *MinIOClient) ListFiles(ctx context.Context, feedbackID, prefix string) ([]*FileInfo, error) {
+	keyPrefix := feedbackObjectKey(feedbackID, prefix) + "/"
+
+	objects, err := c.ListObjects(ctx, keyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*FileInfo, len(objects))
+	for i, object := range objects {
+		files[i] = &FileInfo{
+			Filename:     strings.TrimPrefix(object.Key, keyPrefix),
+			Size:         object.Size,
+			ContentType:  object.ContentType,
+			LastModified: object.LastModified,
+		}
+	}
+
+	return files, nil
+}
+
+// MoveFile moves a file of a feedback folder to another path in the same folder, replacing
+// any file stored there
+func (c *MinIOClient) MoveFile(ctx context.Context, feedbackID, fromPath, toPath string) error {
+	src := minio.CopySrcOptions{Bucket: c.bucketName, Object: feedbackObjectKey(feedbackID, fromPath)}
+	dst := minio.CopyDestOptions{Bucket: c.bucketName, Object: feedbackObjectKey(feedbackID, toPath)}
+	if _, err := c.client.CopyObject(ctx, dst, src); err != nil {
+		return fmt.Errorf("failed to copy object: %w", err)
+	}
+	return c.RemoveObject(ctx, src.Object)
+}
+
+// DeleteFile removes a single file of a feedback folder
+func (c *MinIOClient) DeleteFile(ctx context.Context, feedbackID, filePath string) error {
+	return c.RemoveObject(ctx, feedbackObjectKey(feedbackID, filePath))
+}
+
+// DeleteFolder removes every file of a feedback
+func (c *MinIOClient) DeleteFolder(ctx context.Context, feedbackID string) error {
+	return c.RemoveObjectsWithPrefix(ctx, feedbackObjectKey(feedbackID, "")+"/")
+}
+
+// OpenFile returns a reader streaming a file of a feedback folder without buffering it in memory
+func (c *MinIOClient) OpenFile(ctx context.Context, feedbackID, filePath string) (io.ReadCloser, error) {
+	object, err := c.client.GetObject(ctx, c.bucketName, feedbackObjectKey(feedbackID, filePath), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+
+	// GetObject is lazy, Stat surfaces missing objects before the caller starts reading
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		return nil, fmt.Errorf("failed to stat object: %w", err)
+	}
+
+	return object, nil
+}
+
+// PresignFile returns a time-limited URL for downloading a file of a feedback folder
+func (c *MinIOClient) PresignFile(ctx context.Context, feedbackID, filePath string, expiry time.Duration) (string, error) {
+	presigned, err := c.client.PresignedGetObject(ctx, c.bucketName, feedbackObjectKey(feedbackID, filePath), expiry, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign object: %w", err)
+	}
+	return presigned.String(), nil
+}
+
+// feedbackObjectKey builds the key of a file inside a feedback folder
+func feedbackObjectKey(feedbackID, filePath string) string {
+	return path.Join("feedback", feedbackID, filePath)
+}
@@ -0,0 +1,121 @@
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	// Register decoders used by image.Decode
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ContentType is the MIME type of generated thumbnails
+const ContentType = "image/jpeg"
+
+var supportedTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// DefaultMaxPixels limits the decoded size of images to about 160MB of RGBA pixels
+const DefaultMaxPixels = 40_000_000
+
+// ErrImageTooLarge is returned for images whose dimensions exceed the pixel limit
+var ErrImageTooLarge = errors.New("image too large")
+
+// Generator produces downscaled JPEG previews of images
+type Generator struct {
+	sizes     []int
+	maxPixels int64
+}
+
+// NewGenerator creates a generator for the given bounding box sizes in pixels. Images with more
+// than maxPixels pixels are rejected before decoding, 0 selects DefaultMaxPixels.
+func NewGenerator(sizes []int, maxPixels int64) *Generator {
+	if maxPixels <= 0 {
+		maxPixels = DefaultMaxPixels
+	}
+	return &Generator{
+		sizes:     sizes,
+		maxPixels: maxPixels,
+	}
+}
+
+// Sizes returns the configured thumbnail sizes
+func (g *Generator) Sizes() []int {
+	return g.sizes
+}
+
+// Supports reports whether thumbnails can be generated for the content type
+func (g *Generator) Supports(contentType string) bool {
+	return supportedTypes[contentType]
+}
+
+// Generate decodes the image once and returns an encoded thumbnail per configured size
+func (g *Generator) Generate(data []byte) (map[int][]byte, error) {
+	// A small file can declare huge dimensions, check them before allocating the pixels
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image header: %w", err)
+	}
+	if pixels := int64(config.Width) * int64(config.Height); pixels > g.maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d exceeds %d pixels", ErrImageTooLarge, config.Width, config.Height, g.maxPixels)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	thumbnails := make(map[int][]byte, len(g.sizes))
+	for _, size := range g.sizes {
+		encoded, err := encode(resize(src, size))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %dpx thumbnail: %w", size, err)
+		}
+		thumbnails[size] = encoded
+	}
+
+	return thumbnails, nil
+}
+
+// resize scales src to fit into a size x size box, never upscaling
+func resize(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width > size || height > size {
+		if width >= height {
+			height = max(1, height*size/width)
+			width = size
+		} else {
+			width = max(1, width*size/height)
+			height = size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	// JPEG has no alpha channel, flatten transparent images onto white
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	return dst
+}
+
+func encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,53 @@
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGenerate(t *testing.T) {
+	generator := NewGenerator([]int{16, 64}, 0)
+
+	thumbnails, err := generator.Generate(encodePNG(t, 200, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int][2]int{16: {16, 8}, 64: {64, 32}}
+	for size, dims := range want {
+		img, err := jpeg.Decode(bytes.NewReader(thumbnails[size]))
+		if err != nil {
+			t.Fatalf("%dpx: %v", size, err)
+		}
+		if got := img.Bounds().Size(); got.X != dims[0] || got.Y != dims[1] {
+			t.Errorf("%dpx: got %v, want %dx%d", size, got, dims[0], dims[1])
+		}
+	}
+}
+
+func TestGenerateRejectsImagesAbovePixelLimit(t *testing.T) {
+	generator := NewGenerator([]int{16}, 100*100)
+
+	if _, err := generator.Generate(encodePNG(t, 100, 100)); err != nil {
+		t.Fatalf("image at the limit: %v", err)
+	}
+
+	_, err := generator.Generate(encodePNG(t, 101, 100))
+	if !errors.Is(err, ErrImageTooLarge) {
+		t.Fatalf("got %v, want ErrImageTooLarge", err)
+	}
+}
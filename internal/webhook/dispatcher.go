@@ -0,0 +1,196 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/repository"
+)
+
+// Retry delays of failed deliveries grow exponentially between these bounds
+const (
+	minRetryDelay = 10 * time.Second
+	maxRetryDelay = time.Hour
+)
+
+// maxDrainedBody is how much of a response is read so that its connection can be reused
+const maxDrainedBody = 64 << 10
+
+// DispatcherOptions configures webhook delivery
+type DispatcherOptions struct {
+	Client       *http.Client  // Defaults to NewClient(Timeout), tests can pass an httptest client
+	Timeout      time.Duration // Per attempt
+	MaxAttempts  int           // A delivery fails for good after this many attempts
+	DisableAfter int           // Consecutive failed attempts that disable a subscription, 0 never disables
+	BatchSize    int           // Deliveries claimed per round
+	Concurrency  int           // Deliveries sent in parallel
+	PollInterval time.Duration // Pause after a round that found nothing to send
+	Retention    time.Duration // Completed deliveries are kept this long, 0 keeps them forever
+}
+
+// Dispatcher sends queued webhook deliveries
+type Dispatcher struct {
+	repo   *repository.FeedbackRepository
+	client *http.Client
+	opts   DispatcherOptions
+}
+
+func NewDispatcher(repo *repository.FeedbackRepository, opts DispatcherOptions) *Dispatcher {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 20
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+
+	client := opts.Client
+	if client == nil {
+		client = NewClient(opts.Timeout)
+	}
+
+	return &Dispatcher{
+		repo:   repo,
+		client: client,
+		opts:   opts,
+	}
+}
+
+// Run sends due deliveries until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	lastCleanup := time.Now()
+
+	for {
+		sent, err := d.DispatchOnce(ctx)
+		if err != nil {
+			log.Printf("Failed to dispatch webhooks: %v", err)
+		}
+
+		if d.opts.Retention > 0 && time.Since(lastCleanup) > time.Hour {
+			lastCleanup = time.Now()
+			if _, err := d.repo.DeleteCompletedWebhookDeliveries(ctx, time.Now().Add(-d.opts.Retention)); err != nil {
+				log.Printf("Failed to delete webhook deliveries: %v", err)
+			}
+		}
+
+		// Keep draining while there is work, otherwise wait for new deliveries
+		if sent > 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.opts.PollInterval):
+		}
+	}
+}
+
+// DispatchOnce sends one batch of due deliveries and returns how many were attempted
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	// The lease outlives the attempt, so nobody else sends the delivery meanwhile
+	pending, err := d.repo.ClaimWebhookDeliveries(ctx, d.opts.BatchSize, 2*d.opts.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, d.opts.Concurrency)
+	for _, item := range pending {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(item *repository.PendingDelivery) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			d.attempt(ctx, item)
+		}(item)
+	}
+	wg.Wait()
+
+	return len(pending), nil
+}
+
+// attempt sends a delivery once and records the outcome
+func (d *Dispatcher) attempt(ctx context.Context, item *repository.PendingDelivery) {
+	delivery := item.Delivery
+	delivery.ResponseCode, delivery.LastError = d.send(ctx, item)
+
+	switch {
+	case delivery.LastError == "":
+		delivery.Status = models.DeliveryStatusSucceeded
+	case delivery.Attempts >= d.opts.MaxAttempts:
+		delivery.Status = models.DeliveryStatusFailed
+	default:
+		delivery.Status = models.DeliveryStatusPending
+		delivery.NextAttemptAt = time.Now().Add(retryDelay(delivery.Attempts))
+	}
+
+	disabled, err := d.repo.RecordWebhookAttempt(ctx, delivery, d.opts.DisableAfter)
+	if err != nil {
+		log.Printf("Failed to record webhook delivery %d: %v", delivery.ID, err)
+		return
+	}
+	if disabled {
+		log.Printf("Disabled webhook %s after %d consecutive failures", delivery.SubscriptionID, d.opts.DisableAfter)
+	}
+}
+
+// send posts the payload and returns the response code and, unless it is 2xx, what went wrong.
+// Only the status code of a failed response is kept, its body is never stored.
+func (d *Dispatcher) send(ctx context.Context, item *repository.PendingDelivery) (int, string) {
+	delivery := item.Delivery
+	timestamp := time.Now().Unix()
+
+	ctx, cancel := context.WithTimeout(ctx, d.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, item.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err.Error()
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "feedback-webhooks/1")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(item.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainedBody))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, ""
+	}
+	return resp.StatusCode, fmt.Sprintf("unexpected response status %d", resp.StatusCode)
+}
+
+// retryDelay doubles the delay with every failed attempt
+func retryDelay(attempts int) time.Duration {
+	delay := minRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
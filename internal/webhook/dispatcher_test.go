@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/repository"
+)
+
+func pendingDelivery(url string) *repository.PendingDelivery {
+	return &repository.PendingDelivery{
+		Delivery: &models.WebhookDelivery{
+			ID:        7,
+			EventType: models.EventFeedbackCreated,
+			Payload:   []byte(`{"id":"e1"}`),
+		},
+		URL:    url,
+		Secret: "0123456789abcdef0123456789abcdef",
+	}
+}
+
+func TestSendSignsTheDelivery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+
+		if r.Header.Get(HeaderEvent) != models.EventFeedbackCreated || r.Header.Get(HeaderDelivery) != "7" ||
+			!Verify("0123456789abcdef0123456789abcdef", timestamp, body, r.Header.Get(HeaderSignature)) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dispatcher := NewDispatcher(nil, DispatcherOptions{Client: server.Client(), Timeout: time.Second})
+	code, lastError := dispatcher.send(context.Background(), pendingDelivery(server.URL))
+	if code != http.StatusNoContent || lastError != "" {
+		t.Errorf("got %d %q, want a successful delivery", code, lastError)
+	}
+}
+
+func TestSendStoresOnlyTheStatusCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(w, "db password is hunter2")
+	}))
+	defer server.Close()
+
+	dispatcher := NewDispatcher(nil, DispatcherOptions{Client: server.Client(), Timeout: time.Second})
+	code, lastError := dispatcher.send(context.Background(), pendingDelivery(server.URL))
+	if code != http.StatusInternalServerError {
+		t.Errorf("got status %d, want 500", code)
+	}
+	if lastError == "" || strings.Contains(lastError, "hunter2") {
+		t.Errorf("got last error %q, want the status without the body", lastError)
+	}
+}
+
+func TestSendRefusesInternalTargets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("delivery reached a loopback server")
+	}))
+	defer server.Close()
+
+	// Without a client the dispatcher uses the guarded one
+	dispatcher := NewDispatcher(nil, DispatcherOptions{Timeout: time.Second})
+	code, lastError := dispatcher.send(context.Background(), pendingDelivery(server.URL))
+	if code != 0 || !strings.Contains(lastError, ErrForbiddenTarget.Error()) {
+		t.Errorf("got %d %q, want the target refused", code, lastError)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := map[int]time.Duration{
+		1:  minRetryDelay,
+		2:  2 * minRetryDelay,
+		4:  8 * minRetryDelay,
+		20: maxRetryDelay,
+	}
+	for attempts, want := range tests {
+		if got := retryDelay(attempts); got != want {
+			t.Errorf("retryDelay(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenTarget is returned for webhook targets on loopback, private, link-local or
+// unspecified addresses, which would let subscribers reach internal services
+var ErrForbiddenTarget = errors.New("webhook target address is not allowed")
+
+var (
+	// forbiddenPrefixes are non-public IPv4 ranges the netip predicates do not cover
+	forbiddenPrefixes = []netip.Prefix{
+		netip.MustParsePrefix("0.0.0.0/8"),     // "This network"
+		netip.MustParsePrefix("100.64.0.0/10"), // Carrier-grade NAT
+	}
+
+	nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+	sixToFour   = netip.MustParsePrefix("2002::/16")
+)
+
+// CheckAddress rejects addresses that webhooks must not be sent to. IPv6 addresses embedding an
+// IPv4 address, mapped, NAT64 or 6to4, are judged by the embedded address.
+func CheckAddress(addr netip.Addr) error {
+	if embedded, ok := embeddedIPv4(addr); ok {
+		if err := CheckAddress(embedded); err != nil {
+			return fmt.Errorf("%w: %s", ErrForbiddenTarget, addr)
+		}
+		return nil
+	}
+
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, addr)
+	}
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("%w: %s", ErrForbiddenTarget, addr)
+		}
+	}
+	return nil
+}
+
+// embeddedIPv4 returns the IPv4 address carried by an IPv4-mapped, NAT64 or 6to4 address
+func embeddedIPv4(addr netip.Addr) (netip.Addr, bool) {
+	if addr.Is4In6() {
+		return addr.Unmap(), true
+	}
+
+	bytes := addr.As16()
+	switch {
+	case nat64Prefix.Contains(addr):
+		return netip.AddrFrom4([4]byte(bytes[12:16])), true
+	case sixToFour.Contains(addr):
+		return netip.AddrFrom4([4]byte(bytes[2:6])), true
+	}
+	return netip.Addr{}, false
+}
+
+// CheckHost rejects hosts that are known not to be allowed without resolving them, addresses
+// and localhost names. Other names are checked against their resolved address on every dial.
+func CheckHost(host string) error {
+	name := strings.TrimSuffix(strings.ToLower(host), ".")
+	if name == "localhost" || strings.HasSuffix(name, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, host)
+	}
+
+	addr, err := netip.ParseAddr(strings.Trim(host, "[]"))
+	if err != nil {
+		return nil
+	}
+	return CheckAddress(addr)
+}
+
+// dialControl checks the resolved address right before a connection is made, so that DNS
+// answers changing after validation cannot redirect deliveries to internal addresses
+func dialControl(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, address)
+	}
+	return CheckAddress(addrPort.Addr())
+}
+
+// NewClient returns the HTTP client deliveries are sent with. It only connects to public
+// addresses, ignores proxy settings and does not follow redirects.
+func NewClient(timeout time.Duration) *http.Client {
+	return newClient(timeout, dialControl)
+}
+
+func newClient(timeout time.Duration, control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: control,
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConnsPerHost:   2,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		// A redirect could point at an internal address, the 3xx counts as a failed attempt
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
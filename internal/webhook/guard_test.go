@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestCheckAddress(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34":        true,
+		"2606:2800:220:1::248": true,
+		"127.0.0.1":            false,
+		"::1":                  false,
+		"10.1.2.3":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"fe80::1":              false,
+		"fd00::1":              false,
+		"0.0.0.0":              false,
+		"::":                   false,
+		"::ffff:127.0.0.1":     false,
+		"::ffff:10.0.0.1":      false,
+		"224.0.0.1":            false,
+		"0.1.2.3":              false,
+		"100.64.0.1":           false,
+		"100.127.255.254":      false,
+		"100.128.0.1":          true,
+		"64:ff9b::7f00:1":      false,
+		"64:ff9b::a9fe:a9fe":   false,
+		"64:ff9b::5db8:d822":   true,
+		"2002:a00:1::1":        false,
+		"2002:6440:1::1":       false,
+		"2002:5db8:d822::1":    true,
+	}
+
+	for address, allowed := range tests {
+		err := CheckAddress(netip.MustParseAddr(address))
+		if (err == nil) != allowed {
+			t.Errorf("%s: got %v, want allowed %v", address, err, allowed)
+		}
+		if err != nil && !errors.Is(err, ErrForbiddenTarget) {
+			t.Errorf("%s: got %v, want %v", address, err, ErrForbiddenTarget)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	tests := map[string]bool{
+		"hooks.example.com": true,
+		"93.184.216.34":     true,
+		"localhost":         false,
+		"LOCALHOST.":        false,
+		"api.localhost":     false,
+		"127.0.0.1":         false,
+		"[::1]":             false,
+		"::1":               false,
+		"169.254.169.254":   false,
+	}
+
+	for host, allowed := range tests {
+		if err := CheckHost(host); (err == nil) != allowed {
+			t.Errorf("%s: got %v, want allowed %v", host, err, allowed)
+		}
+	}
+}
+
+func TestNewClientRefusesInternalAddresses(t *testing.T) {
+	hit := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer server.Close()
+
+	resp, err := NewClient(time.Second).Post(server.URL, "application/json", nil)
+	if err == nil {
+		resp.Body.Close()
+	}
+	if !errors.Is(err, ErrForbiddenTarget) {
+		t.Errorf("got %v, want %v", err, ErrForbiddenTarget)
+	}
+	if hit {
+		t.Error("request reached a loopback server")
+	}
+}
+
+func TestClientDoesNotFollowRedirects(t *testing.T) {
+	followed := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hook", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	})
+	mux.HandleFunc("/internal", func(w http.ResponseWriter, r *http.Request) {
+		followed = true
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// The loopback server is only reachable without the address check
+	resp, err := newClient(time.Second, nil).Post(server.URL+"/hook", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound || followed {
+		t.Errorf("got status %d, followed %v, want the redirect itself", resp.StatusCode, followed)
+	}
+}
@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Ravwvil/feedback/internal/models"
+	"github.com/Ravwvil/feedback/internal/repository"
+)
+
+// eventFeedback is the part of an event payload telling whether the event concerns a draft
+type eventFeedback struct {
+	Feedback *struct {
+		Status   string `json:"status"`
+		AuthorID int64  `json:"author_id"`
+	} `json:"feedback"`
+}
+
+// Publisher queues a delivery of every relayed event for the matching subscriptions.
+// It plugs into the outbox relay, the Dispatcher sends the queued deliveries.
+type Publisher struct {
+	repo *repository.FeedbackRepository
+}
+
+func NewPublisher(repo *repository.FeedbackRepository) *Publisher {
+	return &Publisher{
+		repo: repo,
+	}
+}
+
+// Publish queues the event, publishing it again does not queue it twice. Events on drafts only
+// go to subscriptions of the draft's author, the only user who can see it.
+func (p *Publisher) Publish(ctx context.Context, event *models.DomainEvent) error {
+	draft, authorID, err := p.draftOf(ctx, event)
+	if err != nil {
+		return err
+	}
+	if !draft {
+		authorID = 0
+	} else if authorID == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	if _, err := p.repo.EnqueueWebhookDeliveries(ctx, event, payload, authorID); err != nil {
+		return fmt.Errorf("failed to queue webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// draftOf reports whether the event concerns a draft and who wrote it. Events carrying a feedback
+// snapshot are judged by the status they were recorded with, others by the current status.
+// Feedback that is gone by now counts as a draft without author, except for purge events.
+func (p *Publisher) draftOf(ctx context.Context, event *models.DomainEvent) (bool, int64, error) {
+	var data eventFeedback
+	if len(event.Data) > 0 {
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return false, 0, fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+		}
+	}
+	if data.Feedback != nil {
+		return data.Feedback.Status == models.StatusDraft, data.Feedback.AuthorID, nil
+	}
+	if event.Type == models.EventFeedbackPurged {
+		return false, 0, nil
+	}
+
+	feedback, err := p.repo.GetByID(ctx, event.FeedbackID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return true, 0, nil
+	}
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to get feedback: %w", err)
+	}
+	return feedback.Status == models.StatusDraft, feedback.AuthorID, nil
+}
@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Ravwvil/feedback/internal/models"
+)
+
+func TestDraftOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    *models.DomainEvent
+		draft    bool
+		authorID int64
+	}{
+		{
+			name:     "draft created",
+			event:    &models.DomainEvent{Type: models.EventFeedbackCreated, Data: json.RawMessage(`{"feedback":{"status":"draft","author_id":7}}`)},
+			draft:    true,
+			authorID: 7,
+		},
+		{
+			name:     "comment on a draft",
+			event:    &models.DomainEvent{Type: models.EventCommentCreated, Data: json.RawMessage(`{"feedback":{"status":"draft","author_id":7},"comment":{"content":"secret"}}`)},
+			draft:    true,
+			authorID: 7,
+		},
+		{
+			name:     "published update",
+			event:    &models.DomainEvent{Type: models.EventFeedbackUpdated, Data: json.RawMessage(`{"feedback":{"status":"published","author_id":7}}`)},
+			authorID: 7,
+		},
+		{
+			name:  "purge",
+			event: &models.DomainEvent{Type: models.EventFeedbackPurged, Data: json.RawMessage(`{}`)},
+		},
+	}
+
+	// None of the cases needs the current feedback
+	p := &Publisher{}
+	for _, tt := range tests {
+		draft, authorID, err := p.draftOf(context.Background(), tt.event)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if draft != tt.draft || authorID != tt.authorID {
+			t.Errorf("%s: got (%v, %d), want (%v, %d)", tt.name, draft, authorID, tt.draft, tt.authorID)
+		}
+	}
+
+	if _, _, err := p.draftOf(context.Background(), &models.DomainEvent{Data: json.RawMessage(`{not json`)}); err == nil {
+		t.Error("undecodable event was accepted")
+	}
+}
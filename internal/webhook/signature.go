@@ -0,0 +1,38 @@
+// Package webhook delivers domain events to HTTP endpoints.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Feedback-Event"
+	HeaderDelivery  = "X-Feedback-Delivery"
+	HeaderTimestamp = "X-Feedback-Timestamp"
+	HeaderSignature = "X-Feedback-Signature"
+)
+
+const signaturePrefix = "sha256="
+
+// Sign returns the signature header value of a delivery, an HMAC-SHA256 over the timestamp,
+// a dot and the body. Receivers recompute it with the shared secret and reject stale timestamps.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid signature of timestamp and body
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,12 @@
+-- Asset metadata used for quota accounting
+CREATE TABLE IF NOT EXISTS feedback_assets (
+    id UUID NOT NULL PRIMARY KEY,
+    feedback_id UUID NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (feedback_id, filename)
+);
+
+CREATE INDEX idx_feedback_assets_feedback_id ON feedback_assets(feedback_id);
@@ -0,0 +1,2 @@
+-- Track which thumbnail sizes have been generated for an asset
+ALTER TABLE feedback_assets ADD COLUMN thumbnail_sizes INTEGER[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,4 @@
+-- Malware scan state of assets, anything but 'clean' is quarantined
+ALTER TABLE feedback_assets ADD COLUMN scan_status VARCHAR(16) NOT NULL DEFAULT 'clean';
+ALTER TABLE feedback_assets ALTER COLUMN scan_status SET DEFAULT 'pending';
+ALTER TABLE feedback_assets ADD COLUMN scan_signature VARCHAR(255);
@@ -0,0 +1,14 @@
+-- Feedback lifecycle: draft -> published -> acknowledged -> resolved -> archived
+-- Existing feedback was visible to students already, keep it published
+ALTER TABLE feedback_files ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'published';
+ALTER TABLE feedback_files ALTER COLUMN status SET DEFAULT 'draft';
+ALTER TABLE feedback_files ADD COLUMN author_id BIGINT;
+ALTER TABLE feedback_files ADD COLUMN published_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE feedback_files ADD COLUMN acknowledged_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE feedback_files ADD COLUMN resolved_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE feedback_files ADD COLUMN archived_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE feedback_files SET published_at = created_at WHERE status = 'published';
+
+CREATE INDEX idx_feedback_status ON feedback_files(status);
+CREATE INDEX idx_feedback_author_id ON feedback_files(author_id);
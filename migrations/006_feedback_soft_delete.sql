@@ -0,0 +1,5 @@
+-- Deleted feedback is kept in the trash until the purge job removes it
+ALTER TABLE feedback_files ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE feedback_files ADD COLUMN deleted_by BIGINT;
+
+CREATE INDEX idx_feedback_deleted_at ON feedback_files(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,18 @@
+-- Tag vocabulary of each lab
+CREATE TABLE lab_tags (
+    id SERIAL PRIMARY KEY,
+    lab_id BIGINT NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (lab_id, name)
+);
+
+-- Tags applied to feedback
+CREATE TABLE feedback_tags (
+    feedback_id UUID NOT NULL,
+    tag_id INTEGER NOT NULL REFERENCES lab_tags(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (feedback_id, tag_id)
+);
+
+CREATE INDEX idx_feedback_tags_tag_id ON feedback_tags(tag_id);
@@ -0,0 +1,24 @@
+-- Rubric definitions are versioned per lab, a new definition never changes existing scores
+CREATE TABLE rubrics (
+    id UUID PRIMARY KEY,
+    lab_id BIGINT NOT NULL,
+    version INTEGER NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    criteria JSONB NOT NULL,
+    created_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (lab_id, version)
+);
+
+-- Rubric score of a feedback, criteria holds the per-criterion points and comments
+CREATE TABLE feedback_scores (
+    feedback_id UUID PRIMARY KEY,
+    rubric_id UUID NOT NULL REFERENCES rubrics(id),
+    criteria JSONB NOT NULL,
+    total NUMERIC(10, 2) NOT NULL,
+    max_total NUMERIC(10, 2) NOT NULL,
+    scored_by BIGINT,
+    scored_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_feedback_scores_rubric_id ON feedback_scores(rubric_id);
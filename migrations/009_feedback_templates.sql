@@ -0,0 +1,13 @@
+-- Markdown skeletons for feedback, lab_id NULL marks a global template
+CREATE TABLE feedback_templates (
+    id UUID PRIMARY KEY,
+    lab_id BIGINT,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    created_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_feedback_templates_name ON feedback_templates(COALESCE(lab_id, 0), name);
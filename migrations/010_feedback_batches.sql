@@ -0,0 +1,9 @@
+-- Items of batch creations, a retried batch returns the feedback created by the first attempt.
+-- feedback_id is NULL while the item is being created.
+CREATE TABLE feedback_batch_items (
+    batch_id VARCHAR(128) NOT NULL,
+    item_key VARCHAR(128) NOT NULL,
+    feedback_id UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (batch_id, item_key)
+);
@@ -0,0 +1,14 @@
+-- Responses of mutating RPCs keyed by the client supplied idempotency key.
+-- response is NULL while the first request with the key is still running.
+CREATE TABLE idempotency_keys (
+    caller_id BIGINT NOT NULL,
+    method VARCHAR(255) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    fingerprint CHAR(64) NOT NULL,
+    response BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (caller_id, method, idempotency_key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
@@ -0,0 +1,4 @@
+-- Keyset pagination orders by the sort column with the id as tie breaker
+CREATE INDEX idx_feedback_created_at_id ON feedback_files(created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_feedback_updated_at_id ON feedback_files(updated_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_feedback_title_id ON feedback_files(title, id) WHERE deleted_at IS NULL;
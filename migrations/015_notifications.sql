@@ -0,0 +1,27 @@
+-- How a user wants to be notified, users without a row are notified immediately
+CREATE TABLE notification_preferences (
+    user_id BIGINT PRIMARY KEY,
+    mode VARCHAR(20) NOT NULL DEFAULT 'immediate',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- One notification per event and recipient. Immediate notifications are sent by the
+-- notification sender, digest ones wait for the recipient's next digest.
+CREATE TABLE notifications (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL,
+    user_id BIGINT NOT NULL,
+    kind VARCHAR(64) NOT NULL,
+    feedback_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    sent_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (event_id, user_id)
+);
+
+CREATE INDEX idx_notifications_pending ON notifications(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_notifications_digest ON notifications(user_id, id) WHERE status = 'digest';
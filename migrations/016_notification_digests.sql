@@ -0,0 +1,23 @@
+-- Digests bundle the notifications of a user collected since the previous digest. A
+-- notification belongs to at most one digest, so restarts neither repeat nor skip any.
+CREATE TABLE notification_digests (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    period VARCHAR(20) NOT NULL,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    notification_count INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    sent_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (user_id, period_end)
+);
+
+CREATE INDEX idx_notification_digests_pending ON notification_digests(next_attempt_at) WHERE status = 'pending';
+
+ALTER TABLE notifications ADD COLUMN digest_id BIGINT REFERENCES notification_digests(id);
+
+CREATE INDEX idx_notifications_digest_id ON notifications(digest_id) WHERE digest_id IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Identify the content of an asset so that background work on a replaced upload can be discarded
+ALTER TABLE feedback_assets ADD COLUMN content_hash VARCHAR(64) NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+-- Batch items belong to the caller who created them and remember what was requested under their key.
+-- reserved_until bounds a reservation, an item without feedback_id may be taken over after it passed.
+ALTER TABLE feedback_batch_items ADD COLUMN caller_id BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE feedback_batch_items ADD COLUMN fingerprint VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE feedback_batch_items ADD COLUMN reserved_until TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE feedback_batch_items DROP CONSTRAINT feedback_batch_items_pkey;
+ALTER TABLE feedback_batch_items ADD PRIMARY KEY (caller_id, batch_id, item_key);
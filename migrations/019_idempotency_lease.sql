@@ -0,0 +1,5 @@
+-- reserved_until bounds how long a request may hold its idempotency key without a response,
+-- once it passed a retry with the same payload may take the key over.
+ALTER TABLE idempotency_keys ADD COLUMN reserved_until TIMESTAMP WITH TIME ZONE;
+
+UPDATE idempotency_keys SET reserved_until = created_at + INTERVAL '5 minutes' WHERE response IS NULL;
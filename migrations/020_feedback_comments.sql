@@ -0,0 +1,11 @@
+-- Comments on feedback, a reply references the comment it answers on the same feedback
+CREATE TABLE feedback_comments (
+    id UUID PRIMARY KEY,
+    feedback_id UUID NOT NULL,
+    parent_id UUID REFERENCES feedback_comments(id) ON DELETE CASCADE,
+    author_id BIGINT NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_feedback_comments_feedback_id ON feedback_comments(feedback_id, created_at);
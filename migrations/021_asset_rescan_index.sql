@@ -0,0 +1,2 @@
+-- The rescan job looks up assets without a clean or infected result, oldest first
+CREATE INDEX idx_feedback_assets_unscanned ON feedback_assets(created_at, id) WHERE scan_status IN ('pending', 'error');